@@ -83,6 +83,17 @@ type CloseIdler interface {
 	CloseIdleConnections()
 }
 
+// StreamingClient is implemented by Clients that can return a response without
+// reading its body first, as needed for streaming endpoints (e.g. server-sent
+// events). The Client returned by NewClient implements StreamingClient. Note
+// that a timeout configured for the http.Client in Config also limits the
+// duration of a stream.
+type StreamingClient interface {
+	// DoStream sends the request and returns the response with its body
+	// unread. The caller is responsible for closing the body.
+	DoStream(context.Context, *http.Request) (*http.Response, error)
+}
+
 // NewClient returns a new Client.
 //
 // It is safe to use the returned Client from multiple goroutines.
@@ -126,6 +137,13 @@ func (c *httpClient) CloseIdleConnections() {
 	c.client.CloseIdleConnections()
 }
 
+func (c *httpClient) DoStream(ctx context.Context, req *http.Request) (*http.Response, error) {
+	if ctx != nil {
+		req = req.WithContext(ctx)
+	}
+	return c.client.Do(req)
+}
+
 func (c *httpClient) Do(ctx context.Context, req *http.Request) (*http.Response, []byte, error) {
 	if ctx != nil {
 		req = req.WithContext(ctx)
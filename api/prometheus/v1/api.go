@@ -13,6 +13,11 @@
 
 // Package v1 provides bindings to the Prometheus HTTP API v1:
 // http://prometheus.io/docs/querying/api/
+//
+// Query results can be exported as tables with ExportMatrix and ExportVector,
+// or as CSV with WriteMatrixCSV and WriteVectorCSV. Arrow and Parquet output is
+// not provided, as it would add large dependencies to this package. Implement
+// a RowWriter adapter for those formats instead.
 package v1
 
 import (
@@ -381,6 +386,8 @@ const (
 	epRuntimeinfo     = apiPrefix + "/status/runtimeinfo"
 	epTSDB            = apiPrefix + "/status/tsdb"
 	epWalReplay       = apiPrefix + "/status/walreplay"
+
+	epNotificationsLive = apiPrefix + "/notifications/live"
 )
 
 // AlertState models the state of an alert.
@@ -415,6 +422,16 @@ const (
 	ErrBadResponse ErrorType = "bad_response"
 	ErrServer      ErrorType = "server_error"
 	ErrClient      ErrorType = "client_error"
+	// ErrAdminAPIDisabled is returned by the TSDB admin methods (Snapshot,
+	// DeleteSeries, CleanTombstones) if the API has been created without
+	// the EnableAdminAPI option, or if the admin API is disabled on the
+	// Prometheus server (which is the default, see the
+	// --web.enable-admin-api flag).
+	ErrAdminAPIDisabled ErrorType = "admin_api_disabled"
+	// ErrCircuitOpen is returned without contacting the server if the
+	// circuit breaker configured with WithCircuitBreaker is open for the
+	// endpoint class of the request.
+	ErrCircuitOpen ErrorType = "circuit_open"
 
 	// Possible values for HealthStatus.
 	HealthGood    HealthStatus = "up"
@@ -467,10 +484,12 @@ type API interface {
 	// AlertManagers returns an overview of the current state of the Prometheus alert manager discovery.
 	AlertManagers(ctx context.Context) (AlertManagersResult, error)
 	// CleanTombstones removes the deleted data from disk and cleans up the existing tombstones.
+	// It requires the EnableAdminAPI option, see there.
 	CleanTombstones(ctx context.Context) error
 	// Config returns the current Prometheus configuration.
 	Config(ctx context.Context) (ConfigResult, error)
 	// DeleteSeries deletes data for a selection of series in a time range.
+	// It requires the EnableAdminAPI option, see there.
 	DeleteSeries(ctx context.Context, matches []string, startTime, endTime time.Time) error
 	// Flags returns the flag values that Prometheus was launched with.
 	Flags(ctx context.Context) (FlagsResult, error)
@@ -492,6 +511,7 @@ type API interface {
 	Series(ctx context.Context, matches []string, startTime, endTime time.Time, opts ...Option) ([]model.LabelSet, Warnings, error)
 	// Snapshot creates a snapshot of all current data into snapshots/<datetime>-<rand>
 	// under the TSDB's data directory and returns the directory as response.
+	// It requires the EnableAdminAPI option, see there.
 	Snapshot(ctx context.Context, skipHead bool) (SnapshotResult, error)
 	// Rules returns a list of alerting and recording rules that are currently loaded.
 	Rules(ctx context.Context) (RulesResult, error)
@@ -670,6 +690,98 @@ type queryResult struct {
 
 	// The decoded value.
 	v model.Value
+	// The decoded stats, nil if the server hasn't returned any.
+	stats *QueryStats
+}
+
+// QueryStats contains the statistics of a query evaluation as returned by
+// Prometheus if requested with the WithStats option.
+type QueryStats struct {
+	Timings QueryTimings `json:"timings"`
+	// Samples is nil if the server hasn't returned sample statistics.
+	Samples *QuerySamples `json:"samples,omitempty"`
+}
+
+// QueryTimings contains the time spent in the phases of a query evaluation,
+// in seconds.
+type QueryTimings struct {
+	EvalTotalTime        float64 `json:"evalTotalTime"`
+	ResultSortTime       float64 `json:"resultSortTime"`
+	QueryPreparationTime float64 `json:"queryPreparationTime"`
+	InnerEvalTime        float64 `json:"innerEvalTime"`
+	ExecQueueTime        float64 `json:"execQueueTime"`
+	ExecTotalTime        float64 `json:"execTotalTime"`
+}
+
+// QuerySamples contains the sample statistics of a query evaluation.
+type QuerySamples struct {
+	// TotalQueryableSamplesPerStep is only returned if per-step statistics
+	// have been requested (see WithStats). For an instant query, it
+	// contains a single step.
+	TotalQueryableSamplesPerStep []StepSamples `json:"totalQueryableSamplesPerStep,omitempty"`
+	// TotalQueryableSamples is the total number of samples loaded from
+	// storage to evaluate the query.
+	TotalQueryableSamples int64 `json:"totalQueryableSamples"`
+	// PeakSamples is the maximum number of samples kept in memory at the
+	// same time during the evaluation of the query.
+	PeakSamples int64 `json:"peakSamples"`
+}
+
+// StepSamples is the number of samples loaded from storage to evaluate a
+// single step of a query.
+type StepSamples struct {
+	Timestamp model.Time
+	Samples   int64
+}
+
+// UnmarshalJSON implements json.Unmarshaler. A StepSamples is encoded as a
+// JSON array with the timestamp in seconds and the number of samples.
+func (s *StepSamples) UnmarshalJSON(b []byte) error {
+	var v [2]json.Number
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	// Decode the timestamp from its decimal representation, as converting
+	// it via a float64 loses millisecond precision.
+	var ts model.Time
+	if err := ts.UnmarshalJSON([]byte(v[0])); err != nil {
+		return fmt.Errorf("invalid step timestamp: %w", err)
+	}
+	samples, err := v[1].Int64()
+	if err != nil {
+		return fmt.Errorf("invalid number of step samples: %w", err)
+	}
+	s.Timestamp = ts
+	s.Samples = samples
+	return nil
+}
+
+// TotalSamplesFromSteps returns the sum of the samples of all steps. It is
+// equal to TotalQueryableSamples, but only available if per-step statistics
+// have been requested. It is useful to verify or to partially sum up the
+// per-step statistics.
+func (s QuerySamples) TotalSamplesFromSteps() int64 {
+	var total int64
+	for _, step := range s.TotalQueryableSamplesPerStep {
+		total += step.Samples
+	}
+	return total
+}
+
+// PeakStep returns the step in which the most samples had to be loaded from
+// storage, and false if no per-step statistics are available. If several
+// steps have the same number of samples, the earliest one is returned.
+func (s QuerySamples) PeakStep() (StepSamples, bool) {
+	if len(s.TotalQueryableSamplesPerStep) == 0 {
+		return StepSamples{}, false
+	}
+	peak := s.TotalQueryableSamplesPerStep[0]
+	for _, step := range s.TotalQueryableSamplesPerStep[1:] {
+		if step.Samples > peak.Samples {
+			peak = step
+		}
+	}
+	return peak, true
 }
 
 // TSDBResult contains the result from querying the tsdb endpoint.
@@ -822,12 +934,14 @@ func (qr *queryResult) UnmarshalJSON(b []byte) error {
 	v := struct {
 		Type   model.ValueType `json:"resultType"`
 		Result json.RawMessage `json:"result"`
+		Stats  *QueryStats     `json:"stats"`
 	}{}
 
 	err := json.Unmarshal(b, &v)
 	if err != nil {
 		return err
 	}
+	qr.stats = v.Stats
 
 	switch v.Type {
 	case model.ValScalar:
@@ -863,19 +977,80 @@ type ExemplarQueryResult struct {
 	Exemplars    []Exemplar     `json:"exemplars"`
 }
 
-// NewAPI returns a new API for the client.
+// NewAPI returns a new API for the client, configured by the provided
+// APIOptions.
 //
 // It is safe to use the returned API from multiple goroutines.
-func NewAPI(c api.Client) API {
-	return &httpAPI{
+func NewAPI(c api.Client, opts ...APIOption) API {
+	h := &httpAPI{
 		client: &apiClientImpl{
 			client: c,
 		},
 	}
+	for _, o := range opts {
+		o(h)
+	}
+	return h
+}
+
+// APIOption configures the API returned by NewAPI.
+type APIOption func(h *httpAPI)
+
+// EnableAdminAPI enables the TSDB admin methods of the API, i.e. Snapshot,
+// DeleteSeries, and CleanTombstones. These methods can cause data loss or
+// consume significant disk space on the Prometheus server. As a safety
+// interlock, they fail with an *Error of type ErrAdminAPIDisabled (without
+// contacting the server) unless the API has been created with this option.
+//
+// Note that the admin API also has to be enabled on the Prometheus server
+// with the --web.enable-admin-api flag. Otherwise, the server rejects the
+// requests, which is also reported as an *Error of type ErrAdminAPIDisabled.
+func EnableAdminAPI() APIOption {
+	return func(h *httpAPI) {
+		h.adminAPIEnabled = true
+	}
 }
 
 type httpAPI struct {
-	client apiClient
+	client          apiClient
+	adminAPIEnabled bool
+}
+
+// errAdminAPINotEnabled returns the error returned by the admin methods if the
+// API has been created without EnableAdminAPI.
+func errAdminAPINotEnabled() error {
+	return &Error{
+		Type: ErrAdminAPIDisabled,
+		Msg:  "admin API not enabled in the client, see EnableAdminAPI",
+	}
+}
+
+// adminAPIDisabledMsg is the error message of the server if the admin API is
+// disabled.
+const adminAPIDisabledMsg = "admin APIs disabled"
+
+// adminAPIError converts an error returned by the server because the admin
+// API is disabled into an *Error of type ErrAdminAPIDisabled. All other errors
+// are returned unchanged.
+func adminAPIError(err error) error {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Type != ErrServer {
+		return err
+	}
+	// The server responds with 503 and an API error of type "unavailable",
+	// which is also used for other conditions (e.g. the TSDB not being
+	// ready yet), so the message has to be checked, too.
+	var result apiResponse
+	if json.Unmarshal([]byte(apiErr.Detail), &result) != nil ||
+		result.ErrorType != "unavailable" ||
+		!strings.Contains(result.Error, adminAPIDisabledMsg) {
+		return err
+	}
+	return &Error{
+		Type:   ErrAdminAPIDisabled,
+		Msg:    result.Error,
+		Detail: apiErr.Detail,
+	}
 }
 
 func (h *httpAPI) Alerts(ctx context.Context) (AlertsResult, error) {
@@ -915,6 +1090,9 @@ func (h *httpAPI) AlertManagers(ctx context.Context) (AlertManagersResult, error
 }
 
 func (h *httpAPI) CleanTombstones(ctx context.Context) error {
+	if !h.adminAPIEnabled {
+		return errAdminAPINotEnabled()
+	}
 	u := h.client.URL(epCleanTombstones, nil)
 
 	req, err := http.NewRequest(http.MethodPost, u.String(), nil)
@@ -923,7 +1101,7 @@ func (h *httpAPI) CleanTombstones(ctx context.Context) error {
 	}
 
 	_, _, _, err = h.client.Do(ctx, req)
-	return err
+	return adminAPIError(err)
 }
 
 func (h *httpAPI) Config(ctx context.Context) (ConfigResult, error) {
@@ -945,6 +1123,9 @@ func (h *httpAPI) Config(ctx context.Context) (ConfigResult, error) {
 }
 
 func (h *httpAPI) DeleteSeries(ctx context.Context, matches []string, startTime, endTime time.Time) error {
+	if !h.adminAPIEnabled {
+		return errAdminAPINotEnabled()
+	}
 	u := h.client.URL(epDeleteSeries, nil)
 	q := u.Query()
 
@@ -967,7 +1148,7 @@ func (h *httpAPI) DeleteSeries(ctx context.Context, matches []string, startTime,
 	}
 
 	_, _, _, err = h.client.Do(ctx, req)
-	return err
+	return adminAPIError(err)
 }
 
 func (h *httpAPI) Flags(ctx context.Context) (FlagsResult, error) {
@@ -1077,8 +1258,10 @@ func (h *httpAPI) LabelValues(ctx context.Context, label string, matches []strin
 }
 
 type apiOptions struct {
-	timeout time.Duration
-	limit   uint64
+	timeout      time.Duration
+	limit        uint64
+	stats        *QueryStats
+	statsPerStep bool
 }
 
 type Option func(c *apiOptions)
@@ -1099,11 +1282,43 @@ func WithLimit(limit uint64) Option {
 	}
 }
 
-func addOptionalURLParams(q url.Values, opts []Option) url.Values {
+// WithStats requests the statistics of the query evaluation for Query and
+// QueryRange. The statistics returned by the server are stored in the provided
+// QueryStats. If perStep is true, the number of samples loaded per step of the
+// evaluation is requested, too (see QuerySamples). Statistics are only
+// returned by Prometheus v2.35 and later. If the server doesn't return any,
+// the provided QueryStats is left unchanged. All other methods ignore this
+// option.
+func WithStats(stats *QueryStats, perStep bool) Option {
+	return func(o *apiOptions) {
+		o.stats = stats
+		o.statsPerStep = perStep
+	}
+}
+
+func newAPIOptions(opts []Option) *apiOptions {
 	opt := &apiOptions{}
 	for _, o := range opts {
 		o(opt)
 	}
+	return opt
+}
+
+// decodeQueryResult decodes the query result in body and stores any returned
+// stats as requested by opts.
+func decodeQueryResult(body []byte, opts []Option) (model.Value, error) {
+	var qres queryResult
+	if err := json.Unmarshal(body, &qres); err != nil {
+		return nil, err
+	}
+	if opt := newAPIOptions(opts); opt.stats != nil && qres.stats != nil {
+		*opt.stats = *qres.stats
+	}
+	return qres.v, nil
+}
+
+func addOptionalURLParams(q url.Values, opts []Option) url.Values {
+	opt := newAPIOptions(opts)
 
 	if opt.timeout > 0 {
 		q.Set("timeout", opt.timeout.String())
@@ -1116,9 +1331,25 @@ func addOptionalURLParams(q url.Values, opts []Option) url.Values {
 	return q
 }
 
+// addQueryURLParams is like addOptionalURLParams, but also adds the
+// parameters only supported by the query endpoints.
+func addQueryURLParams(q url.Values, opts []Option) url.Values {
+	q = addOptionalURLParams(q, opts)
+
+	if opt := newAPIOptions(opts); opt.stats != nil {
+		if opt.statsPerStep {
+			q.Set("stats", "all")
+		} else {
+			q.Set("stats", "true")
+		}
+	}
+
+	return q
+}
+
 func (h *httpAPI) Query(ctx context.Context, query string, ts time.Time, opts ...Option) (model.Value, Warnings, error) {
 	u := h.client.URL(epQuery, nil)
-	q := addOptionalURLParams(u.Query(), opts)
+	q := addQueryURLParams(u.Query(), opts)
 
 	q.Set("query", query)
 	if !ts.IsZero() {
@@ -1130,13 +1361,13 @@ func (h *httpAPI) Query(ctx context.Context, query string, ts time.Time, opts ..
 		return nil, warnings, err
 	}
 
-	var qres queryResult
-	return qres.v, warnings, json.Unmarshal(body, &qres)
+	v, err := decodeQueryResult(body, opts)
+	return v, warnings, err
 }
 
 func (h *httpAPI) QueryRange(ctx context.Context, query string, r Range, opts ...Option) (model.Value, Warnings, error) {
 	u := h.client.URL(epQueryRange, nil)
-	q := addOptionalURLParams(u.Query(), opts)
+	q := addQueryURLParams(u.Query(), opts)
 
 	q.Set("query", query)
 	q.Set("start", formatTime(r.Start))
@@ -1148,8 +1379,8 @@ func (h *httpAPI) QueryRange(ctx context.Context, query string, r Range, opts ..
 		return nil, warnings, err
 	}
 
-	var qres queryResult
-	return qres.v, warnings, json.Unmarshal(body, &qres)
+	v, err := decodeQueryResult(body, opts)
+	return v, warnings, err
 }
 
 func (h *httpAPI) Series(ctx context.Context, matches []string, startTime, endTime time.Time, opts ...Option) ([]model.LabelSet, Warnings, error) {
@@ -1177,6 +1408,9 @@ func (h *httpAPI) Series(ctx context.Context, matches []string, startTime, endTi
 }
 
 func (h *httpAPI) Snapshot(ctx context.Context, skipHead bool) (SnapshotResult, error) {
+	if !h.adminAPIEnabled {
+		return SnapshotResult{}, errAdminAPINotEnabled()
+	}
 	u := h.client.URL(epSnapshot, nil)
 	q := u.Query()
 
@@ -1191,7 +1425,7 @@ func (h *httpAPI) Snapshot(ctx context.Context, skipHead bool) (SnapshotResult,
 
 	_, body, _, err := h.client.Do(ctx, req)
 	if err != nil {
-		return SnapshotResult{}, err
+		return SnapshotResult{}, adminAPIError(err)
 	}
 
 	var res SnapshotResult
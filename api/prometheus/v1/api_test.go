@@ -30,6 +30,8 @@ import (
 	json "github.com/json-iterator/go"
 
 	"github.com/prometheus/common/model"
+
+	"github.com/prometheus/client_golang/api"
 )
 
 type apiTest struct {
@@ -104,7 +106,8 @@ func TestAPIs(t *testing.T) {
 		T: t,
 	}
 	promAPI := &httpAPI{
-		client: tc,
+		client:          tc,
+		adminAPIEnabled: true,
 	}
 
 	doAlertManagers := func() func() (interface{}, Warnings, error) {
@@ -1862,3 +1865,180 @@ func TestDoGetFallback(t *testing.T) {
 		t.Fatalf("Mismatch in values")
 	}
 }
+
+func TestAdminAPIInterlock(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"status":"error","errorType":"unavailable","error":"admin APIs disabled"}`))
+	}))
+	defer server.Close()
+
+	client, err := api.NewClient(api.Config{Address: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	adminCalls := map[string]func(API) error{
+		"Snapshot": func(a API) error {
+			_, err := a.Snapshot(context.Background(), false)
+			return err
+		},
+		"DeleteSeries": func(a API) error {
+			return a.DeleteSeries(context.Background(), []string{"up"}, time.Time{}, time.Time{})
+		},
+		"CleanTombstones": func(a API) error {
+			return a.CleanTombstones(context.Background())
+		},
+	}
+
+	for name, call := range adminCalls {
+		t.Run(name, func(t *testing.T) {
+			requests = 0
+
+			// Without EnableAdminAPI, no request is sent.
+			err := call(NewAPI(client))
+			var apiErr *Error
+			if !errors.As(err, &apiErr) || apiErr.Type != ErrAdminAPIDisabled {
+				t.Fatalf("expected error of type %s, got %v", ErrAdminAPIDisabled, err)
+			}
+			if requests != 0 {
+				t.Fatalf("expected no request, got %d", requests)
+			}
+
+			// With EnableAdminAPI, the server rejection is typed, too.
+			err = call(NewAPI(client, EnableAdminAPI()))
+			if !errors.As(err, &apiErr) || apiErr.Type != ErrAdminAPIDisabled {
+				t.Fatalf("expected error of type %s, got %v", ErrAdminAPIDisabled, err)
+			}
+			if apiErr.Msg != "admin APIs disabled" {
+				t.Errorf("unexpected error message %q", apiErr.Msg)
+			}
+			if requests != 1 {
+				t.Fatalf("expected one request, got %d", requests)
+			}
+		})
+	}
+}
+
+func TestAdminAPIOtherUnavailableError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"status":"error","errorType":"unavailable","error":"TSDB not ready"}`))
+	}))
+	defer server.Close()
+
+	client, err := api.NewClient(api.Config{Address: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewAPI(client, EnableAdminAPI()).Snapshot(context.Background(), false)
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *Error, got %v", err)
+	}
+	if apiErr.Type == ErrAdminAPIDisabled {
+		t.Errorf("unexpected error type %s for unrelated unavailability", apiErr.Type)
+	}
+}
+
+func TestQueryStats(t *testing.T) {
+	var statsParam string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		statsParam = r.Form.Get("stats")
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/series") {
+			w.Write([]byte(`{"status":"success","data":[]}`))
+			return
+		}
+		w.Write([]byte(`{"status":"success","data":{
+			"resultType":"matrix",
+			"result":[],
+			"stats":{
+				"timings":{"evalTotalTime":0.5,"resultSortTime":0,"queryPreparationTime":0.1,"innerEvalTime":0.3,"execQueueTime":0.01,"execTotalTime":0.6},
+				"samples":{
+					"totalQueryableSamplesPerStep":[[1700000000,10],[1700000015.5,30],[1700000031,30]],
+					"totalQueryableSamples":70,
+					"peakSamples":25
+				}
+			}
+		}}`))
+	}))
+	defer server.Close()
+
+	client, err := api.NewClient(api.Config{Address: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	promAPI := NewAPI(client)
+	r := Range{Start: time.Unix(1700000000, 0), End: time.Unix(1700000031, 0), Step: 15 * time.Second}
+
+	var stats QueryStats
+	if _, _, err := promAPI.QueryRange(context.Background(), "up", r, WithStats(&stats, true)); err != nil {
+		t.Fatal(err)
+	}
+	if statsParam != "all" {
+		t.Errorf("got stats parameter %q, want %q", statsParam, "all")
+	}
+	if stats.Timings.ExecTotalTime != 0.6 {
+		t.Errorf("got exec total time %v, want 0.6", stats.Timings.ExecTotalTime)
+	}
+	if stats.Samples == nil {
+		t.Fatal("no sample stats decoded")
+	}
+	if got := stats.Samples.TotalQueryableSamples; got != 70 {
+		t.Errorf("got %d total queryable samples, want 70", got)
+	}
+	if got := stats.Samples.PeakSamples; got != 25 {
+		t.Errorf("got %d peak samples, want 25", got)
+	}
+	if got := stats.Samples.TotalSamplesFromSteps(); got != 70 {
+		t.Errorf("got %d total samples from steps, want 70", got)
+	}
+	peak, ok := stats.Samples.PeakStep()
+	if want := (StepSamples{Timestamp: model.TimeFromUnixNano(1700000015500000000), Samples: 30}); !ok || peak != want {
+		t.Errorf("got peak step %v, want %v", peak, want)
+	}
+
+	if _, _, err := promAPI.Query(context.Background(), "up", time.Time{}, WithStats(&stats, false)); err != nil {
+		t.Fatal(err)
+	}
+	if statsParam != "true" {
+		t.Errorf("got stats parameter %q, want %q", statsParam, "true")
+	}
+
+	// Without WithStats, no stats are requested.
+	if _, _, err := promAPI.Query(context.Background(), "up", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if statsParam != "" {
+		t.Errorf("got stats parameter %q, want none", statsParam)
+	}
+
+	// Stats are only requested from the query endpoints.
+	if _, _, err := promAPI.Series(context.Background(), []string{"up"}, time.Time{}, time.Time{}, WithStats(&stats, true)); err != nil {
+		t.Fatal(err)
+	}
+	if statsParam != "" {
+		t.Errorf("got stats parameter %q for series, want none", statsParam)
+	}
+}
+
+func TestStepSamplesTimestampPrecision(t *testing.T) {
+	for ms := int64(1700000000000); ms < 1700000001000; ms++ {
+		b := []byte("[" + strconv.FormatInt(ms/1000, 10) + "." + strconv.FormatInt(1000+ms%1000, 10)[1:] + ",1]")
+		var s StepSamples
+		if err := json.Unmarshal(b, &s); err != nil {
+			t.Fatal(err)
+		}
+		if s.Timestamp != model.Time(ms) {
+			t.Fatalf("decoding %s: got timestamp %d, want %d", b, s.Timestamp, ms)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of the circuit breaker of an endpoint class, see
+// WithCircuitBreaker.
+type CircuitState int
+
+// Possible values for CircuitState.
+const (
+	// CircuitClosed is the normal state, in which requests are sent.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen is the state after too many consecutive failures, in
+	// which requests fail immediately with an *Error of type
+	// ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen is the state after CircuitBreakerOpts.OpenDuration
+	// has passed in the open state. A single probe request is sent. If it
+	// succeeds, the circuit is closed again. Otherwise, it is opened again.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitBreakerOpts specifies options for WithCircuitBreaker. The zero value
+// of CircuitBreakerOpts is a reasonable default.
+type CircuitBreakerOpts struct {
+	// FailureThreshold is the number of consecutive failed requests to an
+	// endpoint class after which its circuit is opened. Defaults to 5.
+	FailureThreshold int
+	// OpenDuration is the time a circuit stays open before a probe request
+	// is let through. Defaults to 10 seconds.
+	OpenDuration time.Duration
+	// Classify maps the URL path of a request to its endpoint class. Each
+	// endpoint class has its own circuit. If nil, the first path element
+	// after "/api/v1/" is used, e.g. "query" for instant queries,
+	// "query_range" for range queries, "label" for label values, and
+	// "status" for all status endpoints.
+	Classify func(path string) string
+	// OnStateChange, if not nil, is called whenever the circuit of an
+	// endpoint class changes its state, e.g. to log the change or to
+	// update a metric. It is called synchronously while handling a
+	// request and must therefore return quickly. It must not call methods
+	// of the API.
+	OnStateChange func(class string, from, to CircuitState)
+}
+
+// WithCircuitBreaker adds a client-side circuit breaker to the API: After
+// opts.FailureThreshold consecutive failed requests to an endpoint class (see
+// CircuitBreakerOpts.Classify), further requests to that class fail
+// immediately with an *Error of type ErrCircuitOpen, without contacting the
+// server. After opts.OpenDuration, a single probe request is let through to
+// find out if the server has recovered.
+//
+// This is meant for batch tooling sending many requests, which should degrade
+// fast while Prometheus is down rather than waiting for a timeout with each
+// request. Only failures indicating an unavailable or overloaded server count
+// as failures: errors sending the request (except for the cancellation of the
+// context of the request itself), responses with a 5xx status code, and
+// responses with status code 429 (Too Many Requests). Other errors (e.g.
+// invalid queries) count as successes.
+func WithCircuitBreaker(opts CircuitBreakerOpts) APIOption {
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = 5
+	}
+	if opts.OpenDuration <= 0 {
+		opts.OpenDuration = 10 * time.Second
+	}
+	if opts.Classify == nil {
+		opts.Classify = defaultEndpointClass
+	}
+	return func(h *httpAPI) {
+		h.client = &circuitBreakerClient{
+			apiClient: h.client,
+			opts:      opts,
+			circuits:  map[string]*circuit{},
+			now:       time.Now,
+		}
+	}
+}
+
+// defaultEndpointClass returns the first path element after the API prefix.
+func defaultEndpointClass(path string) string {
+	if i := strings.Index(path, apiPrefix+"/"); i >= 0 {
+		path = path[i+len(apiPrefix)+1:]
+	}
+	if i := strings.IndexByte(path, '/'); i >= 0 {
+		path = path[:i]
+	}
+	return path
+}
+
+// circuit is the circuit breaker state of an endpoint class.
+type circuit struct {
+	mtx           sync.Mutex
+	state         CircuitState
+	failures      int
+	openedAt      time.Time
+	probeInFlight bool
+}
+
+type circuitBreakerClient struct {
+	apiClient
+	opts CircuitBreakerOpts
+	now  func() time.Time
+
+	mtx      sync.Mutex
+	circuits map[string]*circuit
+}
+
+func (c *circuitBreakerClient) Do(ctx context.Context, req *http.Request) (*http.Response, []byte, Warnings, error) {
+	class := c.opts.Classify(req.URL.Path)
+	probe, err := c.acquire(class)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	resp, body, warnings, err := c.apiClient.Do(ctx, req)
+	c.record(ctx, class, probe, resp, err)
+	return resp, body, warnings, err
+}
+
+func (c *circuitBreakerClient) DoGetFallback(ctx context.Context, u *url.URL, args url.Values) (*http.Response, []byte, Warnings, error) {
+	class := c.opts.Classify(u.Path)
+	probe, err := c.acquire(class)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	resp, body, warnings, err := c.apiClient.DoGetFallback(ctx, u, args)
+	c.record(ctx, class, probe, resp, err)
+	return resp, body, warnings, err
+}
+
+func (c *circuitBreakerClient) circuit(class string) *circuit {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	cb, ok := c.circuits[class]
+	if !ok {
+		cb = &circuit{}
+		c.circuits[class] = cb
+	}
+	return cb
+}
+
+// acquire returns an error if no request to the endpoint class may be sent.
+// Otherwise, it returns whether the request is the probe request of a
+// half-open circuit.
+func (c *circuitBreakerClient) acquire(class string) (bool, error) {
+	cb := c.circuit(class)
+	cb.mtx.Lock()
+	defer cb.mtx.Unlock()
+
+	switch cb.state {
+	case CircuitOpen:
+		if c.now().Sub(cb.openedAt) < c.opts.OpenDuration {
+			return false, errCircuitOpen(class)
+		}
+		c.transition(class, cb, CircuitHalfOpen)
+	case CircuitHalfOpen:
+		if cb.probeInFlight {
+			return false, errCircuitOpen(class)
+		}
+	default:
+		return false, nil
+	}
+	cb.probeInFlight = true
+	return true, nil
+}
+
+// record updates the circuit of the endpoint class according to the outcome
+// of a request. probe is whether the request has been the probe request of a
+// half-open circuit, as returned by acquire. Only the probe request changes
+// the state of a half-open circuit. Other requests finishing while the circuit
+// is open or half-open have been sent before it opened and are stale.
+func (c *circuitBreakerClient) record(ctx context.Context, class string, probe bool, resp *http.Response, err error) {
+	cb := c.circuit(class)
+	cb.mtx.Lock()
+	defer cb.mtx.Unlock()
+
+	if probe {
+		cb.probeInFlight = false
+	}
+	switch {
+	case resp == nil && err != nil && ctx.Err() != nil:
+		// Canceled by the caller. Neither a success nor a failure.
+		return
+	case (resp == nil && err != nil) || (resp != nil && (resp.StatusCode/100 == 5 || resp.StatusCode == http.StatusTooManyRequests)):
+		cb.failures++
+		if probe || (cb.state == CircuitClosed && cb.failures >= c.opts.FailureThreshold) {
+			cb.openedAt = c.now()
+			c.transition(class, cb, CircuitOpen)
+		}
+	default:
+		if probe {
+			cb.failures = 0
+			c.transition(class, cb, CircuitClosed)
+		} else if cb.state == CircuitClosed {
+			cb.failures = 0
+		}
+	}
+}
+
+// transition changes the state of cb. The caller must have locked cb.mtx.
+func (c *circuitBreakerClient) transition(class string, cb *circuit, to CircuitState) {
+	from := cb.state
+	cb.state = to
+	if c.opts.OnStateChange != nil {
+		c.opts.OnStateChange(class, from, to)
+	}
+}
+
+func errCircuitOpen(class string) error {
+	return &Error{
+		Type: ErrCircuitOpen,
+		Msg:  fmt.Sprintf("circuit breaker open for endpoint class %q", class),
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/api"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var (
+		healthy  atomic.Bool
+		requests atomic.Int32
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if !healthy.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"scalar","result":[1,"1"]}}`)
+	}))
+	defer server.Close()
+
+	client, err := api.NewClient(api.Config{Address: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var transitions []string
+	promAPI := NewAPI(client, WithCircuitBreaker(CircuitBreakerOpts{
+		FailureThreshold: 3,
+		OpenDuration:     time.Minute,
+		OnStateChange: func(class string, from, to CircuitState) {
+			transitions = append(transitions, fmt.Sprintf("%s: %s -> %s", class, from, to))
+		},
+	}))
+	now := time.Now()
+	promAPI.(*httpAPI).client.(*circuitBreakerClient).now = func() time.Time { return now }
+
+	query := func() error {
+		_, _, err := promAPI.Query(context.Background(), "1", time.Time{})
+		return err
+	}
+	isOpen := func(err error) bool {
+		var apiErr *Error
+		return errors.As(err, &apiErr) && apiErr.Type == ErrCircuitOpen
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := query(); err == nil || isOpen(err) {
+			t.Fatalf("request %d: got error %v, want server error", i, err)
+		}
+	}
+	if err := query(); !isOpen(err) {
+		t.Fatalf("got error %v, want open circuit", err)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("got %d requests at the server, want 3", got)
+	}
+	// Other endpoint classes are not affected.
+	if _, err := promAPI.Buildinfo(context.Background()); err == nil || isOpen(err) {
+		t.Errorf("got error %v for other endpoint class, want server error", err)
+	}
+
+	// The probe fails, so the circuit is opened again.
+	now = now.Add(time.Minute)
+	if err := query(); err == nil || isOpen(err) {
+		t.Fatalf("got error %v for probe, want server error", err)
+	}
+	if err := query(); !isOpen(err) {
+		t.Fatalf("got error %v, want open circuit after failed probe", err)
+	}
+
+	// The probe succeeds, so the circuit is closed again.
+	healthy.Store(true)
+	now = now.Add(time.Minute)
+	if err := query(); err != nil {
+		t.Fatalf("unexpected error for probe: %v", err)
+	}
+	if err := query(); err != nil {
+		t.Fatalf("unexpected error after successful probe: %v", err)
+	}
+
+	want := []string{
+		"query: closed -> open",
+		"query: open -> half-open",
+		"query: half-open -> open",
+		"query: open -> half-open",
+		"query: half-open -> closed",
+	}
+	if fmt.Sprint(transitions) != fmt.Sprint(want) {
+		t.Errorf("got transitions %q, want %q", transitions, want)
+	}
+}
+
+func TestCircuitBreakerStaleRequest(t *testing.T) {
+	var (
+		releaseSlow  = make(chan struct{})
+		releaseProbe = make(chan struct{})
+		arrived      = make(chan string, 2)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch q := r.FormValue("query"); q {
+		case "slow":
+			arrived <- q
+			<-releaseSlow
+		case "probe":
+			arrived <- q
+			<-releaseProbe
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		case "fail":
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"scalar","result":[1,"1"]}}`)
+	}))
+	defer server.Close()
+	var releaseSlowOnce, releaseProbeOnce sync.Once
+	release := func() {
+		releaseSlowOnce.Do(func() { close(releaseSlow) })
+		releaseProbeOnce.Do(func() { close(releaseProbe) })
+	}
+	defer release() // Don't block server.Close if the test fails early.
+
+	client, err := api.NewClient(api.Config{Address: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		mtx         sync.Mutex
+		transitions []string
+	)
+	promAPI := NewAPI(client, WithCircuitBreaker(CircuitBreakerOpts{
+		FailureThreshold: 1,
+		OpenDuration:     time.Minute,
+		OnStateChange: func(class string, from, to CircuitState) {
+			mtx.Lock()
+			defer mtx.Unlock()
+			transitions = append(transitions, fmt.Sprintf("%s -> %s", from, to))
+		},
+	}))
+	now := time.Now()
+	cbc := promAPI.(*httpAPI).client.(*circuitBreakerClient)
+	cbc.now = func() time.Time {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return now
+	}
+	query := func(q string) error {
+		_, _, err := promAPI.Query(context.Background(), q, time.Time{})
+		return err
+	}
+	isOpen := func(err error) bool {
+		var apiErr *Error
+		return errors.As(err, &apiErr) && apiErr.Type == ErrCircuitOpen
+	}
+
+	// A slow request is sent while the circuit is closed.
+	slowDone := make(chan error, 1)
+	go func() { slowDone <- query("slow") }()
+	<-arrived
+
+	// The circuit opens, and the probe is sent after OpenDuration.
+	if err := query("fail"); err == nil || isOpen(err) {
+		t.Fatalf("got error %v, want server error", err)
+	}
+	mtx.Lock()
+	now = now.Add(time.Minute)
+	mtx.Unlock()
+	probeDone := make(chan error, 1)
+	go func() { probeDone <- query("probe") }()
+	<-arrived
+
+	// The stale slow request succeeds while the probe is in flight. It
+	// must neither close the circuit nor let another probe through.
+	releaseSlowOnce.Do(func() { close(releaseSlow) })
+	if err := <-slowDone; err != nil {
+		t.Fatalf("unexpected error for slow request: %v", err)
+	}
+	if err := query("ok"); !isOpen(err) {
+		t.Fatalf("got error %v, want open circuit while the probe is in flight", err)
+	}
+
+	// The probe fails, so the circuit is opened again.
+	releaseProbeOnce.Do(func() { close(releaseProbe) })
+	if err := <-probeDone; err == nil || isOpen(err) {
+		t.Fatalf("got error %v for probe, want server error", err)
+	}
+	if err := query("ok"); !isOpen(err) {
+		t.Fatalf("got error %v, want open circuit after failed probe", err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	want := []string{"closed -> open", "open -> half-open", "half-open -> open"}
+	if fmt.Sprint(transitions) != fmt.Sprint(want) {
+		t.Errorf("got transitions %q, want %q", transitions, want)
+	}
+}
+
+func TestDefaultEndpointClass(t *testing.T) {
+	for path, want := range map[string]string{
+		"/api/v1/query":              "query",
+		"/prefix/api/v1/query_range": "query_range",
+		"/api/v1/label/job/values":   "label",
+		"/api/v1/status/buildinfo":   "status",
+	} {
+		if got := defaultEndpointClass(path); got != want {
+			t.Errorf("%s: got class %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/prometheus/common/model"
+)
+
+// RowWriter writes the rows of a table, one record at a time. It is
+// implemented by *csv.Writer, and adapters for other tabular formats can
+// implement it to be used with ExportMatrix and ExportVector. This package
+// provides no Arrow or Parquet writers itself, as it would need to depend on
+// their (large) libraries.
+type RowWriter interface {
+	Write(record []string) error
+}
+
+// ExportMatrix writes m as a table to w, one row per sample. The first row is
+// the header. The columns are the union of the label names of all series
+// (sorted, with the metric name first, empty where a series doesn't have the
+// label), followed by "timestamp" (in seconds since the epoch, with up to
+// millisecond precision) and "value" (formatted as by strconv.FormatFloat,
+// i.e. including NaN, +Inf, and -Inf). Native histogram samples are not
+// exported.
+//
+// A label named "timestamp" or "value" is written to a column named
+// "label:timestamp" or "label:value", respectively, so that all column names
+// are unique. If a series also has a label of that name (only possible with
+// UTF-8 label names), an error is returned before writing anything.
+func ExportMatrix(w RowWriter, m model.Matrix) error {
+	metrics := make([]model.Metric, len(m))
+	for i, ss := range m {
+		metrics[i] = ss.Metric
+	}
+	names := exportLabelNames(metrics)
+	header, err := exportHeader(names)
+	if err != nil {
+		return err
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, ss := range m {
+		for _, sp := range ss.Values {
+			if err := w.Write(exportRow(names, ss.Metric, sp.Timestamp, sp.Value)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ExportVector writes v as a table to w, one row per sample, with the same
+// columns as written by ExportMatrix.
+func ExportVector(w RowWriter, v model.Vector) error {
+	metrics := make([]model.Metric, len(v))
+	for i, s := range v {
+		metrics[i] = s.Metric
+	}
+	names := exportLabelNames(metrics)
+	header, err := exportHeader(names)
+	if err != nil {
+		return err
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, s := range v {
+		if s.Histogram != nil {
+			continue
+		}
+		if err := w.Write(exportRow(names, s.Metric, s.Timestamp, s.Value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteMatrixCSV writes m in CSV format to w, see ExportMatrix.
+func WriteMatrixCSV(w io.Writer, m model.Matrix) error {
+	cw := csv.NewWriter(w)
+	if err := ExportMatrix(cw, m); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteVectorCSV writes v in CSV format to w, see ExportVector.
+func WriteVectorCSV(w io.Writer, v model.Vector) error {
+	cw := csv.NewWriter(w)
+	if err := ExportVector(cw, v); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// exportLabelNames returns the sorted union of the label names of metrics,
+// with the metric name first if present.
+func exportLabelNames(metrics []model.Metric) []string {
+	seen := map[model.LabelName]struct{}{}
+	for _, m := range metrics {
+		for name := range m {
+			seen[name] = struct{}{}
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, string(name))
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if names[i] == model.MetricNameLabel || names[j] == model.MetricNameLabel {
+			return names[i] == model.MetricNameLabel
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// exportColumnPrefix is the prefix of the column of a label colliding with the
+// timestamp or value column.
+const exportColumnPrefix = "label:"
+
+// exportHeader returns the header row for the provided label names, see
+// ExportMatrix.
+func exportHeader(names []string) ([]string, error) {
+	header := make([]string, 0, len(names)+2)
+	seen := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		seen[name] = struct{}{}
+	}
+	for _, name := range names {
+		if name == "timestamp" || name == "value" {
+			escaped := exportColumnPrefix + name
+			if _, ok := seen[escaped]; ok {
+				return nil, fmt.Errorf("cannot export label %q: both its name and its escaped name %q are used as label names", name, escaped)
+			}
+			name = escaped
+		}
+		header = append(header, name)
+	}
+	return append(header, "timestamp", "value"), nil
+}
+
+func exportRow(names []string, m model.Metric, ts model.Time, v model.SampleValue) []string {
+	row := make([]string, 0, len(names)+2)
+	for _, name := range names {
+		row = append(row, string(m[model.LabelName(name)]))
+	}
+	return append(row, ts.String(), strconv.FormatFloat(float64(v), 'g', -1, 64))
+}
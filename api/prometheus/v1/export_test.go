@@ -0,0 +1,101 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/prometheus/common/model"
+)
+
+func TestWriteMatrixCSV(t *testing.T) {
+	m := model.Matrix{
+		{
+			Metric: model.Metric{"__name__": "up", "job": "a", "instance": "x:80"},
+			Values: []model.SamplePair{{Timestamp: 1000, Value: 1}, {Timestamp: 16500, Value: 0}},
+		},
+		{
+			Metric: model.Metric{"__name__": "up", "job": "b,c", "zone": "z"},
+			Values: []model.SamplePair{{Timestamp: 1000, Value: model.SampleValue(math.Inf(1))}},
+		},
+	}
+	var buf bytes.Buffer
+	if err := WriteMatrixCSV(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	want := `__name__,instance,job,zone,timestamp,value
+up,x:80,a,,1,1
+up,x:80,a,,16.5,0
+up,,"b,c",z,1,+Inf
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteVectorCSV(t *testing.T) {
+	v := model.Vector{
+		{Metric: model.Metric{"job": "a"}, Timestamp: 2000, Value: 0.25},
+		{Metric: model.Metric{"job": "b"}, Timestamp: 2000, Histogram: &model.SampleHistogram{Count: 1}},
+	}
+	var buf bytes.Buffer
+	if err := WriteVectorCSV(&buf, v); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "job,timestamp,value\na,2,0.25\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWriteVectorCSVCollidingLabels(t *testing.T) {
+	v := model.Vector{
+		{Metric: model.Metric{"job": "a", "timestamp": "t", "value": "v"}, Timestamp: 2000, Value: 1},
+	}
+	var buf bytes.Buffer
+	if err := WriteVectorCSV(&buf, v); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "job,label:timestamp,label:value,timestamp,value\na,t,v,2,1\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	v = append(v, &model.Sample{Metric: model.Metric{"label:value": "x"}, Timestamp: 2000, Value: 1})
+	buf.Reset()
+	if err := WriteVectorCSV(&buf, v); err == nil {
+		t.Error("expected error for ambiguous columns")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("got output %q despite the error", buf.String())
+	}
+}
+
+type failingRowWriter struct{ rows int }
+
+func (w *failingRowWriter) Write([]string) error {
+	if w.rows == 1 {
+		return errors.New("full")
+	}
+	w.rows++
+	return nil
+}
+
+func TestExportMatrixWriteError(t *testing.T) {
+	m := model.Matrix{{Metric: model.Metric{"job": "a"}, Values: []model.SamplePair{{Timestamp: 1, Value: 1}}}}
+	if err := ExportMatrix(&failingRowWriter{}, m); err == nil || err.Error() != "full" {
+		t.Errorf("got error %v, want full", err)
+	}
+}
@@ -0,0 +1,338 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/prometheus/common/model"
+)
+
+const (
+	// defaultPageSize is the page size used if PaginationOpts.PageSize is
+	// zero.
+	defaultPageSize = 10000
+	// defaultMaxResumeMatcherBytes is the limit used if
+	// PaginationOpts.MaxResumeMatcherBytes is zero.
+	defaultMaxResumeMatcherBytes = 16 << 10
+)
+
+// PaginationOpts specifies options for NewLabelValuesPager and
+// NewSeriesPager. The zero value of PaginationOpts is a reasonable default.
+type PaginationOpts struct {
+	// PageSize is the maximum number of label values requested per page.
+	// Defaults to 10000.
+	PageSize uint64
+	// StartTime and EndTime restrict the time range of the requests as in
+	// API.LabelValues and API.Series. They are ignored if zero.
+	StartTime, EndTime time.Time
+	// ResumeToken continues the iteration after the position returned by
+	// the ResumeToken method of a previous pager (for the same label and
+	// matchers), e.g. after a restart of a long-running tool. If empty,
+	// the iteration starts from the beginning.
+	ResumeToken string
+	// MaxResumeMatcherBytes is the maximum size of the matcher selecting
+	// the values after the previous page (see LabelValuesPager), encoded
+	// as a URL query parameter. If a page ends on a value requiring a
+	// larger matcher, the iteration ends with an error rather than sending
+	// a request likely to be rejected by the server or a proxy. Defaults
+	// to 16KiB, which covers values of about 150 bytes.
+	MaxResumeMatcherBytes int
+}
+
+// LabelValuesPager fetches the values of a label in bounded pages, so that
+// the values of high-cardinality labels can be processed with bounded memory.
+// Create a LabelValuesPager with NewLabelValuesPager and use it like this:
+//
+//	p := v1.NewLabelValuesPager(api, "pod", nil, v1.PaginationOpts{})
+//	for p.Next(ctx) {
+//		for _, v := range p.Page() {
+//			// Process v.
+//		}
+//	}
+//	if err := p.Err(); err != nil {
+//		// Handle error.
+//	}
+//
+// Pages are requested with the limit parameter (supported by Prometheus v2.54
+// and later). Since the API has no server-side cursor, each page after the
+// first is requested with an additional regular expression matcher that only
+// selects values sorting after the last value of the previous page. This
+// relies on the server returning the values in sorted order (which Prometheus
+// does). A server ignoring the limit parameter returns all values as a single
+// page.
+//
+// The size of the matcher grows with the square of the length of the last
+// value of the previous page, and the matcher is sent in the URL (as the label
+// values endpoint of Prometheus only supports GET requests). Thus, iterating
+// over long values (e.g. URLs) fails once a page ends on a value whose matcher
+// exceeds PaginationOpts.MaxResumeMatcherBytes. Use a larger limit if the
+// server and any proxies in between accept long URLs, or a larger page size to
+// fetch all values in one page.
+//
+// A LabelValuesPager must not be used concurrently.
+type LabelValuesPager struct {
+	api     API
+	label   string
+	matches []string
+	opts    PaginationOpts
+
+	page     model.LabelValues
+	last     string
+	warnings Warnings
+	err      error
+	done     bool
+}
+
+// NewLabelValuesPager returns a LabelValuesPager for the values of the provided
+// label in the series selected by the provided matchers (or in all series if
+// no matchers are provided).
+func NewLabelValuesPager(api API, label string, matches []string, opts PaginationOpts) *LabelValuesPager {
+	if opts.PageSize == 0 {
+		opts.PageSize = defaultPageSize
+	}
+	if opts.MaxResumeMatcherBytes == 0 {
+		opts.MaxResumeMatcherBytes = defaultMaxResumeMatcherBytes
+	}
+	return &LabelValuesPager{
+		api:     api,
+		label:   label,
+		matches: matches,
+		opts:    opts,
+		last:    opts.ResumeToken,
+	}
+}
+
+// Next fetches the next page. It returns false once all values have been
+// fetched or an error occurred, in which case Err returns the error.
+func (p *LabelValuesPager) Next(ctx context.Context) bool {
+	if p.done || p.err != nil {
+		return false
+	}
+	matches := p.matches
+	if p.last != "" {
+		matcher := labelMatcher(p.label, "=~", greaterThanRegexp(p.last))
+		if n := len(url.QueryEscape(matcher)); n > p.opts.MaxResumeMatcherBytes {
+			p.err = fmt.Errorf(
+				"cannot resume after label value of %d bytes: matcher of %d bytes exceeds MaxResumeMatcherBytes (%d)",
+				len(p.last), n, p.opts.MaxResumeMatcherBytes,
+			)
+			return false
+		}
+		matches = withLabelMatcher(matches, matcher)
+	}
+	page, w, err := p.api.LabelValues(ctx, p.label, matches, p.opts.StartTime, p.opts.EndTime, WithLimit(p.opts.PageSize))
+	p.warnings = append(p.warnings, w...)
+	if err != nil {
+		p.err = err
+		return false
+	}
+	if uint64(len(page)) < p.opts.PageSize {
+		p.done = true
+	}
+	if len(page) == 0 {
+		return false
+	}
+	p.page = page
+	p.last = string(page[len(page)-1])
+	return true
+}
+
+// Page returns the values fetched by the last call of Next, in sorted order.
+func (p *LabelValuesPager) Page() model.LabelValues {
+	return p.page
+}
+
+// ResumeToken returns a token to resume the iteration after the current page
+// with a new LabelValuesPager, see PaginationOpts.ResumeToken.
+func (p *LabelValuesPager) ResumeToken() string {
+	return p.last
+}
+
+// Warnings returns the warnings returned by the server for all pages fetched
+// so far. Note that a server truncating a page to the limit might report that
+// as a warning.
+func (p *LabelValuesPager) Warnings() Warnings {
+	return p.warnings
+}
+
+// Err returns the error that ended the iteration, if any.
+func (p *LabelValuesPager) Err() error {
+	return p.err
+}
+
+// SeriesPager fetches series in bounded pages. As the series of the Series API
+// cannot be resumed by a simple lexicographic range, the series are
+// partitioned by the values of a label instead (the metric name by default):
+// The values are fetched with a LabelValuesPager, and each page of the
+// SeriesPager contains all series (selected by the matchers) with one of these
+// values. The memory needed is thus bounded by the number of series sharing
+// the same value of the partition label. Create a SeriesPager with
+// NewSeriesPager and use it like a LabelValuesPager.
+//
+// A SeriesPager must not be used concurrently.
+type SeriesPager struct {
+	api     API
+	label   string
+	matches []string
+	opts    PaginationOpts
+	values  *LabelValuesPager
+
+	pending  model.LabelValues
+	page     []model.LabelSet
+	last     string
+	warnings Warnings
+	err      error
+}
+
+// NewSeriesPager returns a SeriesPager for the series selected by the provided
+// matchers (or for all series if no matchers are provided), partitioned by the
+// provided label. If partitionLabel is empty, the metric name is used. Only
+// series with a non-empty value of the partition label are returned.
+// opts.PageSize applies to the pages of partition label values fetched in the
+// background.
+func NewSeriesPager(api API, partitionLabel string, matches []string, opts PaginationOpts) *SeriesPager {
+	if partitionLabel == "" {
+		partitionLabel = model.MetricNameLabel
+	}
+	return &SeriesPager{
+		api:     api,
+		label:   partitionLabel,
+		matches: matches,
+		opts:    opts,
+		values:  NewLabelValuesPager(api, partitionLabel, matches, opts),
+		last:    opts.ResumeToken,
+	}
+}
+
+// Next fetches the next page, i.e. the series of the next value of the
+// partition label. It returns false once all series have been fetched or an
+// error occurred, in which case Err returns the error.
+func (p *SeriesPager) Next(ctx context.Context) bool {
+	for p.err == nil {
+		if len(p.pending) == 0 {
+			if !p.values.Next(ctx) {
+				p.err = p.values.Err()
+				return false
+			}
+			p.pending = p.values.Page()
+		}
+		value := string(p.pending[0])
+		p.pending = p.pending[1:]
+
+		page, w, err := p.api.Series(
+			ctx, withLabelMatcher(p.matches, labelMatcher(p.label, "=", value)),
+			p.opts.StartTime, p.opts.EndTime,
+		)
+		p.warnings = append(p.warnings, w...)
+		if err != nil {
+			p.err = err
+			return false
+		}
+		p.last = value
+		if len(page) > 0 {
+			p.page = page
+			return true
+		}
+	}
+	return false
+}
+
+// Page returns the series fetched by the last call of Next.
+func (p *SeriesPager) Page() []model.LabelSet {
+	return p.page
+}
+
+// ResumeToken returns a token to resume the iteration after the current page
+// with a new SeriesPager, see PaginationOpts.ResumeToken.
+func (p *SeriesPager) ResumeToken() string {
+	return p.last
+}
+
+// Warnings returns the warnings returned by the server for all requests so
+// far, including those for fetching the partition label values.
+func (p *SeriesPager) Warnings() Warnings {
+	w := append(Warnings(nil), p.values.Warnings()...)
+	return append(w, p.warnings...)
+}
+
+// Err returns the error that ended the iteration, if any.
+func (p *SeriesPager) Err() error {
+	return p.err
+}
+
+// labelMatcher returns a label matcher in PromQL syntax.
+func labelMatcher(label, op, value string) string {
+	name := label
+	if !model.LabelName(label).IsValidLegacy() {
+		name = strconv.Quote(label)
+	}
+	return name + op + strconv.Quote(value)
+}
+
+// withLabelMatcher returns the provided series selectors with the provided
+// label matcher added to each of them. If no selectors are provided, a single
+// selector consisting of the label matcher is returned.
+func withLabelMatcher(selectors []string, matcher string) []string {
+	if len(selectors) == 0 {
+		return []string{"{" + matcher + "}"}
+	}
+	res := make([]string, 0, len(selectors))
+	for _, s := range selectors {
+		s = strings.TrimSpace(s)
+		if !strings.HasSuffix(s, "}") {
+			// Just a metric name.
+			res = append(res, s+"{"+matcher+"}")
+			continue
+		}
+		inner := strings.TrimSpace(s[:len(s)-1])
+		if !strings.HasSuffix(inner, "{") && !strings.HasSuffix(inner, ",") {
+			inner += ","
+		}
+		res = append(res, inner+matcher+"}")
+	}
+	return res
+}
+
+// greaterThanRegexp returns a regular expression (in RE2 syntax, anchored at
+// both ends by Prometheus) that matches exactly those strings that sort after
+// s in byte-wise lexicographic order (which is the same as the order of code
+// points for valid UTF-8). It is a flat alternation with one alternative per
+// rune of s (the preceding runes followed by a greater rune and anything) and
+// a final alternative for the strings having s as a proper prefix. Nested
+// alternatives would exceed the nesting limit of RE2 for long values. The
+// alternatives are wrapped in capturing groups, as the Go regexp parser would
+// otherwise factor out their common prefixes, nesting them again. The size of
+// the regular expression is quadratic in the length of s.
+func greaterThanRegexp(s string) string {
+	alternatives := make([]string, 0, utf8.RuneCountInString(s)+1)
+	for i, r := range s {
+		if r == unicode.MaxRune {
+			continue // No greater rune.
+		}
+		alternatives = append(alternatives, fmt.Sprintf(
+			`(%s[\x{%x}-\x{%x}](?s:.*))`, regexp.QuoteMeta(s[:i]), r+1, unicode.MaxRune,
+		))
+	}
+	alternatives = append(alternatives, "("+regexp.QuoteMeta(s)+"(?s:.+))")
+	return strings.Join(alternatives, "|")
+}
@@ -0,0 +1,259 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+func TestGreaterThanRegexp(t *testing.T) {
+	values := []string{"", "a", "a\n", "aa", "ab", "b", "b]", "b^x", "ba", "z", "ä", "äb", "日本", "\U0010ffff", "\U0010ffffa"}
+	sort.Strings(values)
+	for _, s := range values {
+		re := regexp.MustCompile("^(?:" + greaterThanRegexp(s) + ")$")
+		for _, v := range values {
+			if got, want := re.MatchString(v), v > s; got != want {
+				t.Errorf("regexp for %q matching %q: got %v, want %v", s, v, got, want)
+			}
+		}
+	}
+}
+
+func TestGreaterThanRegexpLongValue(t *testing.T) {
+	// Long label values must not exceed the nesting limit of RE2.
+	s := strings.Repeat("ab", 400)
+	re, err := regexp.Compile("^(?:" + greaterThanRegexp(s) + ")$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for v, want := range map[string]bool{
+		s:                               false,
+		s[:len(s)-1]:                    false,
+		s + "a":                         true,
+		s[:len(s)-1] + "c":              true,
+		strings.Repeat("ab", 399) + "a": false,
+		"b":                             true,
+	} {
+		if got := re.MatchString(v); got != want {
+			t.Errorf("matching %d bytes: got %v, want %v", len(v), got, want)
+		}
+	}
+}
+
+func TestWithLabelMatcher(t *testing.T) {
+	got := withLabelMatcher(
+		[]string{"up", ` up{} `, `{job="a"}`, `up{job="a", }`, `{job="}"}`},
+		labelMatcher("pod", "=", "x"),
+	)
+	want := []string{`up{pod="x"}`, `up{pod="x"}`, `{job="a",pod="x"}`, `up{job="a",pod="x"}`, `{job="}",pod="x"}`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := withLabelMatcher(nil, labelMatcher("service.name", "=~", "a.*")), []string{`{"service.name"=~"a.*"}`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// paginationTestAPI serves label values and series from a fixed set of series,
+// evaluating only the matchers added by the pagers.
+type paginationTestAPI struct {
+	API
+	series []model.LabelSet
+	calls  int
+}
+
+var paginationMatcherRE = regexp.MustCompile(`(\w+)(=~?)("(?:[^"\\]|\\.)*")`)
+
+func (a *paginationTestAPI) selects(matches []string, ls model.LabelSet) bool {
+	if len(matches) == 0 {
+		return true
+	}
+	for _, m := range paginationMatcherRE.FindAllStringSubmatch(matches[0], -1) {
+		value, err := strconv.Unquote(m[3])
+		if err != nil {
+			panic(err)
+		}
+		lv := string(ls[model.LabelName(m[1])])
+		if m[2] == "=" && lv != value {
+			return false
+		}
+		if m[2] == "=~" && !regexp.MustCompile("^(?:"+value+")$").MatchString(lv) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *paginationTestAPI) LabelValues(_ context.Context, label string, matches []string, _, _ time.Time, opts ...Option) (model.LabelValues, Warnings, error) {
+	a.calls++
+	set := map[model.LabelValue]struct{}{}
+	for _, ls := range a.series {
+		if v, ok := ls[model.LabelName(label)]; ok && a.selects(matches, ls) {
+			set[v] = struct{}{}
+		}
+	}
+	var res model.LabelValues
+	for v := range set {
+		res = append(res, v)
+	}
+	sort.Sort(res)
+	if limit := newAPIOptions(opts).limit; limit > 0 && uint64(len(res)) > limit {
+		return res[:limit], Warnings{"results truncated due to limit"}, nil
+	}
+	return res, nil, nil
+}
+
+func (a *paginationTestAPI) Series(_ context.Context, matches []string, _, _ time.Time, _ ...Option) ([]model.LabelSet, Warnings, error) {
+	a.calls++
+	var res []model.LabelSet
+	for _, ls := range a.series {
+		if a.selects(matches, ls) {
+			res = append(res, ls)
+		}
+	}
+	return res, nil, nil
+}
+
+func TestLabelValuesPager(t *testing.T) {
+	a := &paginationTestAPI{}
+	for _, pod := range []string{"e", "a", "c", "b", "d", "日本", "aa"} {
+		a.series = append(a.series, model.LabelSet{"__name__": "up", "pod": model.LabelValue(pod)})
+	}
+	ctx := context.Background()
+
+	p := NewLabelValuesPager(a, "pod", nil, PaginationOpts{PageSize: 3})
+	var pages []model.LabelValues
+	for p.Next(ctx) {
+		pages = append(pages, p.Page())
+	}
+	if err := p.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []model.LabelValues{{"a", "aa", "b"}, {"c", "d", "e"}, {"日本"}}
+	if !reflect.DeepEqual(pages, want) {
+		t.Errorf("got pages %q, want %q", pages, want)
+	}
+	if a.calls != 3 {
+		t.Errorf("got %d calls, want 3", a.calls)
+	}
+	if len(p.Warnings()) != 2 {
+		t.Errorf("got warnings %q, want 2", p.Warnings())
+	}
+
+	// Resume after the first page, with an exact multiple of the page size left.
+	p = NewLabelValuesPager(a, "pod", nil, PaginationOpts{PageSize: 2, ResumeToken: "b"})
+	var got model.LabelValues
+	for p.Next(ctx) {
+		got = append(got, p.Page()...)
+	}
+	if err := p.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if want := (model.LabelValues{"c", "d", "e", "日本"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if p.ResumeToken() != "日本" {
+		t.Errorf("got resume token %q, want 日本", p.ResumeToken())
+	}
+}
+
+func TestLabelValuesPagerLongValue(t *testing.T) {
+	long := "/m" + strings.Repeat("x", 300)
+	a := &paginationTestAPI{}
+	for _, path := range []string{"/a", long, "/z"} {
+		a.series = append(a.series, model.LabelSet{"__name__": "up", "path": model.LabelValue(path)})
+	}
+	ctx := context.Background()
+
+	p := NewLabelValuesPager(a, "path", nil, PaginationOpts{PageSize: 2})
+	if !p.Next(ctx) {
+		t.Fatalf("no first page: %v", p.Err())
+	}
+	if p.Next(ctx) {
+		t.Fatal("got second page, want error for long resume matcher")
+	}
+	if err := p.Err(); err == nil || !strings.Contains(err.Error(), "MaxResumeMatcherBytes") {
+		t.Errorf("got error %v, want error about MaxResumeMatcherBytes", err)
+	}
+	if a.calls != 1 {
+		t.Errorf("got %d calls, want 1", a.calls)
+	}
+	if p.ResumeToken() != long {
+		t.Error("resume token does not point to the last value")
+	}
+
+	// With a larger limit, the iteration succeeds.
+	p = NewLabelValuesPager(a, "path", nil, PaginationOpts{PageSize: 2, MaxResumeMatcherBytes: 4 << 20})
+	var got model.LabelValues
+	for p.Next(ctx) {
+		got = append(got, p.Page()...)
+	}
+	if err := p.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if want := (model.LabelValues{"/a", model.LabelValue(long), "/z"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %d values, want %d", len(got), len(want))
+	}
+}
+
+func TestSeriesPager(t *testing.T) {
+	a := &paginationTestAPI{series: []model.LabelSet{
+		{"__name__": "up", "job": "a"},
+		{"__name__": "up", "job": "b"},
+		{"__name__": "go_goroutines", "job": "a"},
+		{"__name__": "process_cpu_seconds_total", "job": "b"},
+	}}
+	ctx := context.Background()
+
+	p := NewSeriesPager(a, "", []string{`{job="a"}`}, PaginationOpts{PageSize: 1})
+	var pages [][]model.LabelSet
+	var tokens []string
+	for p.Next(ctx) {
+		pages = append(pages, p.Page())
+		tokens = append(tokens, p.ResumeToken())
+	}
+	if err := p.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]model.LabelSet{
+		{{"__name__": "go_goroutines", "job": "a"}},
+		{{"__name__": "up", "job": "a"}},
+	}
+	if !reflect.DeepEqual(pages, want) {
+		t.Errorf("got pages %v, want %v", pages, want)
+	}
+	if got := strings.Join(tokens, ","); got != "go_goroutines,up" {
+		t.Errorf("got resume tokens %q", got)
+	}
+
+	p = NewSeriesPager(a, "job", nil, PaginationOpts{ResumeToken: "a"})
+	if !p.Next(ctx) {
+		t.Fatalf("no page, error: %v", p.Err())
+	}
+	if got := len(p.Page()); got != 2 {
+		t.Errorf("got %d series, want 2", got)
+	}
+	if p.Next(ctx) {
+		t.Error("unexpected second page")
+	}
+}
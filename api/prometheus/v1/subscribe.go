@@ -0,0 +1,239 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	json "github.com/json-iterator/go"
+
+	"github.com/prometheus/client_golang/api"
+)
+
+// Event is an event received from a server-sent events endpoint.
+type Event struct {
+	// ID is the ID of the event, or of the most recent event that had
+	// one, as specified for server-sent events.
+	ID string
+	// Type is the event type, or "message" if the server didn't specify
+	// one.
+	Type string
+	// Data is the data of the event. Multiple data lines are joined with
+	// a newline.
+	Data []byte
+}
+
+// Notification is a notification about the state of the Prometheus server,
+// e.g. about a failed configuration reload.
+type Notification struct {
+	Text   string    `json:"text"`
+	Date   time.Time `json:"date"`
+	Active bool      `json:"active"`
+}
+
+// SubscribeOpts specifies options for Subscribe and SubscribeNotifications.
+// The zero value of SubscribeOpts is a reasonable default.
+type SubscribeOpts struct {
+	// MinBackoff is the delay before reconnecting after the connection
+	// failed or the stream ended. The delay doubles with each consecutive
+	// failure up to MaxBackoff, and it is reset once an event has been
+	// received. Defaults to one second.
+	MinBackoff time.Duration
+	// MaxBackoff is the maximum delay before reconnecting. Defaults to one
+	// minute.
+	MaxBackoff time.Duration
+	// OnError, if not nil, is called with every error that causes a
+	// reconnect, e.g. to log it.
+	OnError func(error)
+	// BufferSize is the capacity of the returned channel.
+	BufferSize int
+}
+
+// Subscribe connects to the server-sent events endpoint ep (a path like
+// "/api/v1/notifications/live") and returns a channel of the received events.
+// Subscribe reconnects automatically, with an exponential backoff, if the
+// connection fails or the stream ends. Upon reconnecting, the ID of the last
+// received event is sent in the Last-Event-ID header, so that servers
+// supporting it can resume the stream without losing events. The channel is
+// closed once ctx is done.
+//
+// The provided client has to implement api.StreamingClient, as the Client
+// returned by api.NewClient does. Otherwise, an error is returned.
+//
+// Subscribe is meant for endpoints that actually stream events. It is not a
+// substitute for polling endpoints that don't, like the ones used by
+// API.Rules or API.Alerts.
+func Subscribe(ctx context.Context, client api.Client, ep string, opts SubscribeOpts) (<-chan Event, error) {
+	sc, ok := client.(api.StreamingClient)
+	if !ok {
+		return nil, errors.New("client does not support streaming responses")
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = time.Second
+	}
+	if opts.MaxBackoff < opts.MinBackoff {
+		opts.MaxBackoff = max(time.Minute, opts.MinBackoff)
+	}
+
+	s := &subscription{
+		client: sc,
+		url:    client.URL(ep, nil).String(),
+		opts:   opts,
+		events: make(chan Event, opts.BufferSize),
+	}
+	go s.run(ctx)
+	return s.events, nil
+}
+
+// SubscribeNotifications subscribes to the live notifications of the
+// Prometheus server (available in Prometheus v3.0.0 and later). It works like
+// Subscribe. Events that cannot be decoded as a Notification are reported to
+// SubscribeOpts.OnError and skipped.
+func SubscribeNotifications(ctx context.Context, client api.Client, opts SubscribeOpts) (<-chan Notification, error) {
+	events, err := Subscribe(ctx, client, epNotificationsLive, opts)
+	if err != nil {
+		return nil, err
+	}
+	notifications := make(chan Notification, opts.BufferSize)
+	go func() {
+		defer close(notifications)
+		for e := range events {
+			var n Notification
+			if err := json.Unmarshal(e.Data, &n); err != nil {
+				if opts.OnError != nil {
+					opts.OnError(fmt.Errorf("decoding notification: %w", err))
+				}
+				continue
+			}
+			select {
+			case notifications <- n:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return notifications, nil
+}
+
+type subscription struct {
+	client      api.StreamingClient
+	url         string
+	opts        SubscribeOpts
+	events      chan Event
+	lastEventID string
+}
+
+func (s *subscription) run(ctx context.Context) {
+	defer close(s.events)
+	backoff := s.opts.MinBackoff
+	for {
+		received, err := s.stream(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			err = errors.New("event stream ended")
+		}
+		if s.opts.OnError != nil {
+			s.opts.OnError(err)
+		}
+		if received {
+			backoff = s.opts.MinBackoff
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		backoff = min(2*backoff, s.opts.MaxBackoff)
+	}
+}
+
+// stream connects to the endpoint once and forwards events until the stream
+// ends. It returns whether any event was received.
+func (s *subscription) stream(ctx context.Context) (bool, error) {
+	req, err := http.NewRequest(http.MethodGet, s.url, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if s.lastEventID != "" {
+		req.Header.Set("Last-Event-ID", s.lastEventID)
+	}
+	resp, err := s.client.DoStream(ctx, req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return false, fmt.Errorf("server returned HTTP status %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+
+	var (
+		received bool
+		r        = bufio.NewReader(resp.Body)
+		data     []byte
+		hasData  bool
+		typ      string
+	)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return received, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			// Dispatch the event.
+			if hasData {
+				if typ == "" {
+					typ = "message"
+				}
+				select {
+				case s.events <- Event{ID: s.lastEventID, Type: typ, Data: data}:
+					received = true
+				case <-ctx.Done():
+					return received, ctx.Err()
+				}
+			}
+			data, hasData, typ = nil, false, ""
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "": // A comment, e.g. used as keep-alive.
+		case "data":
+			if hasData {
+				data = append(data, '\n')
+			}
+			data = append(data, value...)
+			hasData = true
+		case "event":
+			typ = value
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				s.lastEventID = value
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/api"
+)
+
+func TestSubscribe(t *testing.T) {
+	var (
+		mtx           sync.Mutex
+		connections   int
+		lastEventIDs  []string
+		secondStarted = make(chan struct{})
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/notifications/live" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		mtx.Lock()
+		connections++
+		n := connections
+		lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+		mtx.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		switch n {
+		case 1:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			fmt.Fprint(w, "id: 1\ndata: {\"text\": \"first\", \"active\": true}\n\n")
+			fmt.Fprint(w, "id: 2\nevent: custom\ndata: line 1\ndata: line 2\n\n")
+			// Ending the response forces a reconnect.
+		case 2:
+			fmt.Fprint(w, "id: 3\ndata: {\"text\": \"third\", \"date\": \"2024-01-02T03:04:05Z\"}\n\n")
+			w.(http.Flusher).Flush()
+			close(secondStarted)
+			<-r.Context().Done()
+		default:
+			<-r.Context().Done()
+		}
+	}))
+	defer server.Close()
+
+	client, err := api.NewClient(api.Config{Address: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var errs []error
+	events, err := Subscribe(ctx, client, epNotificationsLive, SubscribeOpts{
+		MinBackoff: time.Millisecond,
+		OnError:    func(err error) { errs = append(errs, err) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []Event
+	for i := 0; i < 3; i++ {
+		select {
+		case e := <-events:
+			got = append(got, e)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timed out waiting for event %d", i+1)
+		}
+	}
+	<-secondStarted
+	want := []Event{
+		{ID: "1", Type: "message", Data: []byte(`{"text": "first", "active": true}`)},
+		{ID: "2", Type: "custom", Data: []byte("line 1\nline 2")},
+		{ID: "3", Type: "message", Data: []byte(`{"text": "third", "date": "2024-01-02T03:04:05Z"}`)},
+	}
+	for i := range want {
+		if got[i].ID != want[i].ID || got[i].Type != want[i].Type || string(got[i].Data) != string(want[i].Data) {
+			t.Errorf("event %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	cancel()
+	if _, ok := <-events; ok {
+		t.Error("expected channel to be closed after cancellation")
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+	if len(lastEventIDs) < 2 || lastEventIDs[0] != "" || lastEventIDs[1] != "2" {
+		t.Errorf("got Last-Event-ID headers %q, want [\"\" \"2\"]", lastEventIDs)
+	}
+	if len(errs) != 1 {
+		t.Errorf("got errors %v, want exactly one for the ended stream", errs)
+	}
+}
+
+func TestSubscribeNotifications(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: not json\n\n")
+		fmt.Fprint(w, "data: {\"text\": \"Configuration reload has failed.\", \"date\": \"2024-01-02T03:04:05Z\", \"active\": true}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client, err := api.NewClient(api.Config{Address: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	decodeErrs := make(chan error, 1)
+	notifications, err := SubscribeNotifications(ctx, client, SubscribeOpts{
+		OnError: func(err error) { decodeErrs <- err },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case n := <-notifications:
+		want := Notification{
+			Text:   "Configuration reload has failed.",
+			Date:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			Active: true,
+		}
+		if n.Text != want.Text || !n.Date.Equal(want.Date) || n.Active != want.Active {
+			t.Errorf("got %+v, want %+v", n, want)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for notification")
+	}
+	select {
+	case err := <-decodeErrs:
+		if err == nil {
+			t.Error("expected decoding error")
+		}
+	default:
+		t.Error("expected decoding error to be reported")
+	}
+}
+
+type nonStreamingClient struct{ api.Client }
+
+func TestSubscribeNonStreamingClient(t *testing.T) {
+	client, err := api.NewClient(api.Config{Address: "http://localhost:9090"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Subscribe(context.Background(), nonStreamingClient{client}, epNotificationsLive, SubscribeOpts{}); err == nil {
+		t.Error("expected error for a client without streaming support")
+	}
+}
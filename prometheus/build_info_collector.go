@@ -13,13 +13,19 @@
 
 package prometheus
 
-import "runtime/debug"
+import (
+	"runtime/debug"
+
+	"github.com/prometheus/client_golang/prometheus/internal"
+)
 
 // NewBuildInfoCollector is the obsolete version of collectors.NewBuildInfoCollector.
 // See there for documentation.
 //
 // Deprecated: Use collectors.NewBuildInfoCollector instead.
 func NewBuildInfoCollector() Collector {
+	internal.ReportDeprecated("prometheus.NewBuildInfoCollector", "collectors.NewBuildInfoCollector")
+
 	path, version, sum := "unknown", "unknown", "unknown"
 	if bi, ok := debug.ReadBuildInfo(); ok {
 		path = bi.Main.Path
@@ -119,6 +119,57 @@ func (c *selfCollector) Collect(ch chan<- Metric) {
 	ch <- c.self
 }
 
+// MultiSelfCollector implements Collector for a fixed set of Metrics so that a
+// composite metric type, i.e. one that consists of several Metrics (e.g. a
+// timer that is both a Histogram and a Counter), collects itself. Embed it in
+// the struct implementing the composite type, and call Init with the
+// constituent Metrics upon creation, before the composite is registered:
+//
+//	type Timer struct {
+//		prometheus.MultiSelfCollector
+//		durations prometheus.Histogram
+//		errors    prometheus.Counter
+//	}
+//
+//	func NewTimer(...) *Timer {
+//		t := &Timer{durations: ..., errors: ...}
+//		t.Init(t.durations, t.errors)
+//		return t
+//	}
+//
+// Collect sends each Metric. Describe sends the Desc of each Metric, but only
+// once for Metrics sharing the same Desc (e.g. two children of the same
+// vector), as Registries reject Collectors describing the same Desc twice.
+type MultiSelfCollector struct {
+	metrics []Metric
+}
+
+// Init provides the MultiSelfCollector with the Metrics it is supposed to
+// collect. It must be called before the MultiSelfCollector is used.
+func (c *MultiSelfCollector) Init(metrics ...Metric) {
+	c.metrics = metrics
+}
+
+// Describe implements Collector.
+func (c *MultiSelfCollector) Describe(ch chan<- *Desc) {
+	seen := make(map[*Desc]struct{}, len(c.metrics))
+	for _, m := range c.metrics {
+		desc := m.Desc()
+		if _, ok := seen[desc]; ok {
+			continue
+		}
+		seen[desc] = struct{}{}
+		ch <- desc
+	}
+}
+
+// Collect implements Collector.
+func (c *MultiSelfCollector) Collect(ch chan<- Metric) {
+	for _, m := range c.metrics {
+		ch <- m
+	}
+}
+
 // collectorMetric is a metric that is also a collector.
 // Because of selfCollector, most (if not all) Metrics in
 // this package are also collectors.
@@ -59,3 +59,50 @@ func TestDescribeByCollect(t *testing.T) {
 		t.Error("gathering failed:", err)
 	}
 }
+
+type compositeTimer struct {
+	MultiSelfCollector
+	durations Histogram
+	errors    Counter
+}
+
+func TestMultiSelfCollector(t *testing.T) {
+	vec := NewCounterVec(CounterOpts{Name: "calls_total", Help: "Calls."}, []string{"result"})
+	timer := &compositeTimer{
+		durations: NewHistogram(HistogramOpts{Name: "call_duration_seconds", Help: "Durations."}),
+		errors:    NewCounter(CounterOpts{Name: "call_errors_total", Help: "Errors."}),
+	}
+	// The children of vec share a Desc, which must only be described once.
+	timer.Init(timer.durations, timer.errors, vec.WithLabelValues("ok"), vec.WithLabelValues("fail"))
+	timer.durations.Observe(0.1)
+	timer.errors.Inc()
+
+	descs := make(chan *Desc, 10)
+	timer.Describe(descs)
+	close(descs)
+	if got := len(descs); got != 3 {
+		t.Errorf("got %d descs, want 3", got)
+	}
+
+	reg := NewPedanticRegistry()
+	if err := reg.Register(timer); err != nil {
+		t.Fatal(err)
+	}
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]int{}
+	for _, mf := range mfs {
+		got[mf.GetName()] = len(mf.GetMetric())
+	}
+	want := map[string]int{"call_duration_seconds": 1, "call_errors_total": 1, "calls_total": 2}
+	if len(got) != len(want) {
+		t.Fatalf("got metric families %v, want %v", got, want)
+	}
+	for name, n := range want {
+		if got[name] != n {
+			t.Errorf("%s: got %d metrics, want %d", name, got[name], n)
+		}
+	}
+}
@@ -29,7 +29,7 @@ var (
 	// MetricsGC allows only GC metrics to be collected from Go runtime.
 	// e.g. go_gc_cycles_automatic_gc_cycles_total
 	// NOTE: This does not include new class of "/cpu/classes/gc/..." metrics.
-	// Use custom metric rule to access those.
+	// Use MetricsCPU to access those.
 	MetricsGC = GoRuntimeMetricsRule{regexp.MustCompile(`^/gc/.*`)}
 	// MetricsMemory allows only memory metrics to be collected from Go runtime.
 	// e.g. go_memory_classes_heap_free_bytes
@@ -40,6 +40,17 @@ var (
 	// MetricsDebug allows only debug metrics to be collected from Go runtime.
 	// e.g. go_godebug_non_default_behavior_gocachetest_events_total
 	MetricsDebug = GoRuntimeMetricsRule{regexp.MustCompile(`^/godebug/.*`)}
+	// MetricsFinalizers allows only metrics about the finalizer and cleanup
+	// queues to be collected from Go runtime (a subset of MetricsGC, only
+	// provided by newer Go versions).
+	// e.g. go_gc_finalizers_queued_finalizers_total
+	// See also NewGoFinalizerCollector.
+	MetricsFinalizers = GoRuntimeMetricsRule{regexp.MustCompile(`^/gc/(finalizers|cleanups)/.*`)}
+	// MetricsCPU allows only the estimates of the CPU time spent by the Go
+	// runtime, split by class, to be collected from Go runtime.
+	// e.g. go_cpu_classes_gc_mark_assist_cpu_seconds_total
+	// See also NewGoCPUClassesCollector.
+	MetricsCPU = GoRuntimeMetricsRule{regexp.MustCompile(`^/cpu/classes/.*`)}
 )
 
 // WithGoCollectorMemStatsMetricsDisabled disables metrics that is gathered in runtime.MemStats structure such as:
@@ -148,6 +159,7 @@ const (
 //
 // Deprecated: Use WithGoCollectorRuntimeMetrics() and WithGoCollectorMemStatsMetricsDisabled() instead to control metrics.
 func WithGoCollections(flags GoCollectionOption) func(options *internal.GoCollectorOptions) {
+	internal.ReportDeprecated("collectors.WithGoCollections", "collectors.WithGoCollectorRuntimeMetrics")
 	return func(options *internal.GoCollectorOptions) {
 		if flags&GoRuntimeMemStatsCollection == 0 {
 			WithGoCollectorMemStatsMetricsDisabled()(options)
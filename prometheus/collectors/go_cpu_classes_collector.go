@@ -0,0 +1,153 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collectors
+
+import (
+	"runtime/metrics"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	goCPUClassesPrefix = "/cpu/classes/"
+	goCPUClassesSuffix = ":cpu-seconds"
+	goCPUClassesTotal  = goCPUClassesPrefix + "total" + goCPUClassesSuffix
+)
+
+type goCPUClassesCollector struct {
+	mtx     sync.Mutex // Protects samples.
+	samples []metrics.Sample
+	classes []string // Label values by index of samples, empty for the total.
+
+	classDesc, totalDesc *prometheus.Desc
+}
+
+// NewGoCPUClassesCollector returns a collector that exports the CPU time spent
+// by the Go runtime, split by what it has been spent on, as the following
+// counters:
+//
+//   - go_cpu_classes_seconds_total with the label "class", e.g. "user" for
+//     the CPU time spent running user goroutines, "gc_mark_assist" for the
+//     CPU time user goroutines spent assisting the GC, "gc_mark_dedicated"
+//     and "gc_mark_idle" for the CPU time spent by GC background workers,
+//     "gc_pause" for stop-the-world pauses, "scavenge_assist" and
+//     "scavenge_background" for returning memory to the OS, and "idle" for
+//     unused CPU time.
+//   - go_cpu_classes_available_seconds_total, the CPU time available to the
+//     Go runtime, i.e. GOMAXPROCS integrated over the wall time, which all
+//     classes add up to.
+//
+// Dividing the rate of a class by the rate of the available CPU time yields
+// the share of the CPU capacity available to the process (as limited by
+// GOMAXPROCS) that is spent on the class, e.g. to detect when the GC consumes
+// a problematic share of it on a busy service.
+//
+// The counters are derived from the runtime/metrics metrics
+// /cpu/classes/*:cpu-seconds. A class is exported for each such metric
+// provided by the Go version the program is built with, apart from the totals
+// of classes with subclasses. As documented by the runtime, these metrics are
+// estimates that are only updated at certain points in time (e.g. at the end
+// of a GC cycle) and are not directly comparable to the CPU time reported by
+// the operating system (e.g. process_cpu_seconds_total). They are also
+// exported under their runtime/metrics names by the GoCollector if enabled,
+// e.g. with WithGoCollectorRuntimeMetrics(MetricsCPU).
+func NewGoCPUClassesCollector() prometheus.Collector {
+	c := &goCPUClassesCollector{
+		classDesc: prometheus.NewDesc(
+			"go_cpu_classes_seconds_total",
+			"Estimated CPU time spent by the Go runtime, by class. All classes add up to go_cpu_classes_available_seconds_total.",
+			[]string{"class"}, nil,
+		),
+		totalDesc: prometheus.NewDesc(
+			"go_cpu_classes_available_seconds_total",
+			"Estimated CPU time available to the Go runtime, i.e. GOMAXPROCS integrated over the wall time.",
+			nil, nil,
+		),
+	}
+	all := metrics.All()
+	parents := map[string]struct{}{}
+	for _, d := range all {
+		if name, ok := goCPUClass(d.Name); ok {
+			if i := strings.LastIndexByte(name, '/'); i >= 0 {
+				parents[name[:i]] = struct{}{}
+			}
+		}
+	}
+	for _, d := range all {
+		if d.Kind != metrics.KindFloat64 {
+			continue
+		}
+		if d.Name == goCPUClassesTotal {
+			c.samples = append(c.samples, metrics.Sample{Name: d.Name})
+			c.classes = append(c.classes, "")
+			continue
+		}
+		name, ok := goCPUClass(d.Name)
+		if !ok {
+			continue
+		}
+		// Skip the totals of classes with subclasses, which would be
+		// counted twice when summing up all classes.
+		if parent, found := strings.CutSuffix(name, "/total"); found {
+			if _, ok := parents[parent]; ok {
+				continue
+			}
+		}
+		c.samples = append(c.samples, metrics.Sample{Name: d.Name})
+		c.classes = append(c.classes, strings.ReplaceAll(name, "/", "_"))
+	}
+	return c
+}
+
+// goCPUClass returns the class of a /cpu/classes/*:cpu-seconds runtime/metrics
+// name, e.g. "gc/mark/assist", and whether the name is such a name (other than
+// the overall total).
+func goCPUClass(name string) (string, bool) {
+	if name == goCPUClassesTotal || !strings.HasPrefix(name, goCPUClassesPrefix) || !strings.HasSuffix(name, goCPUClassesSuffix) {
+		return "", false
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(name, goCPUClassesPrefix), goCPUClassesSuffix), true
+}
+
+// Describe implements prometheus.Collector.
+func (c *goCPUClassesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.classDesc
+	ch <- c.totalDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *goCPUClassesCollector) Collect(ch chan<- prometheus.Metric) {
+	if len(c.samples) == 0 {
+		return
+	}
+	c.mtx.Lock()
+	metrics.Read(c.samples)
+	values := make([]float64, len(c.samples))
+	for i, s := range c.samples {
+		if s.Value.Kind() == metrics.KindFloat64 {
+			values[i] = s.Value.Float64()
+		}
+	}
+	c.mtx.Unlock()
+
+	for i, class := range c.classes {
+		if class == "" {
+			ch <- prometheus.MustNewConstMetric(c.totalDesc, prometheus.CounterValue, values[i])
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.classDesc, prometheus.CounterValue, values[i], class)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collectors
+
+import (
+	"math"
+	"runtime"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestGoCPUClassesCollector(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(NewGoCPUClassesCollector())
+
+	runtime.GC() // Updates the estimates.
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		available float64
+		sum       float64
+		classes   = map[string]bool{}
+	)
+	for _, mf := range mfs {
+		switch mf.GetName() {
+		case "go_cpu_classes_available_seconds_total":
+			available = mf.GetMetric()[0].GetCounter().GetValue()
+		case "go_cpu_classes_seconds_total":
+			for _, m := range mf.GetMetric() {
+				classes[m.GetLabel()[0].GetValue()] = true
+				sum += m.GetCounter().GetValue()
+			}
+		default:
+			t.Errorf("unexpected metric family %s", mf.GetName())
+		}
+	}
+	for _, class := range []string{"user", "idle", "gc_mark_assist", "gc_pause", "scavenge_background"} {
+		if !classes[class] {
+			t.Errorf("class %q not exported", class)
+		}
+	}
+	for _, class := range []string{"gc_total", "scavenge_total", "total"} {
+		if classes[class] {
+			t.Errorf("total %q exported as a class", class)
+		}
+	}
+	if available <= 0 {
+		t.Fatalf("got available CPU time %v, want a positive value", available)
+	}
+	if math.Abs(sum-available) > 1e-6*available {
+		t.Errorf("classes add up to %v, want %v", sum, available)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collectors
+
+import (
+	"runtime/metrics"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// goQueue describes a queue of the Go runtime whose length is derived from a
+// pair of cumulative runtime/metrics counters.
+type goQueue struct {
+	name, help       string
+	queued, executed string // Names of the runtime/metrics counters.
+}
+
+var goFinalizerQueues = []goQueue{
+	{
+		name:     "go_gc_finalizers_queue_length",
+		help:     "Approximate number of finalizers (set by runtime.SetFinalizer) queued but not yet executed.",
+		queued:   "/gc/finalizers/queued:finalizers",
+		executed: "/gc/finalizers/executed:finalizers",
+	},
+	{
+		name:     "go_gc_cleanups_queue_length",
+		help:     "Approximate number of cleanup functions (added by runtime.AddCleanup) queued but not yet executed.",
+		queued:   "/gc/cleanups/queued:cleanups",
+		executed: "/gc/cleanups/executed:cleanups",
+	},
+}
+
+type goFinalizerCollector struct {
+	mtx     sync.Mutex // Protects samples.
+	samples []metrics.Sample
+	descs   []*prometheus.Desc
+}
+
+// NewGoFinalizerCollector returns a collector that exports the current backlog
+// of the queues of the Go runtime for finalizers (set by runtime.SetFinalizer)
+// and cleanup functions (added by runtime.AddCleanup) as the following gauges:
+//
+//   - go_gc_finalizers_queue_length
+//   - go_gc_cleanups_queue_length
+//
+// The functions in those queues are executed by a single goroutine, so a slow
+// or blocking finalizer holds up all others. A growing backlog keeps the
+// objects referenced by the queued functions alive and is thus a subtle
+// cause of memory growth.
+//
+// The backlogs are derived from the cumulative runtime/metrics counters
+// /gc/{finalizers,cleanups}/{queued,executed}, which are only provided by
+// newer Go versions. A gauge is only exported if the Go version the program is
+// built with provides the counters it is derived from. The counters themselves
+// are exported by the GoCollector if enabled, e.g. with
+// WithGoCollectorRuntimeMetrics(MetricsFinalizers).
+func NewGoFinalizerCollector() prometheus.Collector {
+	available := map[string]struct{}{}
+	for _, d := range metrics.All() {
+		available[d.Name] = struct{}{}
+	}
+	c := &goFinalizerCollector{}
+	for _, q := range goFinalizerQueues {
+		_, queuedOK := available[q.queued]
+		_, executedOK := available[q.executed]
+		if !queuedOK || !executedOK {
+			continue
+		}
+		c.samples = append(c.samples, metrics.Sample{Name: q.queued}, metrics.Sample{Name: q.executed})
+		c.descs = append(c.descs, prometheus.NewDesc(q.name, q.help, nil, nil))
+	}
+	return c
+}
+
+// Describe implements prometheus.Collector.
+func (c *goFinalizerCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range c.descs {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *goFinalizerCollector) Collect(ch chan<- prometheus.Metric) {
+	if len(c.descs) == 0 {
+		return
+	}
+	c.mtx.Lock()
+	metrics.Read(c.samples)
+	lengths := make([]float64, len(c.descs))
+	for i := range c.descs {
+		queued, executed := c.samples[2*i].Value, c.samples[2*i+1].Value
+		if queued.Kind() != metrics.KindUint64 || executed.Kind() != metrics.KindUint64 {
+			continue
+		}
+		// Both counters are read non-atomically, so the difference
+		// might be slightly off. Never report a negative backlog.
+		if q, e := queued.Uint64(), executed.Uint64(); q > e {
+			lengths[i] = float64(q - e)
+		}
+	}
+	c.mtx.Unlock()
+
+	for i, d := range c.descs {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, lengths[i])
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collectors
+
+import (
+	"runtime"
+	"runtime/metrics"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// finalizable is large enough to not be allocated by the tiny allocator, for
+// which finalizers are not guaranteed to run.
+type finalizable struct{ _ [64]byte }
+
+func TestGoFinalizerCollector(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(NewGoFinalizerCollector())
+
+	available := map[string]bool{}
+	for _, d := range metrics.All() {
+		available[d.Name] = true
+	}
+	if !available["/gc/finalizers/queued:finalizers"] || !available["/gc/finalizers/executed:finalizers"] {
+		mfs, err := reg.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(mfs) != 0 {
+			t.Errorf("got %d metric families although the runtime provides no finalizer metrics", len(mfs))
+		}
+		return
+	}
+
+	// Block the finalizer goroutine, so that further finalizers queue up.
+	release := make(chan struct{})
+	defer close(release)
+	blocked := make(chan struct{})
+	runtime.SetFinalizer(&finalizable{}, func(*finalizable) {
+		close(blocked)
+		<-release
+	})
+	runtime.GC()
+	select {
+	case <-blocked:
+	case <-time.After(10 * time.Second):
+		t.Skip("finalizer did not run in time")
+	}
+	for i := 0; i < 10; i++ {
+		runtime.SetFinalizer(&finalizable{}, func(*finalizable) {})
+	}
+	runtime.GC()
+
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mf := range mfs {
+		if mf.GetName() != "go_gc_finalizers_queue_length" {
+			continue
+		}
+		if got := mf.GetMetric()[0].GetGauge().GetValue(); got < 10 {
+			t.Errorf("got finalizer queue length %v, want at least 10", got)
+		}
+		return
+	}
+	t.Error("metric go_gc_finalizers_queue_length not found")
+}
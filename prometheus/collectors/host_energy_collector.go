@@ -0,0 +1,118 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collectors
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// HostEnergyCollectorOpts defines the behavior of a host energy collector
+// created with NewHostEnergyCollector.
+type HostEnergyCollectorOpts struct {
+	// SysfsPath is the mount point of the sys filesystem. If empty, "/sys"
+	// is used.
+	SysfsPath string
+	// If true, any error encountered during collection is reported as an
+	// invalid metric (see NewInvalidMetric). Otherwise, errors are ignored
+	// and the collected metrics will be incomplete. (Possibly, no metrics
+	// will be collected at all.) While that's usually not desired, it is
+	// appropriate for the common case where the host simply lacks the
+	// hardware support (or the permissions) to read some of the sensors.
+	ReportErrors bool
+}
+
+type hostEnergyCollector struct {
+	sysfsPath    string
+	reportErrors bool
+
+	raplEnergy  *prometheus.Desc
+	temperature *prometheus.Desc
+	power       *prometheus.Desc
+}
+
+// NewHostEnergyCollector returns a collector which exports energy and
+// temperature readings of the host the program is running on, as needed for
+// energy telemetry of services running on edge devices or bare metal, without
+// deploying a separate exporter. The following metrics are exported:
+//
+//   - host_rapl_energy_joules_total (counter, by "zone" and "index"): The
+//     energy consumed as measured by Intel RAPL (Running Average Power
+//     Limit, also provided by recent AMD CPUs), e.g. for the zone "package"
+//     (the whole CPU package) or "dram". The underlying hardware counter
+//     wraps around regularly (after tens of minutes under load on some
+//     hardware), which appears as a counter reset.
+//   - host_hwmon_temperature_celsius (gauge, by "chip", "chip_name",
+//     "sensor", and "label"): The temperatures reported by hwmon sensors,
+//     e.g. of CPU cores or of the board.
+//   - host_hwmon_power_watts (gauge, same labels as the temperature): The
+//     power reported by hwmon sensors, e.g. of a power supply or a GPU.
+//
+// The "chip" label is the name of the hwmon device (e.g. "hwmon2"),
+// "chip_name" its driver-provided name (e.g. "coretemp"), "sensor" the sensor
+// name (e.g. "temp1"), and "label" the sensor label if provided by the driver
+// (e.g. "Package id 0"), or empty otherwise.
+//
+// The collector is not registered anywhere by default and has to be
+// registered explicitly. It only works on Linux with a sys filesystem. On other
+// operating systems, it will not collect any metrics. Note that reading the
+// RAPL energy counters usually requires root privileges (or adjusted
+// permissions of the energy_uj files) on current kernels.
+func NewHostEnergyCollector(opts HostEnergyCollectorOpts) prometheus.Collector {
+	sensorLabels := []string{"chip", "chip_name", "sensor", "label"}
+	c := &hostEnergyCollector{
+		sysfsPath:    opts.SysfsPath,
+		reportErrors: opts.ReportErrors,
+		raplEnergy: prometheus.NewDesc(
+			"host_rapl_energy_joules_total",
+			"Energy consumed as measured by the RAPL energy counter of the zone.",
+			[]string{"zone", "index"}, nil,
+		),
+		temperature: prometheus.NewDesc(
+			"host_hwmon_temperature_celsius",
+			"Temperature reported by the hwmon sensor.",
+			sensorLabels, nil,
+		),
+		power: prometheus.NewDesc(
+			"host_hwmon_power_watts",
+			"Power reported by the hwmon sensor.",
+			sensorLabels, nil,
+		),
+	}
+	if c.sysfsPath == "" {
+		c.sysfsPath = "/sys"
+	}
+	return c
+}
+
+// Describe implements Collector.
+func (c *hostEnergyCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.raplEnergy
+	ch <- c.temperature
+	ch <- c.power
+}
+
+// Collect implements Collector.
+func (c *hostEnergyCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collectEnergy(ch)
+}
+
+func (c *hostEnergyCollector) reportError(ch chan<- prometheus.Metric, desc *prometheus.Desc, err error) {
+	if !c.reportErrors {
+		return
+	}
+	if desc == nil {
+		desc = prometheus.NewInvalidDesc(err)
+	}
+	ch <- prometheus.NewInvalidMetric(desc, err)
+}
@@ -0,0 +1,121 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collectors
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/procfs/sysfs"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func (c *hostEnergyCollector) collectEnergy(ch chan<- prometheus.Metric) {
+	c.collectRAPL(ch)
+	c.collectHwmon(ch)
+}
+
+func (c *hostEnergyCollector) collectRAPL(ch chan<- prometheus.Metric) {
+	fs, err := sysfs.NewFS(c.sysfsPath)
+	if err != nil {
+		c.reportError(ch, c.raplEnergy, err)
+		return
+	}
+	zones, err := sysfs.GetRaplZones(fs)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			c.reportError(ch, c.raplEnergy, err)
+		}
+		return
+	}
+	for _, z := range zones {
+		uj, err := z.GetEnergyMicrojoules()
+		if err != nil {
+			c.reportError(ch, c.raplEnergy, err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(
+			c.raplEnergy, prometheus.CounterValue, float64(uj)/1e6,
+			z.Name, strconv.Itoa(z.Index),
+		)
+	}
+}
+
+func (c *hostEnergyCollector) collectHwmon(ch chan<- prometheus.Metric) {
+	chips, err := filepath.Glob(filepath.Join(c.sysfsPath, "class", "hwmon", "hwmon*"))
+	if err != nil {
+		c.reportError(ch, nil, err)
+		return
+	}
+	for _, chipPath := range chips {
+		chip := filepath.Base(chipPath)
+		chipName, err := readTrimmed(filepath.Join(chipPath, "name"))
+		if err != nil {
+			c.reportError(ch, nil, err)
+			continue
+		}
+		c.collectHwmonSensors(ch, chipPath, chip, chipName, "temp", c.temperature, 1e-3) // Millidegree Celsius.
+		c.collectHwmonSensors(ch, chipPath, chip, chipName, "power", c.power, 1e-6)      // Microwatt.
+	}
+}
+
+// collectHwmonSensors collects the sensors of the given kind (e.g. "temp") of
+// a hwmon chip. The values read from the <sensor>_input files are multiplied
+// by scale.
+func (c *hostEnergyCollector) collectHwmonSensors(
+	ch chan<- prometheus.Metric,
+	chipPath, chip, chipName, kind string,
+	desc *prometheus.Desc,
+	scale float64,
+) {
+	inputs, err := filepath.Glob(filepath.Join(chipPath, kind+"*_input"))
+	if err != nil {
+		c.reportError(ch, desc, err)
+		return
+	}
+	sort.Strings(inputs)
+	for _, input := range inputs {
+		sensor := strings.TrimSuffix(filepath.Base(input), "_input")
+		raw, err := readTrimmed(input)
+		if err != nil {
+			// Some drivers return an error for sensors that are
+			// currently not available.
+			c.reportError(ch, desc, err)
+			continue
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			c.reportError(ch, desc, err)
+			continue
+		}
+		label, _ := readTrimmed(filepath.Join(chipPath, sensor+"_label")) // Optional.
+		ch <- prometheus.MustNewConstMetric(
+			desc, prometheus.GaugeValue, v*scale,
+			chip, chipName, sensor, label,
+		)
+	}
+}
+
+func readTrimmed(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package collectors
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func writeSysfsFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestHostEnergyCollector(t *testing.T) {
+	sys := t.TempDir()
+	writeSysfsFiles(t, sys, map[string]string{
+		"class/powercap/intel-rapl:0/name":                "package-0\n",
+		"class/powercap/intel-rapl:0/energy_uj":           "2500000\n",
+		"class/powercap/intel-rapl:0/max_energy_range_uj": "262143328850\n",
+		"class/hwmon/hwmon0/name":                         "coretemp\n",
+		"class/hwmon/hwmon0/temp1_input":                  "45000\n",
+		"class/hwmon/hwmon0/temp1_label":                  "Package id 0\n",
+		"class/hwmon/hwmon0/temp2_input":                  "41500\n",
+		"class/hwmon/hwmon1/name":                         "amdgpu\n",
+		"class/hwmon/hwmon1/power1_input":                 "12500000\n",
+	})
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(NewHostEnergyCollector(HostEnergyCollectorOpts{SysfsPath: sys, ReportErrors: true}))
+	expected := `
+# HELP host_hwmon_power_watts Power reported by the hwmon sensor.
+# TYPE host_hwmon_power_watts gauge
+host_hwmon_power_watts{chip="hwmon1",chip_name="amdgpu",label="",sensor="power1"} 12.5
+# HELP host_hwmon_temperature_celsius Temperature reported by the hwmon sensor.
+# TYPE host_hwmon_temperature_celsius gauge
+host_hwmon_temperature_celsius{chip="hwmon0",chip_name="coretemp",label="Package id 0",sensor="temp1"} 45
+host_hwmon_temperature_celsius{chip="hwmon0",chip_name="coretemp",label="",sensor="temp2"} 41.5
+# HELP host_rapl_energy_joules_total Energy consumed as measured by the RAPL energy counter of the zone.
+# TYPE host_rapl_energy_joules_total counter
+host_rapl_energy_joules_total{index="0",zone="package"} 2.5
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHostEnergyCollectorMissingSysfs(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(NewHostEnergyCollector(HostEnergyCollectorOpts{SysfsPath: t.TempDir(), ReportErrors: true}))
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Errorf("unexpected error for host without sensors: %v", err)
+	}
+	if len(mfs) != 0 {
+		t.Errorf("got %d metric families, want none", len(mfs))
+	}
+}
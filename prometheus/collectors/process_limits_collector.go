@@ -0,0 +1,134 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collectors
+
+import (
+	"math"
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Values of the "resource" label of the metrics exported by the collector
+// returned by NewProcessLimitsCollector, named after the corresponding
+// RLIMIT_* constants.
+const (
+	resourceOpenFiles    = "nofile"
+	resourceProcesses    = "nproc"
+	resourceAddressSpace = "as"
+)
+
+type processLimitsCollector struct {
+	pidFn        func() (int, error)
+	reportErrors bool
+
+	limit      *prometheus.Desc
+	usage      *prometheus.Desc
+	usageRatio *prometheus.Desc
+}
+
+// NewProcessLimitsCollector returns a collector which exports the resource
+// limits (ulimits) of a process together with the current usage of the
+// limited resources. The PidFn, Namespace, and ReportErrors fields of the
+// provided ProcessCollectorOpts work as for NewProcessCollector. The following
+// metrics are exported, each with a "resource" label:
+//
+//   - process_resource_limit: The soft limit of the resource. An unlimited
+//     resource is reported as +Inf.
+//   - process_resource_usage: The current usage of the resource.
+//   - process_resource_usage_ratio: The ratio of the usage to the soft limit
+//     (between 0 and 1), which allows alerting before a limit is reached
+//     without knowing the limit in advance. An unlimited resource is reported
+//     with a ratio of 0.
+//
+// The resources are "nofile" (the number of open file descriptors, see
+// RLIMIT_NOFILE), "as" (the virtual memory size in bytes, see RLIMIT_AS), and
+// "nproc" (see RLIMIT_NPROC). For "nproc", only the limit is exported, as the
+// kernel counts the threads of all processes of the user against it, which
+// cannot be determined per process.
+//
+// The collector only works on Linux with a proc filesystem. On other operating
+// systems, it will not collect any metrics.
+func NewProcessLimitsCollector(opts ProcessCollectorOpts) prometheus.Collector {
+	ns := ""
+	if len(opts.Namespace) > 0 {
+		ns = opts.Namespace + "_"
+	}
+	c := &processLimitsCollector{
+		pidFn:        opts.PidFn,
+		reportErrors: opts.ReportErrors,
+		limit: prometheus.NewDesc(
+			ns+"process_resource_limit",
+			"Soft limit of the resource, +Inf if unlimited.",
+			[]string{"resource"}, nil,
+		),
+		usage: prometheus.NewDesc(
+			ns+"process_resource_usage",
+			"Current usage of the resource.",
+			[]string{"resource"}, nil,
+		),
+		usageRatio: prometheus.NewDesc(
+			ns+"process_resource_usage_ratio",
+			"Ratio of the current usage of the resource to its soft limit, 0 if unlimited.",
+			[]string{"resource"}, nil,
+		),
+	}
+	if c.pidFn == nil {
+		pid := os.Getpid()
+		c.pidFn = func() (int, error) { return pid, nil }
+	}
+	return c
+}
+
+// Describe implements Collector.
+func (c *processLimitsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.limit
+	ch <- c.usage
+	ch <- c.usageRatio
+}
+
+// Collect implements Collector.
+func (c *processLimitsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collectLimits(ch)
+}
+
+// collectResource sends the metrics for a resource with the provided usage
+// and soft limit, where a limit of unlimited means that the resource is not
+// limited. If usage is negative, only the limit is sent.
+func (c *processLimitsCollector) collectResource(ch chan<- prometheus.Metric, resource string, usage float64, limit, unlimited uint64) {
+	l := float64(limit)
+	if limit == unlimited {
+		l = math.Inf(1)
+	}
+	ch <- prometheus.MustNewConstMetric(c.limit, prometheus.GaugeValue, l, resource)
+	if usage < 0 {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.usage, prometheus.GaugeValue, usage, resource)
+	var ratio float64
+	if limit != unlimited && limit > 0 {
+		ratio = usage / l
+	}
+	ch <- prometheus.MustNewConstMetric(c.usageRatio, prometheus.GaugeValue, ratio, resource)
+}
+
+func (c *processLimitsCollector) reportError(ch chan<- prometheus.Metric, desc *prometheus.Desc, err error) {
+	if !c.reportErrors {
+		return
+	}
+	if desc == nil {
+		desc = prometheus.NewInvalidDesc(err)
+	}
+	ch <- prometheus.NewInvalidMetric(desc, err)
+}
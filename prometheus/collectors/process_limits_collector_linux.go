@@ -0,0 +1,53 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collectors
+
+import (
+	"github.com/prometheus/procfs"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// procfsUnlimited is how procfs reports an unlimited resource.
+const procfsUnlimited = ^uint64(0)
+
+func (c *processLimitsCollector) collectLimits(ch chan<- prometheus.Metric) {
+	pid, err := c.pidFn()
+	if err != nil {
+		c.reportError(ch, nil, err)
+		return
+	}
+	p, err := procfs.NewProc(pid)
+	if err != nil {
+		c.reportError(ch, nil, err)
+		return
+	}
+	limits, err := p.Limits()
+	if err != nil {
+		c.reportError(ch, nil, err)
+		return
+	}
+
+	if fds, err := p.FileDescriptorsLen(); err == nil {
+		c.collectResource(ch, resourceOpenFiles, float64(fds), limits.OpenFiles, procfsUnlimited)
+	} else {
+		c.reportError(ch, c.usage, err)
+	}
+	if stat, err := p.Stat(); err == nil {
+		c.collectResource(ch, resourceAddressSpace, float64(stat.VirtualMemory()), limits.AddressSpace, procfsUnlimited)
+	} else {
+		c.reportError(ch, c.usage, err)
+	}
+	c.collectResource(ch, resourceProcesses, -1, limits.Processes, procfsUnlimited)
+}
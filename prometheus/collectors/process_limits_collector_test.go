@@ -0,0 +1,84 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package collectors
+
+import (
+	"math"
+	"syscall"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestProcessLimitsCollector(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(NewProcessLimitsCollector(ProcessCollectorOpts{
+		Namespace:    "foo",
+		ReportErrors: true,
+	}))
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[string]map[string]float64{}
+	for _, mf := range mfs {
+		values[mf.GetName()] = map[string]float64{}
+		for _, m := range mf.GetMetric() {
+			values[mf.GetName()][resourceLabel(m)] = m.GetGauge().GetValue()
+		}
+	}
+
+	var rlimit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlimit); err != nil {
+		t.Fatal(err)
+	}
+	wantLimit := float64(rlimit.Cur)
+	if rlimit.Cur == math.MaxUint64 {
+		wantLimit = math.Inf(1)
+	}
+	if got := values["foo_process_resource_limit"]["nofile"]; got != wantLimit {
+		t.Errorf("got nofile limit %v, want %v", got, wantLimit)
+	}
+	for _, resource := range []string{"nofile", "as", "nproc"} {
+		if _, ok := values["foo_process_resource_limit"][resource]; !ok {
+			t.Errorf("missing limit for resource %q", resource)
+		}
+	}
+	for _, resource := range []string{"nofile", "as"} {
+		if got := values["foo_process_resource_usage"][resource]; got <= 0 {
+			t.Errorf("got usage %v for resource %q, want > 0", got, resource)
+		}
+		if got := values["foo_process_resource_usage_ratio"][resource]; got < 0 || got > 1 {
+			t.Errorf("got usage ratio %v for resource %q, want between 0 and 1", got, resource)
+		}
+	}
+	if _, ok := values["foo_process_resource_usage"]["nproc"]; ok {
+		t.Error("unexpected usage for resource nproc")
+	}
+}
+
+func resourceLabel(m *dto.Metric) string {
+	for _, lp := range m.GetLabel() {
+		if lp.GetName() == "resource" {
+			return lp.GetValue()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collectors
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PSICollectorOpts defines the behavior of a pressure stall information
+// collector created with NewPSICollector.
+type PSICollectorOpts struct {
+	// ProcfsPath is the mount point of the proc filesystem, used to read
+	// the host-wide pressure stall information. If empty, "/proc" is used.
+	ProcfsPath string
+	// CgroupPath, if not empty, is the path of a cgroup v2 directory (e.g.
+	// "/sys/fs/cgroup/system.slice/my.service"). Its pressure files are
+	// read instead of the host-wide ones, which restricts the pressure
+	// stall information to the tasks within the cgroup.
+	CgroupPath string
+	// If true, any error encountered during collection is reported as an
+	// invalid metric (see NewInvalidMetric). Otherwise, errors are ignored
+	// and the collected metrics will be incomplete. Missing pressure files
+	// (e.g. on kernels older than 4.20 or with PSI disabled) are never
+	// reported as errors.
+	ReportErrors bool
+}
+
+type psiCollector struct {
+	procfsPath, cgroupPath string
+	reportErrors           bool
+
+	stalled      *prometheus.Desc
+	stalledRatio *prometheus.Desc
+}
+
+// NewPSICollector returns a collector which exports the pressure stall
+// information (PSI) of the Linux kernel for the resources cpu, io, and memory,
+// either of the whole host or of a cgroup (see PSICollectorOpts.CgroupPath).
+// PSI is an early warning signal for saturation of a resource. The following
+// metrics are exported:
+//
+//   - pressure_stalled_seconds_total (counter, by "resource" and "kind"):
+//     The total time in which tasks were stalled waiting for the resource.
+//   - pressure_stalled_ratio (gauge, by "resource", "kind", and "window"):
+//     The share of the time in which tasks were stalled waiting for the
+//     resource (as a ratio between 0 and 1), averaged by the kernel over
+//     the window "10s", "60s", or "300s".
+//
+// The "kind" label is "some" for the time in which at least some tasks were
+// stalled, and "full" for the time in which all non-idle tasks were stalled
+// simultaneously. Note that the kernel reports no (or an always zero) "full"
+// line for cpu on the host level. For custom evaluation windows, apply rate to
+// pressure_stalled_seconds_total.
+//
+// The collector is not registered anywhere by default and has to be
+// registered explicitly. It only works on Linux. On other operating systems,
+// it will not collect any metrics.
+func NewPSICollector(opts PSICollectorOpts) prometheus.Collector {
+	c := &psiCollector{
+		procfsPath:   opts.ProcfsPath,
+		cgroupPath:   opts.CgroupPath,
+		reportErrors: opts.ReportErrors,
+		stalled: prometheus.NewDesc(
+			"pressure_stalled_seconds_total",
+			"Total time in which tasks were stalled waiting for the resource.",
+			[]string{"resource", "kind"}, nil,
+		),
+		stalledRatio: prometheus.NewDesc(
+			"pressure_stalled_ratio",
+			"Share of the time in which tasks were stalled waiting for the resource, averaged over the window.",
+			[]string{"resource", "kind", "window"}, nil,
+		),
+	}
+	if c.procfsPath == "" {
+		c.procfsPath = "/proc"
+	}
+	return c
+}
+
+// Describe implements Collector.
+func (c *psiCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.stalled
+	ch <- c.stalledRatio
+}
+
+// Collect implements Collector.
+func (c *psiCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collectPSI(ch)
+}
+
+func (c *psiCollector) reportError(ch chan<- prometheus.Metric, desc *prometheus.Desc, err error) {
+	if !c.reportErrors {
+		return
+	}
+	if desc == nil {
+		desc = prometheus.NewInvalidDesc(err)
+	}
+	ch <- prometheus.NewInvalidMetric(desc, err)
+}
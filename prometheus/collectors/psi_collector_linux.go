@@ -0,0 +1,76 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collectors
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/prometheus/procfs"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var psiResources = []string{"cpu", "io", "memory"}
+
+// psiWindows are the values of the "window" label for the averages avg10,
+// avg60, and avg300 reported by the kernel.
+var psiWindows = [3]string{"10s", "60s", "300s"}
+
+func (c *psiCollector) collectPSI(ch chan<- prometheus.Metric) {
+	// procfs reads <mount point>/pressure/<resource>. The pressure files of
+	// a cgroup are named <cgroup>/<resource>.pressure instead, which is
+	// reached with the cgroup directory as the mount point and
+	// "../<resource>.pressure" as the resource, as the path is cleaned
+	// lexically.
+	mountPoint, name := c.procfsPath, func(resource string) string { return resource }
+	if c.cgroupPath != "" {
+		mountPoint, name = c.cgroupPath, func(resource string) string { return "../" + resource + ".pressure" }
+	}
+	fs, err := procfs.NewFS(mountPoint)
+	if err != nil {
+		c.reportError(ch, c.stalled, err)
+		return
+	}
+	for _, resource := range psiResources {
+		stats, err := fs.PSIStatsForResource(name(resource))
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				path := filepath.Join(mountPoint, "pressure", name(resource))
+				c.reportError(ch, c.stalled, fmt.Errorf("reading %s: %w", path, err))
+			}
+			continue
+		}
+		for _, l := range []struct {
+			kind string
+			line *procfs.PSILine
+		}{{"some", stats.Some}, {"full", stats.Full}} {
+			if l.line == nil {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(
+				c.stalled, prometheus.CounterValue, float64(l.line.Total)/1e6,
+				resource, l.kind,
+			)
+			for i, avg := range []float64{l.line.Avg10, l.line.Avg60, l.line.Avg300} {
+				ch <- prometheus.MustNewConstMetric(
+					c.stalledRatio, prometheus.GaugeValue, avg/100,
+					resource, l.kind, psiWindows[i],
+				)
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package collectors
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestPSICollector(t *testing.T) {
+	proc := t.TempDir()
+	writeSysfsFiles(t, proc, map[string]string{
+		"pressure/cpu": "some avg10=1.50 avg60=0.50 avg300=0.25 total=2500000\n" +
+			"full avg10=0.00 avg60=0.00 avg300=0.00 total=0\n",
+		"pressure/memory": "some avg10=10.00 avg60=5.00 avg300=2.00 total=4000000\n" +
+			"full avg10=5.00 avg60=2.50 avg300=1.00 total=1000000\n",
+		// No io file, as if not supported.
+	})
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(NewPSICollector(PSICollectorOpts{ProcfsPath: proc, ReportErrors: true}))
+	expected := `
+# HELP pressure_stalled_ratio Share of the time in which tasks were stalled waiting for the resource, averaged over the window.
+# TYPE pressure_stalled_ratio gauge
+pressure_stalled_ratio{kind="full",resource="cpu",window="10s"} 0
+pressure_stalled_ratio{kind="full",resource="cpu",window="300s"} 0
+pressure_stalled_ratio{kind="full",resource="cpu",window="60s"} 0
+pressure_stalled_ratio{kind="full",resource="memory",window="10s"} 0.05
+pressure_stalled_ratio{kind="full",resource="memory",window="300s"} 0.01
+pressure_stalled_ratio{kind="full",resource="memory",window="60s"} 0.025
+pressure_stalled_ratio{kind="some",resource="cpu",window="10s"} 0.015
+pressure_stalled_ratio{kind="some",resource="cpu",window="300s"} 0.0025
+pressure_stalled_ratio{kind="some",resource="cpu",window="60s"} 0.005
+pressure_stalled_ratio{kind="some",resource="memory",window="10s"} 0.1
+pressure_stalled_ratio{kind="some",resource="memory",window="300s"} 0.02
+pressure_stalled_ratio{kind="some",resource="memory",window="60s"} 0.05
+# HELP pressure_stalled_seconds_total Total time in which tasks were stalled waiting for the resource.
+# TYPE pressure_stalled_seconds_total counter
+pressure_stalled_seconds_total{kind="full",resource="cpu"} 0
+pressure_stalled_seconds_total{kind="full",resource="memory"} 1
+pressure_stalled_seconds_total{kind="some",resource="cpu"} 2.5
+pressure_stalled_seconds_total{kind="some",resource="memory"} 4
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPSICollectorCgroup(t *testing.T) {
+	cgroup := t.TempDir()
+	writeSysfsFiles(t, cgroup, map[string]string{
+		"io.pressure": "some avg10=0.00 avg60=0.00 avg300=0.00 total=500000\n" +
+			"full avg10=0.00 avg60=0.00 avg300=0.00 total=250000\n",
+		"memory.pressure": "some avg10=garbage avg60=0.00 avg300=0.00 total=0\n",
+	})
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(NewPSICollector(PSICollectorOpts{CgroupPath: cgroup}))
+	expected := `
+# HELP pressure_stalled_seconds_total Total time in which tasks were stalled waiting for the resource.
+# TYPE pressure_stalled_seconds_total counter
+pressure_stalled_seconds_total{kind="full",resource="io"} 0.25
+pressure_stalled_seconds_total{kind="some",resource="io"} 0.5
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "pressure_stalled_seconds_total"); err != nil {
+		t.Error(err)
+	}
+
+	reg = prometheus.NewPedanticRegistry()
+	reg.MustRegister(NewPSICollector(PSICollectorOpts{CgroupPath: cgroup, ReportErrors: true}))
+	if _, err := reg.Gather(); err == nil || !strings.Contains(err.Error(), "memory.pressure") {
+		t.Errorf("expected parse error for memory.pressure, got %v", err)
+	}
+}
@@ -90,7 +90,7 @@ func NewCounter(opts CounterOpts) Counter {
 		opts.Help,
 		nil,
 		opts.ConstLabels,
-	)
+	).withMetadata(opts.Metadata)
 	if opts.now == nil {
 		opts.now = time.Now
 	}
@@ -201,7 +201,7 @@ func (v2) NewCounterVec(opts CounterVecOpts) *CounterVec {
 		opts.Help,
 		opts.VariableLabels,
 		opts.ConstLabels,
-	)
+	).withMetadata(opts.Metadata)
 	if opts.now == nil {
 		opts.now = time.Now
 	}
@@ -350,5 +350,5 @@ func NewCounterFunc(opts CounterOpts, function func() float64) CounterFunc {
 		opts.Help,
 		nil,
 		opts.ConstLabels,
-	), CounterValue, function)
+	).withMetadata(opts.Metadata), CounterValue, function)
 }
@@ -0,0 +1,134 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus/internal"
+)
+
+// DeprecatedUsage describes the calls of a deprecated function from one call
+// site.
+type DeprecatedUsage struct {
+	// Function is the deprecated function, e.g. "prometheus.NewGoCollector".
+	Function string
+	// Replacement is the function to use instead.
+	Replacement string
+	// CallSite is the location of the call as "file:line".
+	CallSite string
+	// Calls is the number of calls so far.
+	Calls uint64
+}
+
+// DeprecationReporter records the calls of deprecated functions of this module
+// (e.g. NewGoCollector, which has been replaced by collectors.NewGoCollector,
+// or collectors.WithGoCollections) by call site, which helps to find and
+// migrate the remaining usages in a large code base before a future major
+// version removes them. Calls from within this module (e.g. through the
+// replacements in the collectors package) are not recorded. Neither are the
+// uses of deprecated types and constants, like collectors.GoCollectionOption,
+// which are only used together with a deprecated function anyway.
+//
+// A DeprecationReporter only records calls while it is set with
+// SetDeprecationReporter. It is also a Collector exposing the counter
+// "client_golang_deprecated_calls_total" with the labels "function",
+// "replacement", and "call_site". Alternatively, use Usages to obtain a
+// report.
+type DeprecationReporter struct {
+	desc *Desc
+
+	mtx    sync.Mutex
+	usages map[deprecatedCall]uint64
+}
+
+type deprecatedCall struct {
+	function, replacement, callSite string
+}
+
+// NewDeprecationReporter returns a DeprecationReporter without any recorded
+// calls. Use SetDeprecationReporter to activate it.
+func NewDeprecationReporter() *DeprecationReporter {
+	return &DeprecationReporter{
+		desc: NewDesc(
+			"client_golang_deprecated_calls_total",
+			"Total number of calls of deprecated functions of the Prometheus client library, by call site.",
+			[]string{"function", "replacement", "call_site"}, nil,
+		),
+		usages: map[deprecatedCall]uint64{},
+	}
+}
+
+// SetDeprecationReporter sets the DeprecationReporter recording the calls of
+// deprecated functions from now on. Calls are not recorded by default, and
+// setting nil stops recording. Looking up the call site of each call of a
+// deprecated function has a small cost, which is usually negligible, as those
+// functions are typically only called during initialization.
+func SetDeprecationReporter(r *DeprecationReporter) {
+	if r == nil {
+		internal.SetDeprecationHook(nil)
+		return
+	}
+	internal.SetDeprecationHook(r.record)
+}
+
+// Usages returns the recorded calls, sorted by function and call site.
+func (r *DeprecationReporter) Usages() []DeprecatedUsage {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	usages := make([]DeprecatedUsage, 0, len(r.usages))
+	for call, n := range r.usages {
+		usages = append(usages, DeprecatedUsage{
+			Function:    call.function,
+			Replacement: call.replacement,
+			CallSite:    call.callSite,
+			Calls:       n,
+		})
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].Function != usages[j].Function {
+			return usages[i].Function < usages[j].Function
+		}
+		return usages[i].CallSite < usages[j].CallSite
+	})
+	return usages
+}
+
+// Describe implements Collector.
+func (r *DeprecationReporter) Describe(ch chan<- *Desc) {
+	ch <- r.desc
+}
+
+// Collect implements Collector.
+func (r *DeprecationReporter) Collect(ch chan<- Metric) {
+	for _, u := range r.Usages() {
+		ch <- MustNewConstMetric(r.desc, CounterValue, float64(u.Calls), u.Function, u.Replacement, u.CallSite)
+	}
+}
+
+// record records a call of a deprecated function. It is set as the hook of
+// internal.ReportDeprecated by SetDeprecationReporter.
+func (r *DeprecationReporter) record(function, replacement, callSite string) {
+	call := deprecatedCall{
+		function:    function,
+		replacement: replacement,
+		callSite:    callSite,
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.usages[call]++
+}
@@ -0,0 +1,61 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus_test
+
+import (
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestDeprecationReporter(t *testing.T) {
+	prometheus.NewBuildInfoCollector() // Not recorded, no reporter set.
+
+	r := prometheus.NewDeprecationReporter()
+	prometheus.SetDeprecationReporter(r)
+	defer prometheus.SetDeprecationReporter(nil)
+
+	_, file, line, _ := runtime.Caller(0)
+	for i := 0; i < 2; i++ {
+		prometheus.NewBuildInfoCollector() // line + 2
+	}
+	prometheus.NewExpvarCollector(nil) // line + 4
+	collectors.NewBuildInfoCollector() // Not recorded, the replacement.
+	collectors.NewGoCollector(
+		collectors.WithGoCollections(collectors.GoRuntimeMetricsCollection), // line + 7
+	)
+
+	usages := r.Usages()
+	want := []prometheus.DeprecatedUsage{
+		{Function: "collectors.WithGoCollections", Replacement: "collectors.WithGoCollectorRuntimeMetrics", CallSite: file + ":" + strconv.Itoa(line+7), Calls: 1},
+		{Function: "prometheus.NewBuildInfoCollector", Replacement: "collectors.NewBuildInfoCollector", CallSite: file + ":" + strconv.Itoa(line+2), Calls: 2},
+		{Function: "prometheus.NewExpvarCollector", Replacement: "collectors.NewExpvarCollector", CallSite: file + ":" + strconv.Itoa(line+4), Calls: 1},
+	}
+	if len(usages) != len(want) {
+		t.Fatalf("got usages %+v, want %+v", usages, want)
+	}
+	for i := range want {
+		if usages[i] != want[i] {
+			t.Errorf("got usage %+v, want %+v", usages[i], want[i])
+		}
+	}
+
+	if got, want := testutil.CollectAndCount(r, "client_golang_deprecated_calls_total"), 3; got != want {
+		t.Errorf("got %d series, want %d", got, want)
+	}
+}
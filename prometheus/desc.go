@@ -64,6 +64,9 @@ type Desc struct {
 	// err is an error that occurred during construction. It is reported on
 	// registration time.
 	err error
+	// metadata is opaque metadata not exposed to Prometheus, see
+	// Desc.Metadata.
+	metadata map[string]string
 }
 
 // NewDesc allocates and initializes a new Desc. Errors are recorded in the Desc
@@ -181,6 +184,80 @@ func NewInvalidDesc(err error) *Desc {
 	}
 }
 
+// DescLabel is a label of a Desc as returned by Desc.Labels.
+type DescLabel struct {
+	Name string
+	// Value is the value of a constant label. It is empty for a variable
+	// label.
+	Value string
+	// Const is true for a constant label and false for a variable label.
+	Const bool
+	// Constrained is true for a variable label that has a constraint
+	// function (see ConstrainedLabels).
+	Constrained bool
+}
+
+// Labels returns the full label schema of the Desc: first the constant labels
+// with their values, sorted by label name, followed by the variable labels in
+// the order in which they have been declared (which is also the order in which
+// their values have to be provided to the Metric). The result is meant for
+// tooling like documentation generators. It is a fresh slice and can be
+// modified by the caller. An invalid Desc (one with an error recorded) returns
+// nil.
+func (d *Desc) Labels() []DescLabel {
+	if d.err != nil {
+		return nil
+	}
+	var variableNames []string
+	if d.variableLabels != nil {
+		variableNames = d.variableLabels.names
+	}
+	labels := make([]DescLabel, 0, len(d.constLabelPairs)+len(variableNames))
+	for _, lp := range d.constLabelPairs {
+		labels = append(labels, DescLabel{
+			Name:  lp.GetName(),
+			Value: lp.GetValue(),
+			Const: true,
+		})
+	}
+	for _, name := range variableNames {
+		fn, ok := d.variableLabels.labelConstraints[name]
+		labels = append(labels, DescLabel{
+			Name:        name,
+			Constrained: ok && fn != nil,
+		})
+	}
+	return labels
+}
+
+// Metadata returns the opaque metadata attached to the Desc (e.g. via the
+// Metadata field of Opts or with DescBuilder.Metadata), or nil if there is
+// none. The metadata is meant for tooling like metric catalogs. It is not
+// exposed to Prometheus. The result is a fresh map and can be modified by the
+// caller.
+func (d *Desc) Metadata() map[string]string {
+	return copyMetadata(d.metadata)
+}
+
+// withMetadata sets a copy of the provided metadata as the metadata of d and
+// returns d.
+func (d *Desc) withMetadata(metadata map[string]string) *Desc {
+	d.metadata = copyMetadata(metadata)
+	return d
+}
+
+// copyMetadata returns a copy of the provided metadata, or nil if it is empty.
+func copyMetadata(metadata map[string]string) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+	c := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		c[k] = v
+	}
+	return c
+}
+
 func (d *Desc) String() string {
 	lpStrings := make([]string, 0, len(d.constLabelPairs))
 	for _, lp := range d.constLabelPairs {
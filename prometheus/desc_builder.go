@@ -0,0 +1,123 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DescBuilder creates a Desc with a fluent API. In contrast to NewDesc, which
+// records errors in the Desc to be reported at registration time, DescBuilder
+// reports errors when the Desc is built. Create a DescBuilder with
+// NewDescBuilder, configure it with its methods, and finally call Build or
+// MustBuild, like this:
+//
+//	desc := prometheus.NewDescBuilder("requests_total").
+//		Namespace("myapp").
+//		Help("Total number of handled requests.").
+//		Labels(requestLabels). // A shared *LabelSchema.
+//		ConstLabels(prometheus.Labels{"shard": "a"}).
+//		MustBuild()
+//
+// The configuring methods return a pointer to the DescBuilder itself for
+// convenience. A DescBuilder must not be used concurrently.
+type DescBuilder struct {
+	namespace, subsystem, name string
+	help                       string
+	variableLabels             ConstrainableLabels
+	constLabels                Labels
+	metadata                   map[string]string
+}
+
+// NewDescBuilder returns a DescBuilder for a Desc with the provided name. The
+// fully-qualified name of the Desc is created from the name together with the
+// namespace and subsystem (if configured) as in BuildFQName.
+func NewDescBuilder(name string) *DescBuilder {
+	return &DescBuilder{name: name}
+}
+
+// Namespace sets the namespace component of the fully-qualified name.
+func (b *DescBuilder) Namespace(namespace string) *DescBuilder {
+	b.namespace = namespace
+	return b
+}
+
+// Subsystem sets the subsystem component of the fully-qualified name.
+func (b *DescBuilder) Subsystem(subsystem string) *DescBuilder {
+	b.subsystem = subsystem
+	return b
+}
+
+// Help sets the help string.
+func (b *DescBuilder) Help(help string) *DescBuilder {
+	b.help = help
+	return b
+}
+
+// Labels sets the variable labels. Usually, a *LabelSchema is provided here,
+// but UnconstrainedLabels and ConstrainedLabels work, too. Any previously set
+// variable labels are replaced.
+func (b *DescBuilder) Labels(labels ConstrainableLabels) *DescBuilder {
+	b.variableLabels = labels
+	return b
+}
+
+// ConstLabels sets the constant labels. Any previously set constant labels are
+// replaced.
+func (b *DescBuilder) ConstLabels(labels Labels) *DescBuilder {
+	b.constLabels = labels
+	return b
+}
+
+// Metadata sets a key/value pair of the opaque metadata of the Desc (see
+// Desc.Metadata).
+func (b *DescBuilder) Metadata(key, value string) *DescBuilder {
+	if b.metadata == nil {
+		b.metadata = map[string]string{}
+	}
+	b.metadata[key] = value
+	return b
+}
+
+// Build creates the Desc. It returns an error if the Desc would be invalid, i.e.
+// if the name is empty or invalid, or if any label is invalid.
+func (b *DescBuilder) Build() (*Desc, error) {
+	fqName := BuildFQName(b.namespace, b.subsystem, b.name)
+	if fqName == "" {
+		return nil, errors.New("desc has no name")
+	}
+	variableLabels := b.variableLabels
+	if variableLabels == nil {
+		variableLabels = UnconstrainedLabels(nil)
+	}
+	if _, err := NewLabelSchema(variableLabels); err != nil {
+		return nil, fmt.Errorf("invalid variable labels for metric %q: %w", fqName, err)
+	}
+	d := V2.NewDesc(fqName, b.help, variableLabels, b.constLabels).withMetadata(b.metadata)
+	if d.err != nil {
+		return nil, d.err
+	}
+	return d, nil
+}
+
+// MustBuild works like Build but panics where Build would have returned an
+// error.
+func (b *DescBuilder) MustBuild() *Desc {
+	d, err := b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
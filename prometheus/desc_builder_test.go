@@ -0,0 +1,70 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDescBuilder(t *testing.T) {
+	schema := MustNewLabelSchema(ConstrainedLabels{
+		{Name: "method", Constraint: strings.ToLower},
+		{Name: "code"},
+	})
+
+	desc := NewDescBuilder("requests_total").
+		Namespace("app").
+		Subsystem("http").
+		Help("Total requests.").
+		Labels(schema).
+		ConstLabels(Labels{"shard": "a"}).
+		MustBuild()
+	want := `Desc{fqName: "app_http_requests_total", help: "Total requests.", constLabels: {shard="a"}, variableLabels: {c(method),code}}`
+	if got := desc.String(); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	// Descs built from the same schema are consistent.
+	other := NewDescBuilder("app_http_requests_total").
+		Help("Total requests.").
+		Labels(schema).
+		ConstLabels(Labels{"shard": "b"}).
+		MustBuild()
+	if desc.dimHash != other.dimHash {
+		t.Error("expected same dimensions for Descs sharing a label schema")
+	}
+}
+
+func TestDescBuilderErrors(t *testing.T) {
+	scenarios := map[string]*DescBuilder{
+		"empty name":               NewDescBuilder(""),
+		"invalid name":             NewDescBuilder("a-b"),
+		"invalid variable label":   NewDescBuilder("name").Labels(UnconstrainedLabels{"a-b"}),
+		"reserved variable label":  NewDescBuilder("name").Labels(UnconstrainedLabels{"__a"}),
+		"duplicate variable label": NewDescBuilder("name").Labels(UnconstrainedLabels{"a", "a"}),
+		"invalid const label":      NewDescBuilder("name").ConstLabels(Labels{"a-b": "x"}),
+		"invalid const value":      NewDescBuilder("name").ConstLabels(Labels{"a": "\xFF"}),
+		"const and variable label": NewDescBuilder("name").
+			Labels(UnconstrainedLabels{"a"}).
+			ConstLabels(Labels{"a": "x"}),
+	}
+	for name, b := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			if d, err := b.Build(); err == nil {
+				t.Errorf("expected error, got %s", d)
+			}
+		})
+	}
+}
@@ -14,6 +14,8 @@
 package prometheus
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -61,3 +63,84 @@ func TestNewInvalidDesc_String(t *testing.T) {
 		t.Errorf("String: unexpected output: %s", desc.String())
 	}
 }
+
+func TestDescLabels(t *testing.T) {
+	desc := V2.NewDesc(
+		"sample_label",
+		"sample label",
+		ConstrainedLabels{
+			{Name: "zone"},
+			{Name: "method", Constraint: strings.ToUpper},
+			{Name: "code"},
+		},
+		Labels{"instance": "a", "env": "prod"},
+	)
+	want := []DescLabel{
+		{Name: "env", Value: "prod", Const: true},
+		{Name: "instance", Value: "a", Const: true},
+		{Name: "zone"},
+		{Name: "method", Constrained: true},
+		{Name: "code"},
+	}
+	if got := desc.Labels(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if got := NewDesc("no_labels", "no labels", nil, nil).Labels(); len(got) != 0 {
+		t.Errorf("got %v, want no labels", got)
+	}
+	if got := NewDesc("invalid", "invalid", []string{"__x"}, nil).Labels(); got != nil {
+		t.Errorf("got %v for invalid Desc, want nil", got)
+	}
+	if got := NewInvalidDesc(nil).Labels(); len(got) != 0 {
+		t.Errorf("got %v for invalid Desc, want no labels", got)
+	}
+}
+
+func TestDescMetadata(t *testing.T) {
+	metadata := map[string]string{"team": "storage", "runbook": "https://example.org/runbook"}
+	reg := NewPedanticRegistry()
+	c := NewCounter(CounterOpts{Name: "c_total", Help: "c", Metadata: metadata})
+	h := NewHistogramVec(HistogramOpts{Name: "h", Help: "h", Metadata: map[string]string{"slo": "latency"}}, []string{"l"})
+	g := NewGauge(GaugeOpts{Name: "g", Help: "g"})
+	wrapped := NewCounter(CounterOpts{Name: "w_total", Help: "w", Metadata: map[string]string{"team": "compute"}})
+	reg.MustRegister(c, h, g)
+	WrapRegistererWithPrefix("prefix_", reg).MustRegister(wrapped)
+	metadata["team"] = "modified" // Must not affect the Desc.
+
+	var names []string
+	got := map[string]map[string]string{}
+	for _, d := range reg.Descriptors() {
+		names = append(names, d.fqName)
+		got[d.fqName] = d.Metadata()
+	}
+	if want := []string{"c_total", "g", "h", "prefix_w_total"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got descriptors %v, want %v", names, want)
+	}
+	want := map[string]map[string]string{
+		"c_total":        {"team": "storage", "runbook": "https://example.org/runbook"},
+		"g":              nil,
+		"h":              {"slo": "latency"},
+		"prefix_w_total": {"team": "compute"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got metadata %v, want %v", got, want)
+	}
+
+	// Metadata is not exposed.
+	c.Inc()
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mf := range mfs {
+		if strings.Contains(mf.String(), "storage") {
+			t.Errorf("metadata exposed in %v", mf)
+		}
+	}
+
+	d := NewDescBuilder("b").Metadata("team", "storage").MustBuild()
+	if got, want := d.Metadata(), map[string]string{"team": "storage"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got builder metadata %v, want %v", got, want)
+	}
+}
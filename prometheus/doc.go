@@ -203,6 +203,16 @@
 // Functions and examples to push metrics from a Gatherer to Graphite can be
 // found in the graphite sub-package.
 //
+// # WebAssembly and TinyGo
+//
+// This package, the collectors, promhttp, and push sub-packages build for the
+// js/wasm and wasip1/wasm targets. On those targets, and when building with
+// TinyGo for targets other than darwin and windows, the process Collector is
+// not supported. It doesn't collect any metrics but reports an error if
+// ReportErrors is set in its options. Programs without an HTTP server (e.g.
+// running in a browser or on an edge platform) can push their metrics with the
+// push sub-package.
+//
 // # Other Means of Exposition
 //
 // More ways of exposing metrics can easily be added by following the approaches
@@ -0,0 +1,133 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+// MarkExpectedAbsent declares that the metric with the provided labels is
+// intentionally absent, e.g. because the tenant it belongs to has been
+// decommissioned. The metric is deleted from the vector (if it exists), and
+// the labels are exposed by the Collector returned by ExpectedAbsentCollector
+// until ClearExpectedAbsent is called for them or a metric with the same
+// labels is created again. Tooling can use this to suppress alerts based on
+// absent() for those label sets. Reset and Rebuild do not affect the marks.
+//
+// The labels are validated in the same way as for GetMetricWith (including
+// curried labels), and an error is returned if they are inconsistent with the
+// variable labels of the vector.
+func (m *MetricVec) MarkExpectedAbsent(labels Labels) error {
+	labels, closer := constrainLabels(m.desc, labels)
+	defer closer()
+
+	h, err := m.hashLabels(labels)
+	if err != nil {
+		return err
+	}
+	lvs := extractLabelValues(m.desc, labels, m.curry)
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	for _, metricsByHash := range m.modifiable() {
+		if i := findMetricWithLabelValues(metricsByHash[h], lvs, nil); i < len(metricsByHash[h]) {
+			deleteFromBucket(metricsByHash, h, i)
+		}
+	}
+	if i := findMetricWithLabelValues(m.expectedAbsent[h], lvs, nil); i < len(m.expectedAbsent[h]) {
+		return nil // Already marked.
+	}
+	if m.expectedAbsent == nil {
+		m.expectedAbsent = map[uint64][]metricWithLabelValues{}
+	}
+	m.expectedAbsent[h] = append(m.expectedAbsent[h], metricWithLabelValues{values: lvs})
+	return nil
+}
+
+// ClearExpectedAbsent removes the mark set by MarkExpectedAbsent for the
+// provided labels. It returns whether the labels have been marked. Labels
+// inconsistent with the variable labels of the vector are never marked, so the
+// method returns false in that case.
+func (m *MetricVec) ClearExpectedAbsent(labels Labels) bool {
+	labels, closer := constrainLabels(m.desc, labels)
+	defer closer()
+
+	h, err := m.hashLabels(labels)
+	if err != nil {
+		return false
+	}
+	lvs := extractLabelValues(m.desc, labels, m.curry)
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	return m.unmarkExpectedAbsent(h, lvs)
+}
+
+// ExpectedAbsentCollector returns a Collector exposing a gauge with the value 1
+// for each label set marked with MarkExpectedAbsent. The gauge is named after
+// the vector with the suffix "_expected_absent" and has the same constant and
+// variable labels. Register it alongside the vector to surface the marks. The
+// vector itself does not expose them, so that its Describe method keeps
+// sending exactly one Desc.
+func (m *MetricVec) ExpectedAbsentCollector() Collector {
+	constLabels := make(Labels, len(m.desc.constLabelPairs))
+	for _, lp := range m.desc.constLabelPairs {
+		constLabels[lp.GetName()] = lp.GetValue()
+	}
+	return &expectedAbsentCollector{
+		metricMap: m.metricMap,
+		desc: NewDesc(
+			m.desc.fqName+"_expected_absent",
+			"Label sets of "+m.desc.fqName+" whose absence is intentional.",
+			m.desc.variableLabels.names,
+			constLabels,
+		),
+	}
+}
+
+// unmarkExpectedAbsent removes the mark for the provided (complete) label
+// values and returns whether they have been marked. Must be called while
+// holding the write mutex.
+func (m *metricMap) unmarkExpectedAbsent(h uint64, lvs []string) bool {
+	marked, ok := m.expectedAbsent[h]
+	if !ok {
+		return false
+	}
+	i := findMetricWithLabelValues(marked, lvs, nil)
+	if i >= len(marked) {
+		return false
+	}
+	deleteFromBucket(m.expectedAbsent, h, i)
+	return true
+}
+
+// expectedAbsentCollector is the Collector returned by
+// MetricVec.ExpectedAbsentCollector.
+type expectedAbsentCollector struct {
+	metricMap *metricMap
+	desc      *Desc
+}
+
+func (c *expectedAbsentCollector) Describe(ch chan<- *Desc) {
+	ch <- c.desc
+}
+
+func (c *expectedAbsentCollector) Collect(ch chan<- Metric) {
+	c.metricMap.mtx.RLock()
+	defer c.metricMap.mtx.RUnlock()
+
+	for _, marked := range c.metricMap.expectedAbsent {
+		for _, m := range marked {
+			ch <- MustNewConstMetric(c.desc, GaugeValue, 1, m.values...)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricVecExpectedAbsent(t *testing.T) {
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name:        "tenant_up",
+		Help:        "Whether the tenant is up.",
+		ConstLabels: prometheus.Labels{"region": "eu"},
+	}, []string{"tenant", "zone"})
+	vec.WithLabelValues("a", "z1").Set(1)
+	vec.WithLabelValues("b", "z1").Set(1)
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(vec, vec.ExpectedAbsentCollector())
+
+	check := func(want string) {
+		t.Helper()
+		if err := testutil.GatherAndCompare(reg, strings.NewReader(want)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if err := vec.MarkExpectedAbsent(prometheus.Labels{"tenant": "a"}); err == nil {
+		t.Error("expected error for incomplete labels")
+	}
+	if err := vec.MarkExpectedAbsent(prometheus.Labels{"tenant": "a", "zone": "z1"}); err != nil {
+		t.Fatal(err)
+	}
+	// Marking twice is a no-op.
+	if err := vec.MarkExpectedAbsent(prometheus.Labels{"tenant": "a", "zone": "z1"}); err != nil {
+		t.Fatal(err)
+	}
+	// Curried vectors share the marks.
+	if err := vec.MustCurryWith(prometheus.Labels{"zone": "z2"}).MarkExpectedAbsent(prometheus.Labels{"tenant": "c"}); err != nil {
+		t.Fatal(err)
+	}
+	check(`
+# HELP tenant_up Whether the tenant is up.
+# TYPE tenant_up gauge
+tenant_up{region="eu",tenant="b",zone="z1"} 1
+# HELP tenant_up_expected_absent Label sets of tenant_up whose absence is intentional.
+# TYPE tenant_up_expected_absent gauge
+tenant_up_expected_absent{region="eu",tenant="a",zone="z1"} 1
+tenant_up_expected_absent{region="eu",tenant="c",zone="z2"} 1
+`)
+
+	// Recreating a metric clears its mark, Reset doesn't.
+	vec.WithLabelValues("a", "z1").Set(2)
+	vec.Reset()
+	if vec.ClearExpectedAbsent(prometheus.Labels{"tenant": "a", "zone": "z1"}) {
+		t.Error("mark not cleared by recreating the metric")
+	}
+	check(`
+# HELP tenant_up_expected_absent Label sets of tenant_up whose absence is intentional.
+# TYPE tenant_up_expected_absent gauge
+tenant_up_expected_absent{region="eu",tenant="c",zone="z2"} 1
+`)
+
+	if !vec.ClearExpectedAbsent(prometheus.Labels{"tenant": "c", "zone": "z2"}) {
+		t.Error("mark not found")
+	}
+	if vec.ClearExpectedAbsent(prometheus.Labels{"tenant": "c"}) {
+		t.Error("unexpected mark for incomplete labels")
+	}
+	check("")
+}
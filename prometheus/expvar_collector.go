@@ -16,6 +16,8 @@ package prometheus
 import (
 	"encoding/json"
 	"expvar"
+
+	"github.com/prometheus/client_golang/prometheus/internal"
 )
 
 type expvarCollector struct {
@@ -27,6 +29,8 @@ type expvarCollector struct {
 //
 // Deprecated: Use collectors.NewExpvarCollector instead.
 func NewExpvarCollector(exports map[string]*Desc) Collector {
+	internal.ReportDeprecated("prometheus.NewExpvarCollector", "collectors.NewExpvarCollector")
+
 	return &expvarCollector{
 		exports: exports,
 	}
@@ -0,0 +1,146 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus/facade"
+)
+
+// NewFacade returns a facade.Registerer that creates real metrics and registers
+// them with the provided Registerer (or with the DefaultRegisterer if reg is
+// nil). Use it to collect the metrics of a library instrumented against the
+// facade package.
+//
+// If an equal metric of the same kind (e.g. a Counter) is already registered,
+// the existing one is used, so that a library may safely create the same
+// metrics repeatedly. Any other registration error, including an equal
+// metric of a different kind (e.g. a Gauge), causes a panic.
+func NewFacade(reg Registerer) facade.Registerer {
+	if reg == nil {
+		reg = DefaultRegisterer
+	}
+	return facadeRegisterer{reg: reg}
+}
+
+type facadeRegisterer struct {
+	reg Registerer
+}
+
+func (f facadeRegisterer) NewCounter(opts facade.Opts) facade.CounterLike {
+	return f.register(NewCounter(CounterOpts(toOpts(opts)))).(Counter)
+}
+
+func (f facadeRegisterer) NewCounterVec(opts facade.Opts, labelNames []string) facade.CounterVecLike {
+	return facadeCounterVec{f.register(NewCounterVec(CounterOpts(toOpts(opts)), labelNames)).(*CounterVec)}
+}
+
+func (f facadeRegisterer) NewGauge(opts facade.Opts) facade.GaugeLike {
+	return f.register(NewGauge(GaugeOpts(toOpts(opts)))).(Gauge)
+}
+
+func (f facadeRegisterer) NewGaugeVec(opts facade.Opts, labelNames []string) facade.GaugeVecLike {
+	return facadeGaugeVec{f.register(NewGaugeVec(GaugeOpts(toOpts(opts)), labelNames)).(*GaugeVec)}
+}
+
+func (f facadeRegisterer) NewHistogram(opts facade.HistogramOpts) facade.ObserverLike {
+	return f.register(NewHistogram(toHistogramOpts(opts))).(Histogram)
+}
+
+func (f facadeRegisterer) NewHistogramVec(opts facade.HistogramOpts, labelNames []string) facade.ObserverVecLike {
+	return facadeObserverVec{f.register(NewHistogramVec(toHistogramOpts(opts), labelNames)).(*HistogramVec)}
+}
+
+// register registers c and returns it, or returns the already registered
+// equal Collector if it is of the same kind as c. As the method sets of the
+// metric interfaces overlap (e.g. a Gauge is also a Counter), the kinds are
+// compared by concrete type.
+func (f facadeRegisterer) register(c Collector) Collector {
+	if err := f.reg.Register(c); err != nil {
+		are := &AlreadyRegisteredError{}
+		if !errors.As(err, are) {
+			panic(err)
+		}
+		if kind, existingKind := facadeKind(c), facadeKind(are.ExistingCollector); kind != existingKind {
+			panic(fmt.Errorf(
+				"cannot create %s %q: a %s with the same name and constant labels is already registered",
+				kind, describedNames(c)[0], existingKind,
+			))
+		}
+		return are.ExistingCollector
+	}
+	return c
+}
+
+// facadeKind returns the kind of metric c is, for the Collectors created by
+// facadeRegisterer.
+func facadeKind(c Collector) string {
+	switch c.(type) {
+	case *counter:
+		return "counter"
+	case *CounterVec:
+		return "counter vector"
+	case *gauge:
+		return "gauge"
+	case *GaugeVec:
+		return "gauge vector"
+	case *histogram:
+		return "histogram"
+	case *HistogramVec:
+		return "histogram vector"
+	default:
+		return fmt.Sprintf("collector of type %T", c)
+	}
+}
+
+func toOpts(opts facade.Opts) Opts {
+	return Opts{
+		Namespace:   opts.Namespace,
+		Subsystem:   opts.Subsystem,
+		Name:        opts.Name,
+		Help:        opts.Help,
+		ConstLabels: opts.ConstLabels,
+	}
+}
+
+func toHistogramOpts(opts facade.HistogramOpts) HistogramOpts {
+	return HistogramOpts{
+		Namespace:   opts.Namespace,
+		Subsystem:   opts.Subsystem,
+		Name:        opts.Name,
+		Help:        opts.Help,
+		ConstLabels: opts.ConstLabels,
+		Buckets:     opts.Buckets,
+	}
+}
+
+type facadeCounterVec struct{ *CounterVec }
+
+func (v facadeCounterVec) WithLabelValues(lvs ...string) facade.CounterLike {
+	return v.CounterVec.WithLabelValues(lvs...)
+}
+
+type facadeGaugeVec struct{ *GaugeVec }
+
+func (v facadeGaugeVec) WithLabelValues(lvs ...string) facade.GaugeLike {
+	return v.GaugeVec.WithLabelValues(lvs...)
+}
+
+type facadeObserverVec struct{ *HistogramVec }
+
+func (v facadeObserverVec) WithLabelValues(lvs ...string) facade.ObserverLike {
+	return v.HistogramVec.WithLabelValues(lvs...)
+}
@@ -0,0 +1,155 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package facade provides minimal interfaces to instrument code with metrics,
+// together with a no-op implementation. It is meant for authors of libraries
+// who want to offer optional instrumentation without forcing a dependency on
+// the full client library (and its dependencies, e.g. protobuf) onto their
+// users. The package has no dependencies beyond the standard library.
+//
+// A library accepts a Registerer (falling back to Noop if none is provided)
+// and creates its metrics with it:
+//
+//	type Options struct {
+//		// Metrics is used to create the metrics of the library.
+//		// Defaults to facade.Noop.
+//		Metrics facade.Registerer
+//	}
+//
+//	func New(opts Options) *Client {
+//		if opts.Metrics == nil {
+//			opts.Metrics = facade.Noop
+//		}
+//		return &Client{
+//			requests: opts.Metrics.NewCounterVec(facade.Opts{
+//				Name: "mylib_requests_total",
+//				Help: "Total number of requests.",
+//			}, []string{"code"}),
+//		}
+//	}
+//
+// Users of the library who want to collect the metrics pass in an adapter for
+// a real prometheus.Registerer, see prometheus.NewFacade.
+package facade
+
+// Opts bundles the options for creating a counter or a gauge. See the
+// corresponding fields of prometheus.Opts for details.
+type Opts struct {
+	Namespace   string
+	Subsystem   string
+	Name        string
+	Help        string
+	ConstLabels map[string]string
+}
+
+// HistogramOpts bundles the options for creating a histogram. See the
+// corresponding fields of prometheus.HistogramOpts for details.
+type HistogramOpts struct {
+	Namespace   string
+	Subsystem   string
+	Name        string
+	Help        string
+	ConstLabels map[string]string
+	// Buckets are the upper bounds of the buckets. If nil, the default
+	// buckets of the implementation are used.
+	Buckets []float64
+}
+
+// CounterLike is the subset of the methods of prometheus.Counter needed for
+// instrumentation.
+type CounterLike interface {
+	// Inc increments the counter by 1.
+	Inc()
+	// Add adds the given value to the counter. It panics if the value is
+	// negative.
+	Add(float64)
+}
+
+// GaugeLike is the subset of the methods of prometheus.Gauge needed for
+// instrumentation.
+type GaugeLike interface {
+	Set(float64)
+	Inc()
+	Dec()
+	Add(float64)
+	Sub(float64)
+}
+
+// ObserverLike is the interface of prometheus.Observer, which is implemented by
+// histograms and summaries.
+type ObserverLike interface {
+	Observe(float64)
+}
+
+// CounterVecLike is the subset of the methods of prometheus.CounterVec needed
+// for instrumentation.
+type CounterVecLike interface {
+	WithLabelValues(lvs ...string) CounterLike
+}
+
+// GaugeVecLike is the subset of the methods of prometheus.GaugeVec needed for
+// instrumentation.
+type GaugeVecLike interface {
+	WithLabelValues(lvs ...string) GaugeLike
+}
+
+// ObserverVecLike is the subset of the methods of prometheus.HistogramVec
+// needed for instrumentation.
+type ObserverVecLike interface {
+	WithLabelValues(lvs ...string) ObserverLike
+}
+
+// Registerer creates metrics and registers them for collection. It is the
+// facade counterpart of a prometheus.Registerer. Implementations must be safe
+// for concurrent use. Creating the same metric twice (e.g. because a library
+// creates a new client per connection) returns the already existing metric,
+// while inconsistent metrics (e.g. a counter and a gauge with the same name)
+// cause a panic, as invalid metrics are a programming error.
+type Registerer interface {
+	NewCounter(opts Opts) CounterLike
+	NewCounterVec(opts Opts, labelNames []string) CounterVecLike
+	NewGauge(opts Opts) GaugeLike
+	NewGaugeVec(opts Opts, labelNames []string) GaugeVecLike
+	NewHistogram(opts HistogramOpts) ObserverLike
+	NewHistogramVec(opts HistogramOpts, labelNames []string) ObserverVecLike
+}
+
+// Noop is a Registerer whose metrics discard everything. Use it as the default
+// if no Registerer is provided.
+var Noop Registerer = noop{}
+
+type noop struct{}
+
+func (noop) NewCounter(Opts) CounterLike                             { return noop{} }
+func (noop) NewCounterVec(Opts, []string) CounterVecLike             { return noopCounterVec{} }
+func (noop) NewGauge(Opts) GaugeLike                                 { return noop{} }
+func (noop) NewGaugeVec(Opts, []string) GaugeVecLike                 { return noopGaugeVec{} }
+func (noop) NewHistogram(HistogramOpts) ObserverLike                 { return noop{} }
+func (noop) NewHistogramVec(HistogramOpts, []string) ObserverVecLike { return noopObserverVec{} }
+
+func (noop) Set(float64)     {}
+func (noop) Inc()            {}
+func (noop) Dec()            {}
+func (noop) Add(float64)     {}
+func (noop) Sub(float64)     {}
+func (noop) Observe(float64) {}
+
+type (
+	noopCounterVec  struct{}
+	noopGaugeVec    struct{}
+	noopObserverVec struct{}
+)
+
+func (noopCounterVec) WithLabelValues(...string) CounterLike   { return noop{} }
+func (noopGaugeVec) WithLabelValues(...string) GaugeLike       { return noop{} }
+func (noopObserverVec) WithLabelValues(...string) ObserverLike { return noop{} }
@@ -0,0 +1,28 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package facade
+
+import "testing"
+
+func TestNoop(t *testing.T) {
+	// Just make sure nothing panics.
+	Noop.NewCounter(Opts{}).Add(-1)
+	Noop.NewCounterVec(Opts{}, []string{"a"}).WithLabelValues().Inc()
+	g := Noop.NewGaugeVec(Opts{}, nil).WithLabelValues("a", "b")
+	g.Set(1)
+	g.Sub(1)
+	Noop.NewGauge(Opts{}).Dec()
+	Noop.NewHistogram(HistogramOpts{}).Observe(1)
+	Noop.NewHistogramVec(HistogramOpts{}, nil).WithLabelValues().Observe(1)
+}
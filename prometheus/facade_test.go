@@ -0,0 +1,85 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/facade"
+)
+
+func TestNewFacade(t *testing.T) {
+	reg := NewPedanticRegistry()
+	f := NewFacade(reg)
+
+	// Creating the same metrics twice must return the existing ones.
+	for i := 0; i < 2; i++ {
+		f.NewCounter(facade.Opts{Name: "c_total", Help: "c"}).Inc()
+		f.NewCounterVec(facade.Opts{Name: "cv_total", Help: "cv"}, []string{"code"}).WithLabelValues("200").Add(2)
+		f.NewGauge(facade.Opts{Namespace: "ns", Name: "g", Help: "g", ConstLabels: map[string]string{"a": "b"}}).Set(float64(i))
+		f.NewGaugeVec(facade.Opts{Name: "gv", Help: "gv"}, []string{"l"}).WithLabelValues("x").Dec()
+		f.NewHistogram(facade.HistogramOpts{Name: "h", Help: "h", Buckets: []float64{1}}).Observe(0.5)
+		f.NewHistogramVec(facade.HistogramOpts{Name: "hv", Help: "hv"}, []string{"l"}).WithLabelValues("x").Observe(2)
+	}
+
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]float64{}
+	for _, mf := range mfs {
+		m := mf.GetMetric()[0]
+		switch {
+		case m.Counter != nil:
+			got[mf.GetName()] = m.GetCounter().GetValue()
+		case m.Gauge != nil:
+			got[mf.GetName()] = m.GetGauge().GetValue()
+		case m.Histogram != nil:
+			got[mf.GetName()] = float64(m.GetHistogram().GetSampleCount())
+		}
+	}
+	want := map[string]float64{"c_total": 2, "cv_total": 4, "ns_g": 1, "gv": -2, "h": 2, "hv": 2}
+	for name, v := range want {
+		if got[name] != v {
+			t.Errorf("%s: got %v, want %v", name, got[name], v)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("got metrics %v, want %v", got, want)
+	}
+
+}
+
+func TestNewFacadeKindMismatch(t *testing.T) {
+	f := NewFacade(NewRegistry())
+	f.NewCounter(facade.Opts{Name: "c_total", Help: "c"})
+	f.NewGauge(facade.Opts{Name: "g", Help: "g"})
+
+	for name, create := range map[string]func(){
+		// A gauge has all the methods of a counter.
+		"counter after gauge": func() { f.NewCounter(facade.Opts{Name: "g", Help: "g"}) },
+		"gauge after counter": func() { f.NewGauge(facade.Opts{Name: "c_total", Help: "c"}) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				err, ok := recover().(error)
+				if !ok || !strings.Contains(err.Error(), "is already registered") {
+					t.Errorf("expected descriptive panic, got %v", err)
+				}
+			}()
+			create()
+		})
+	}
+}
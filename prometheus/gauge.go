@@ -81,7 +81,7 @@ func NewGauge(opts GaugeOpts) Gauge {
 		opts.Help,
 		nil,
 		opts.ConstLabels,
-	)
+	).withMetadata(opts.Metadata)
 	result := &gauge{desc: desc, labelPairs: desc.constLabelPairs}
 	result.init(result) // Init self-collection.
 	return result
@@ -159,7 +159,7 @@ func (v2) NewGaugeVec(opts GaugeVecOpts) *GaugeVec {
 		opts.Help,
 		opts.VariableLabels,
 		opts.ConstLabels,
-	)
+	).withMetadata(opts.Metadata)
 	return &GaugeVec{
 		MetricVec: NewMetricVec(desc, func(lvs ...string) Metric {
 			if len(lvs) != len(desc.variableLabels.names) {
@@ -303,5 +303,5 @@ func NewGaugeFunc(opts GaugeOpts, function func() float64) GaugeFunc {
 		opts.Help,
 		nil,
 		opts.ConstLabels,
-	), GaugeValue, function)
+	).withMetadata(opts.Metadata), GaugeValue, function)
 }
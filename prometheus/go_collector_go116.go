@@ -20,6 +20,8 @@ import (
 	"runtime"
 	"sync"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus/internal"
 )
 
 type goCollector struct {
@@ -40,6 +42,8 @@ type goCollector struct {
 //
 // Deprecated: Use collectors.NewGoCollector instead.
 func NewGoCollector() Collector {
+	internal.ReportDeprecated("prometheus.NewGoCollector", "collectors.NewGoCollector")
+
 	msMetrics := goRuntimeMemStats()
 	msMetrics = append(msMetrics, struct {
 		desc    *Desc
@@ -79,6 +83,18 @@ func (c *goCollector) Collect(ch chan<- Metric) {
 		ms   = &runtime.MemStats{}
 		done = make(chan struct{})
 	)
+	if NoGoroutinesMode() {
+		// Read memstats synchronously, as the goroutine below might
+		// outlive this call.
+		c.base.Collect(ch)
+		c.msRead(ms)
+		c.msMtx.Lock()
+		c.msLast = ms
+		c.msLastTimestamp = time.Now()
+		c.msMtx.Unlock()
+		c.msCollect(ch, ms)
+		return
+	}
 	// Start reading memstats first as it might take a while.
 	go func() {
 		c.msRead(ms)
@@ -165,6 +165,8 @@ func defaultGoCollectorOptions() internal.GoCollectorOptions {
 //
 // Deprecated: Use collectors.NewGoCollector instead.
 func NewGoCollector(opts ...func(o *internal.GoCollectorOptions)) Collector {
+	internal.ReportDeprecated("prometheus.NewGoCollector", "collectors.NewGoCollector")
+
 	opt := defaultGoCollectorOptions()
 	for _, o := range opts {
 		o(&opt)
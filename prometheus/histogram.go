@@ -17,6 +17,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"math/rand"
 	"runtime"
 	"sort"
 	"sync"
@@ -260,6 +261,17 @@ type Histogram interface {
 	Observe(float64)
 }
 
+// ResetTimeReporter is implemented by the Histograms created by NewHistogram
+// and by HistogramVec. LastResetTime returns the time the Histogram was last
+// reset, or its creation time if it has never been reset. It is the same time
+// that is exposed as the created timestamp of the Histogram. Resets only
+// happen as part of the bucket limitation strategy for native histograms (see
+// NativeHistogramMinResetDuration in HistogramOpts). The method is mostly
+// useful for debugging.
+type ResetTimeReporter interface {
+	LastResetTime() time.Time
+}
+
 // bucketLabel is used for the label that defines the upper bound of a
 // bucket of a histogram ("le" -> "less or equal").
 const bucketLabel = "le"
@@ -390,6 +402,14 @@ type HistogramOpts struct {
 	// https://prometheus.io/docs/instrumenting/writing_exporters/#target-labels-not-static-scraped-labels
 	ConstLabels Labels
 
+	// Metadata is an opaque set of key/value pairs attached to the Desc of
+	// this metric, e.g. the owning team, a runbook URL, or a linked SLO. It
+	// is meant for tooling within an organization (e.g. a metric catalog
+	// built with Registry.Descriptors and Desc.Metadata) and is neither
+	// exposed to Prometheus nor taken into account for the consistency
+	// checks of a Registry. The map is copied on creation of the metric.
+	Metadata map[string]string
+
 	// Buckets defines the buckets into which observations are counted. Each
 	// element in the slice is the upper inclusive bound of a bucket. The
 	// values must be sorted in strictly increasing order. There is no need
@@ -475,10 +495,31 @@ type HistogramOpts struct {
 	//  - Any increased zero threshold or reduced resolution is reset back
 	//    to their original values once NativeHistogramMinResetDuration has
 	//    passed (since the last reset or the creation of the histogram).
+	//    In no-goroutines mode (see SetNoGoroutinesMode), this reset is
+	//    delayed until the next time the histogram is written (usually
+	//    during the next scrape).
 	NativeHistogramMaxBucketNumber  uint32
 	NativeHistogramMinResetDuration time.Duration
 	NativeHistogramMaxZeroThreshold float64
 
+	// NativeHistogramCoordinatedResets only has an effect for the
+	// Histograms in a HistogramVec with a non-zero
+	// NativeHistogramMinResetDuration. If set to true, the resets of the
+	// bucket limitation strategy described above are coordinated across
+	// all Histograms in the HistogramVec: Instead of each Histogram
+	// resetting itself on its own schedule, a single timer is used for the
+	// whole HistogramVec, and all Histograms that have reduced their
+	// resolution (or widened their zero bucket) since the last coordinated
+	// reset are reset at the same time. This avoids glitches in
+	// aggregations across the Histograms caused by resets at different
+	// times. The first coordinated reset happens
+	// NativeHistogramMinResetDuration after the creation of the
+	// HistogramVec, subsequent ones NativeHistogramMinResetDuration after
+	// the previous one. Note that this implies that a Histogram might stay
+	// at a reduced resolution for up to NativeHistogramMinResetDuration
+	// even if its own last reset happened longer ago.
+	NativeHistogramCoordinatedResets bool
+
 	// NativeHistogramMaxExemplars limits the number of exemplars
 	// that are kept in memory for each native histogram. If you leave it at
 	// zero, a default value of 10 is used. If no exemplars should be kept specifically
@@ -495,11 +536,107 @@ type HistogramOpts struct {
 	// 5m is used. To always delete the oldest exemplar, set it to a negative value.
 	NativeHistogramExemplarTTL time.Duration
 
+	// If SampleOneIn is greater than one, the Histogram only records a
+	// random sample of the observations: Each observation is recorded with
+	// a probability of 1/SampleOneIn, and each recorded observation is
+	// counted SampleOneIn times (in the sample count and in its bucket) and
+	// added SampleOneIn times to the sample sum. The exposed counts and sums
+	// are therefore unbiased estimates of the true counts and sums, while
+	// the cost of most observations is reduced to drawing a random number.
+	//
+	// This is only meant for Histograms observed extremely frequently (e.g.
+	// millions of times per second) where exact counts are not required,
+	// e.g. debug-level distributions of very hot code paths. Note the
+	// accuracy trade-off: The counts are always multiples of SampleOneIn,
+	// and the relative standard error of a count of n observations is
+	// approximately sqrt(SampleOneIn/n), i.e. buckets with few observations
+	// are very inaccurate. In particular, rare outliers are likely missed
+	// entirely. Counts of different Histograms (or of different buckets of
+	// the same Histogram) are sampled independently, so ratios between them
+	// are estimates, too. Exemplars are only considered for recorded
+	// observations.
+	//
+	// If SampleOneIn is zero or one, every observation is recorded.
+	SampleOneIn uint32
+
 	// now is for testing purposes, by default it's time.Now.
 	now func() time.Time
 
 	// afterFunc is for testing purposes, by default it's time.AfterFunc.
 	afterFunc func(time.Duration, func()) *time.Timer
+
+	// sample is for testing purposes, by default it uses math/rand.
+	sample func(oneIn uint32) bool
+
+	// resetCoordinator is set by HistogramVec if
+	// NativeHistogramCoordinatedResets is enabled.
+	resetCoordinator *nativeHistogramResetCoordinator
+}
+
+// HistogramDefaults contains defaults for the bucket-related fields of
+// HistogramOpts. They allow to change the bucket layout of many histograms
+// centrally (e.g. in a module shared across a fleet of services) rather than in
+// every histogram declaration. Use Apply to apply them to HistogramOpts, or set
+// them for a Registry with Registry.SetHistogramDefaults, so that they are
+// applied to all Histograms created with the promauto package for that
+// Registry.
+type HistogramDefaults struct {
+	// Buckets is applied if neither Buckets nor
+	// NativeHistogramBucketFactor is set in the HistogramOpts, i.e. if the
+	// HistogramOpts do not configure any buckets at all. (Thereby, a
+	// histogram explicitly configured to only use native buckets doesn't
+	// get classic buckets added.) If Buckets is empty, such HistogramOpts
+	// get DefBuckets, as they would without defaults. In particular,
+	// histograms without any buckets configured keep their classic
+	// buckets if the defaults only set NativeHistogramBucketFactor.
+	Buckets []float64
+
+	// The following fields are each applied if the corresponding field in
+	// the HistogramOpts is left at its zero value. See HistogramOpts for
+	// their meaning. Note that setting NativeHistogramBucketFactor here
+	// adds native buckets to all histograms that do not set their own
+	// NativeHistogramBucketFactor, including those with explicitly set
+	// classic buckets.
+	NativeHistogramBucketFactor     float64
+	NativeHistogramZeroThreshold    float64
+	NativeHistogramMaxBucketNumber  uint32
+	NativeHistogramMinResetDuration time.Duration
+	NativeHistogramMaxZeroThreshold float64
+	NativeHistogramMaxExemplars     int
+	NativeHistogramExemplarTTL      time.Duration
+}
+
+// Apply returns a copy of opts with the defaults applied to zero-valued fields
+// as described for the fields of HistogramDefaults.
+func (d HistogramDefaults) Apply(opts HistogramOpts) HistogramOpts {
+	if len(opts.Buckets) == 0 && opts.NativeHistogramBucketFactor == 0 {
+		opts.Buckets = d.Buckets
+		if len(opts.Buckets) == 0 {
+			opts.Buckets = DefBuckets
+		}
+	}
+	if opts.NativeHistogramBucketFactor == 0 {
+		opts.NativeHistogramBucketFactor = d.NativeHistogramBucketFactor
+	}
+	if opts.NativeHistogramZeroThreshold == 0 {
+		opts.NativeHistogramZeroThreshold = d.NativeHistogramZeroThreshold
+	}
+	if opts.NativeHistogramMaxBucketNumber == 0 {
+		opts.NativeHistogramMaxBucketNumber = d.NativeHistogramMaxBucketNumber
+	}
+	if opts.NativeHistogramMinResetDuration == 0 {
+		opts.NativeHistogramMinResetDuration = d.NativeHistogramMinResetDuration
+	}
+	if opts.NativeHistogramMaxZeroThreshold == 0 {
+		opts.NativeHistogramMaxZeroThreshold = d.NativeHistogramMaxZeroThreshold
+	}
+	if opts.NativeHistogramMaxExemplars == 0 {
+		opts.NativeHistogramMaxExemplars = d.NativeHistogramMaxExemplars
+	}
+	if opts.NativeHistogramExemplarTTL == 0 {
+		opts.NativeHistogramExemplarTTL = d.NativeHistogramExemplarTTL
+	}
+	return opts
 }
 
 // HistogramVecOpts bundles the options to create a HistogramVec metric.
@@ -517,9 +654,9 @@ type HistogramVecOpts struct {
 // NewHistogram creates a new Histogram based on the provided HistogramOpts. It
 // panics if the buckets in HistogramOpts are not in strictly increasing order.
 //
-// The returned implementation also implements ExemplarObserver. It is safe to
-// perform the corresponding type assertion. Exemplars are tracked separately
-// for each bucket.
+// The returned implementation also implements ExemplarObserver and
+// ResetTimeReporter. It is safe to perform the corresponding type
+// assertions. Exemplars are tracked separately for each bucket.
 func NewHistogram(opts HistogramOpts) Histogram {
 	return newHistogram(
 		NewDesc(
@@ -527,7 +664,7 @@ func NewHistogram(opts HistogramOpts) Histogram {
 			opts.Help,
 			nil,
 			opts.ConstLabels,
-		),
+		).withMetadata(opts.Metadata),
 		opts,
 	)
 }
@@ -554,6 +691,9 @@ func newHistogram(desc *Desc, opts HistogramOpts, labelValues ...string) Histogr
 	if opts.afterFunc == nil {
 		opts.afterFunc = time.AfterFunc
 	}
+	if opts.sample == nil {
+		opts.sample = func(oneIn uint32) bool { return rand.Uint32()%oneIn == 0 }
+	}
 
 	h := &histogram{
 		desc:                            desc,
@@ -565,6 +705,12 @@ func newHistogram(desc *Desc, opts HistogramOpts, labelValues ...string) Histogr
 		lastResetTime:                   opts.now(),
 		now:                             opts.now,
 		afterFunc:                       opts.afterFunc,
+		resetCoordinator:                opts.resetCoordinator,
+		weight:                          1,
+	}
+	if opts.SampleOneIn > 1 {
+		h.weight = uint64(opts.SampleOneIn)
+		h.sample = opts.sample
 	}
 	if len(h.upperBounds) == 0 && opts.NativeHistogramBucketFactor <= 1 {
 		h.upperBounds = DefBuckets
@@ -651,12 +797,16 @@ type histogramCounts struct {
 
 // observe manages the parts of observe that only affects
 // histogramCounts. doSparse is true if sparse buckets should be done,
-// too.
-func (hc *histogramCounts) observe(v float64, bucket int, doSparse bool) {
+// too. The observation is counted weight times.
+func (hc *histogramCounts) observe(v float64, bucket int, doSparse bool, weight uint64) {
 	if bucket < len(hc.buckets) {
-		atomic.AddUint64(&hc.buckets[bucket], 1)
+		atomic.AddUint64(&hc.buckets[bucket], weight)
+	}
+	if weight == 1 {
+		atomicAddFloat(&hc.sumBits, v)
+	} else {
+		atomicAddFloat(&hc.sumBits, v*float64(weight))
 	}
-	atomicAddFloat(&hc.sumBits, v)
 	if doSparse && !math.IsNaN(v) {
 		var (
 			key                  int
@@ -690,11 +840,11 @@ func (hc *histogramCounts) observe(v float64, bucket int, doSparse bool) {
 		}
 		switch {
 		case v > zeroThreshold:
-			bucketCreated = addToBucket(&hc.nativeHistogramBucketsPositive, key, 1)
+			bucketCreated = addToBucket(&hc.nativeHistogramBucketsPositive, key, int64(weight))
 		case v < -zeroThreshold:
-			bucketCreated = addToBucket(&hc.nativeHistogramBucketsNegative, key, 1)
+			bucketCreated = addToBucket(&hc.nativeHistogramBucketsNegative, key, int64(weight))
 		default:
-			atomic.AddUint64(&hc.nativeHistogramZeroBucket, 1)
+			atomic.AddUint64(&hc.nativeHistogramZeroBucket, weight)
 		}
 		if bucketCreated {
 			atomic.AddUint32(&hc.nativeHistogramBucketsNumber, 1)
@@ -702,7 +852,7 @@ func (hc *histogramCounts) observe(v float64, bucket int, doSparse bool) {
 	}
 	// Increment count last as we take it as a signal that the observation
 	// is complete.
-	atomic.AddUint64(&hc.count, 1)
+	atomic.AddUint64(&hc.count, weight)
 }
 
 type histogram struct {
@@ -711,7 +861,9 @@ type histogram struct {
 	// below. Observe calls update the hot one. All remaining bits count the
 	// number of Observe calls. Observe starts by incrementing this counter,
 	// and finish by incrementing the count field in the respective
-	// histogramCounts, as a marker for completion.
+	// histogramCounts, as a marker for completion. (With sampling, see
+	// HistogramOpts.SampleOneIn, both are incremented by the weight of the
+	// observation rather than by one.)
 	//
 	// Calls of the Write method (which are non-mutating reads from the
 	// perspective of the histogram) swap the hot–cold under the writeMtx
@@ -749,7 +901,11 @@ type histogram struct {
 	// resetScheduled is protected by mtx. It is true if a reset is
 	// scheduled for a later time (when nativeHistogramMinResetDuration has
 	// passed).
-	resetScheduled  bool
+	resetScheduled bool
+	// resetDeadline is protected by mtx. It is only used in no-goroutines
+	// mode (see SetNoGoroutinesMode), where a scheduled reset is performed
+	// by the first call of Write after resetDeadline.
+	resetDeadline   time.Time
 	nativeExemplars nativeExemplars
 
 	// now is for testing purposes, by default it's time.Now.
@@ -757,13 +913,35 @@ type histogram struct {
 
 	// afterFunc is for testing purposes, by default it's time.AfterFunc.
 	afterFunc func(time.Duration, func()) *time.Timer
+
+	// resetCoordinator is nil unless the histogram is part of a
+	// HistogramVec with NativeHistogramCoordinatedResets enabled.
+	resetCoordinator *nativeHistogramResetCoordinator
+
+	// weight is the number of times each recorded observation is counted,
+	// i.e. HistogramOpts.SampleOneIn or 1 if no sampling is configured.
+	weight uint64
+	// sample decides if an observation is recorded. It is nil if no
+	// sampling is configured.
+	sample func(oneIn uint32) bool
 }
 
 func (h *histogram) Desc() *Desc {
 	return h.desc
 }
 
+// LastResetTime implements ResetTimeReporter.
+func (h *histogram) LastResetTime() time.Time {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	return h.lastResetTime
+}
+
 func (h *histogram) Observe(v float64) {
+	if h.sample != nil && !h.sample(uint32(h.weight)) {
+		return
+	}
 	h.observe(v, h.findBucket(v))
 }
 
@@ -771,6 +949,9 @@ func (h *histogram) Observe(v float64) {
 // for a native histogram with configured exemplars. For this case,
 // the implementation isn't lock-free and might suffer from lock contention.
 func (h *histogram) ObserveWithExemplar(v float64, e Labels) {
+	if h.sample != nil && !h.sample(uint32(h.weight)) {
+		return
+	}
 	i := h.findBucket(v)
 	h.observe(v, i)
 	h.updateExemplar(v, i, e)
@@ -781,9 +962,17 @@ func (h *histogram) Write(out *dto.Metric) error {
 	// the hot path, i.e. Observe is called much more often than Write. The
 	// complication of making Write lock-free isn't worth it, if possible at
 	// all.
+	if h.resetCoordinator != nil {
+		// Must happen before locking h.mtx, see resetPendingIfDue.
+		h.resetCoordinator.resetPendingIfDue()
+	}
 	h.mtx.Lock()
 	defer h.mtx.Unlock()
 
+	if !h.resetDeadline.IsZero() && !h.now().Before(h.resetDeadline) {
+		h.resetLocked()
+	}
+
 	// Adding 1<<63 switches the hot index (from 0 to 1 or from 1 to 0)
 	// without touching the count bits. See the struct comments for a full
 	// description of the algorithm.
@@ -903,9 +1092,9 @@ func (h *histogram) observe(v float64, bucket int) {
 	// We increment h.countAndHotIdx so that the counter in the lower
 	// 63 bits gets incremented. At the same time, we get the new value
 	// back, which we can use to find the currently-hot counts.
-	n := atomic.AddUint64(&h.countAndHotIdx, 1)
+	n := atomic.AddUint64(&h.countAndHotIdx, h.weight)
 	hotCounts := h.counts[n>>63]
-	hotCounts.observe(v, bucket, doSparse)
+	hotCounts.observe(v, bucket, doSparse, h.weight)
 	if doSparse {
 		h.limitBuckets(hotCounts, v, bucket)
 	}
@@ -948,7 +1137,14 @@ func (h *histogram) limitBuckets(counts *histogramCounts, value float64, bucket
 	// if we haven't done so already.
 	if h.nativeHistogramMinResetDuration > 0 && !h.resetScheduled {
 		h.resetScheduled = true
-		h.afterFunc(h.nativeHistogramMinResetDuration-h.now().Sub(h.lastResetTime), h.reset)
+		switch {
+		case h.resetCoordinator != nil:
+			h.resetCoordinator.schedule(h)
+		case NoGoroutinesMode():
+			h.resetDeadline = h.lastResetTime.Add(h.nativeHistogramMinResetDuration)
+		default:
+			h.afterFunc(h.nativeHistogramMinResetDuration-h.now().Sub(h.lastResetTime), h.reset)
+		}
 	}
 
 	if h.maybeWidenZeroBucket(hotCounts, coldCounts) {
@@ -966,6 +1162,7 @@ func (h *histogram) maybeReset(
 	// We are using the possibly mocked h.now() rather than
 	// time.Since(h.lastResetTime) to enable testing.
 	if h.nativeHistogramMinResetDuration == 0 || // No reset configured.
+		h.resetCoordinator != nil || // Resets are coordinated by the HistogramVec.
 		h.resetScheduled || // Do not interefere if a reset is already scheduled.
 		h.now().Sub(h.lastResetTime) < h.nativeHistogramMinResetDuration {
 		return false
@@ -973,9 +1170,9 @@ func (h *histogram) maybeReset(
 	// Completely reset coldCounts.
 	h.resetCounts(cold)
 	// Repeat the latest observation to not lose it completely.
-	cold.observe(value, bucket, true)
+	cold.observe(value, bucket, true, h.weight)
 	// Make coldCounts the new hot counts while resetting countAndHotIdx.
-	n := atomic.SwapUint64(&h.countAndHotIdx, (coldIdx<<63)+1)
+	n := atomic.SwapUint64(&h.countAndHotIdx, (coldIdx<<63)+h.weight)
 	count := n & ((1 << 63) - 1)
 	waitForCooldown(count, hot)
 	// Finally, reset the formerly hot counts, too.
@@ -990,6 +1187,11 @@ func (h *histogram) reset() {
 	h.mtx.Lock()
 	defer h.mtx.Unlock()
 
+	h.resetLocked()
+}
+
+// resetLocked is like reset, but the caller must have locked h.mtx.
+func (h *histogram) resetLocked() {
 	n := atomic.LoadUint64(&h.countAndHotIdx)
 	hotIdx := n >> 63
 	coldIdx := (^n) >> 63
@@ -1005,6 +1207,7 @@ func (h *histogram) reset() {
 	h.resetCounts(hot)
 	h.lastResetTime = h.now()
 	h.resetScheduled = false
+	h.resetDeadline = time.Time{}
 }
 
 // maybeWidenZeroBucket widens the zero bucket until it includes the existing
@@ -1146,6 +1349,99 @@ func (h *histogram) resetCounts(counts *histogramCounts) {
 	deleteSyncMap(&counts.nativeHistogramBucketsPositive)
 }
 
+// nativeHistogramResetCoordinator schedules the resets of all histograms in a
+// HistogramVec with NativeHistogramCoordinatedResets enabled, using a single
+// timer.
+type nativeHistogramResetCoordinator struct {
+	mtx              sync.Mutex
+	minResetDuration time.Duration
+	lastResetTime    time.Time
+	scheduled        bool
+	// deadline is only used in no-goroutines mode, where the pending
+	// resets are performed by resetPendingIfDue rather than by a timer.
+	deadline time.Time
+	pending  map[*histogram]struct{}
+
+	now       func() time.Time
+	afterFunc func(time.Duration, func()) *time.Timer
+}
+
+func newNativeHistogramResetCoordinator(opts HistogramOpts) *nativeHistogramResetCoordinator {
+	c := &nativeHistogramResetCoordinator{
+		minResetDuration: opts.NativeHistogramMinResetDuration,
+		pending:          map[*histogram]struct{}{},
+		now:              opts.now,
+		afterFunc:        opts.afterFunc,
+	}
+	if c.now == nil {
+		c.now = time.Now
+	}
+	if c.afterFunc == nil {
+		c.afterFunc = time.AfterFunc
+	}
+	c.lastResetTime = c.now()
+	return c
+}
+
+// schedule adds h to the histograms to be reset with the next coordinated
+// reset and starts the timer for it if not done already. The caller must have
+// locked h.mtx.
+func (c *nativeHistogramResetCoordinator) schedule(h *histogram) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.pending[h] = struct{}{}
+	if c.scheduled {
+		return
+	}
+	c.scheduled = true
+	if NoGoroutinesMode() {
+		c.deadline = c.lastResetTime.Add(c.minResetDuration)
+		return
+	}
+	c.afterFunc(c.minResetDuration-c.now().Sub(c.lastResetTime), c.resetPending)
+}
+
+// remove removes h from the histograms to be reset with the next coordinated
+// reset. It is called if h is deleted from the HistogramVec so that the
+// coordinator doesn't keep it alive.
+func (c *nativeHistogramResetCoordinator) remove(h *histogram) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	delete(c.pending, h)
+}
+
+// resetPendingIfDue calls resetPending if a reset has been scheduled in
+// no-goroutines mode and its deadline has passed. It has to be called without
+// having locked the mtx of any histogram in the HistogramVec.
+func (c *nativeHistogramResetCoordinator) resetPendingIfDue() {
+	c.mtx.Lock()
+	due := !c.deadline.IsZero() && !c.now().Before(c.deadline)
+	c.mtx.Unlock()
+
+	if due {
+		c.resetPending()
+	}
+}
+
+// resetPending resets all histograms scheduled for the coordinated reset.
+func (c *nativeHistogramResetCoordinator) resetPending() {
+	c.mtx.Lock()
+	pending := c.pending
+	c.pending = map[*histogram]struct{}{}
+	c.scheduled = false
+	c.deadline = time.Time{}
+	c.lastResetTime = c.now()
+	c.mtx.Unlock()
+
+	// Reset outside of c.mtx, as h.reset locks h.mtx, which is held while
+	// calling schedule.
+	for h := range pending {
+		h.reset()
+	}
+}
+
 // updateExemplar replaces the exemplar for the provided classic bucket.
 // With empty labels, it's a no-op. It panics if any of the labels is invalid.
 // If histogram is native, the exemplar will be cached into nativeExemplars,
@@ -1190,12 +1486,21 @@ func (v2) NewHistogramVec(opts HistogramVecOpts) *HistogramVec {
 		opts.Help,
 		opts.VariableLabels,
 		opts.ConstLabels,
-	)
-	return &HistogramVec{
-		MetricVec: NewMetricVec(desc, func(lvs ...string) Metric {
-			return newHistogram(desc, opts.HistogramOpts, lvs...)
-		}),
+	).withMetadata(opts.Metadata)
+	if opts.NativeHistogramCoordinatedResets && opts.NativeHistogramMinResetDuration > 0 {
+		opts.resetCoordinator = newNativeHistogramResetCoordinator(opts.HistogramOpts)
+	}
+	mv := NewMetricVec(desc, func(lvs ...string) Metric {
+		return newHistogram(desc, opts.HistogramOpts, lvs...)
+	})
+	if c := opts.resetCoordinator; c != nil {
+		mv.metricMap.onDelete = func(m Metric) {
+			if h, ok := m.(*histogram); ok {
+				c.remove(h)
+			}
+		}
 	}
+	return &HistogramVec{MetricVec: mv}
 }
 
 // GetMetricWithLabelValues returns the Histogram for the given slice of label
@@ -1475,6 +1780,21 @@ func pickSchema(bucketFactor float64) int32 {
 }
 
 func makeBuckets(buckets *sync.Map) ([]*dto.BucketSpan, []int64) {
+	spans, deltas := makeNativeHistogramSpans(buckets)
+	if spans == nil {
+		return nil, nil
+	}
+	dtoSpans := make([]*dto.BucketSpan, len(spans))
+	for i, span := range spans {
+		dtoSpans[i] = &dto.BucketSpan{
+			Offset: proto.Int32(span.Offset),
+			Length: proto.Uint32(span.Length),
+		}
+	}
+	return dtoSpans, deltas
+}
+
+func makeNativeHistogramSpans(buckets *sync.Map) ([]NativeHistogramSpan, []int64) {
 	var ii []int
 	buckets.Range(func(k, v interface{}) bool {
 		ii = append(ii, k.(int))
@@ -1487,14 +1807,14 @@ func makeBuckets(buckets *sync.Map) ([]*dto.BucketSpan, []int64) {
 	}
 
 	var (
-		spans     []*dto.BucketSpan
+		spans     []NativeHistogramSpan
 		deltas    []int64
 		prevCount int64
 		nextI     int
 	)
 
 	appendDelta := func(count int64) {
-		*spans[len(spans)-1].Length++
+		spans[len(spans)-1].Length++
 		deltas = append(deltas, count-prevCount)
 		prevCount = count
 	}
@@ -1512,10 +1832,7 @@ func makeBuckets(buckets *sync.Map) ([]*dto.BucketSpan, []int64) {
 			// We have to create a new span, either because we are
 			// at the very beginning, or because we have found a gap
 			// of more than two buckets.
-			spans = append(spans, &dto.BucketSpan{
-				Offset: proto.Int32(iDelta),
-				Length: proto.Uint32(0),
-			})
+			spans = append(spans, NativeHistogramSpan{Offset: iDelta})
 		} else {
 			// We have found a small gap (or no gap at all).
 			// Insert empty buckets as needed.
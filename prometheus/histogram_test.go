@@ -1277,6 +1277,188 @@ func TestHistogramVecCreatedTimestampWithDeletes(t *testing.T) {
 	expectCTsForMetricVecValues(t, histogramVec.MetricVec, dto.MetricType_HISTOGRAM, expected)
 }
 
+func TestHistogramVecCoordinatedResets(t *testing.T) {
+	var (
+		ts         = time.Now()
+		start      = ts
+		funcToCall func()
+		whenToCall time.Duration
+		timers     int
+	)
+
+	histogramVec := NewHistogramVec(HistogramOpts{
+		Name:                             "test",
+		Help:                             "test help",
+		NativeHistogramBucketFactor:      1.1,
+		NativeHistogramMaxBucketNumber:   2,
+		NativeHistogramMinResetDuration:  5 * time.Minute,
+		NativeHistogramCoordinatedResets: true,
+		now:                              func() time.Time { return ts },
+		afterFunc: func(d time.Duration, f func()) *time.Timer {
+			timers++
+			funcToCall = f
+			whenToCall = d
+			return nil
+		},
+	}, []string{"label"})
+
+	a := histogramVec.WithLabelValues("a")
+	ts = ts.Add(2 * time.Minute)
+	b := histogramVec.WithLabelValues("b")
+
+	// Both histograms exceed their bucket limit, but only one timer is
+	// started, based on the creation time of the vector.
+	for _, o := range []float64{1, 2, 4} {
+		a.Observe(o)
+		b.Observe(o)
+	}
+	if timers != 1 {
+		t.Fatalf("expected exactly one timer, got %d", timers)
+	}
+	if want := 3 * time.Minute; whenToCall != want {
+		t.Errorf("expected reset to be scheduled in %v, got %v", want, whenToCall)
+	}
+	// b is reset together with a, although it was created two minutes later.
+	ts = start.Add(5 * time.Minute)
+	funcToCall()
+
+	for name, h := range map[string]Observer{"a": a, "b": b} {
+		if got := h.(ResetTimeReporter).LastResetTime(); !got.Equal(ts) {
+			t.Errorf("%s: expected last reset time %v, got %v", name, ts, got)
+		}
+	}
+
+	// The next coordinated reset is scheduled relative to the previous one.
+	ts = ts.Add(time.Minute)
+	for _, o := range []float64{1, 2, 4} {
+		b.Observe(o)
+	}
+	if timers != 2 {
+		t.Fatalf("expected a second timer, got %d", timers)
+	}
+	if want := 4 * time.Minute; whenToCall != want {
+		t.Errorf("expected reset to be scheduled in %v, got %v", want, whenToCall)
+	}
+}
+
+func TestHistogramVecCoordinatedResetsDeletedChildren(t *testing.T) {
+	histogramVec := NewHistogramVec(HistogramOpts{
+		Name:                             "test",
+		Help:                             "test help",
+		NativeHistogramBucketFactor:      1.1,
+		NativeHistogramMaxBucketNumber:   2,
+		NativeHistogramMinResetDuration:  5 * time.Minute,
+		NativeHistogramCoordinatedResets: true,
+		afterFunc: func(time.Duration, func()) *time.Timer {
+			return nil
+		},
+	}, []string{"label"})
+	coordinator := histogramVec.WithLabelValues("a").(*histogram).resetCoordinator
+	exceedLimit := func(lvs ...string) {
+		for _, lv := range lvs {
+			for _, o := range []float64{1, 2, 4} {
+				histogramVec.WithLabelValues(lv).Observe(o)
+			}
+		}
+	}
+	pending := func() int {
+		coordinator.mtx.Lock()
+		defer coordinator.mtx.Unlock()
+		return len(coordinator.pending)
+	}
+
+	exceedLimit("a", "b", "c", "d")
+	if got, want := pending(), 4; got != want {
+		t.Fatalf("expected %d pending resets, got %d", want, got)
+	}
+	histogramVec.DeleteLabelValues("a")
+	histogramVec.Delete(Labels{"label": "b"})
+	if got, want := pending(), 2; got != want {
+		t.Errorf("expected %d pending resets after deletion, got %d", want, got)
+	}
+	histogramVec.DeletePartialMatch(Labels{"label": "c"})
+	if got, want := pending(), 1; got != want {
+		t.Errorf("expected %d pending resets after partial match deletion, got %d", want, got)
+	}
+	histogramVec.Rebuild(func() { exceedLimit("e") })
+	if got, want := pending(), 1; got != want {
+		t.Errorf("expected %d pending resets after rebuild, got %d", want, got)
+	}
+	histogramVec.Reset()
+	if got, want := pending(), 0; got != want {
+		t.Errorf("expected %d pending resets after reset, got %d", want, got)
+	}
+}
+
+func TestHistogramResetsNoGoroutinesMode(t *testing.T) {
+	SetNoGoroutinesMode(true)
+	defer SetNoGoroutinesMode(false)
+
+	var (
+		ts        = time.Now()
+		afterFunc = func(time.Duration, func()) *time.Timer {
+			t.Fatal("unexpected timer in no-goroutines mode")
+			return nil
+		}
+	)
+
+	h := NewHistogram(HistogramOpts{
+		Name:                            "test",
+		Help:                            "test help",
+		NativeHistogramBucketFactor:     1.1,
+		NativeHistogramMaxBucketNumber:  2,
+		NativeHistogramMinResetDuration: 5 * time.Minute,
+		now:                             func() time.Time { return ts },
+		afterFunc:                       afterFunc,
+	})
+	histogramVec := NewHistogramVec(HistogramOpts{
+		Name:                             "test",
+		Help:                             "test help",
+		NativeHistogramBucketFactor:      1.1,
+		NativeHistogramMaxBucketNumber:   2,
+		NativeHistogramMinResetDuration:  5 * time.Minute,
+		NativeHistogramCoordinatedResets: true,
+		now:                              func() time.Time { return ts },
+		afterFunc:                        afterFunc,
+	}, []string{"label"})
+	a := histogramVec.WithLabelValues("a")
+
+	// Exceed the bucket limit within the min reset duration, so that a
+	// reset is scheduled.
+	ts = ts.Add(time.Minute)
+	for _, o := range []float64{1, 2, 4} {
+		h.Observe(o)
+		a.Observe(o)
+	}
+	created := ts.Add(-time.Minute)
+
+	m := &dto.Metric{}
+	for name, o := range map[string]Observer{"histogram": h, "vec": a} {
+		// Not yet due.
+		if err := o.(Metric).Write(m); err != nil {
+			t.Fatal(err)
+		}
+		if got := o.(ResetTimeReporter).LastResetTime(); !got.Equal(created) {
+			t.Errorf("%s: expected last reset time %v, got %v", name, created, got)
+		}
+	}
+
+	ts = ts.Add(5 * time.Minute)
+	for name, o := range map[string]Observer{"histogram": h, "vec": a} {
+		// Due now, so Write performs the reset.
+		m.Reset()
+		if err := o.(Metric).Write(m); err != nil {
+			t.Fatal(err)
+		}
+		if got := o.(ResetTimeReporter).LastResetTime(); !got.Equal(ts) {
+			t.Errorf("%s: expected last reset time %v, got %v", name, ts, got)
+		}
+		if got := m.Histogram.GetSampleCount(); got != 0 {
+			t.Errorf("%s: expected sample count 0 after reset, got %d", name, got)
+		}
+	}
+}
+
 func TestNewConstHistogramWithCreatedTimestamp(t *testing.T) {
 	metricDesc := NewDesc(
 		"sample_value",
@@ -2085,3 +2267,92 @@ func TestConstNativeHistogram(t *testing.T) {
 		})
 	}
 }
+
+func TestHistogramSampling(t *testing.T) {
+	calls := 0
+	h := NewHistogram(HistogramOpts{
+		Name:                        "test_histogram",
+		Help:                        "helpless",
+		Buckets:                     []float64{1, 10},
+		NativeHistogramBucketFactor: 2,
+		SampleOneIn:                 4,
+		// Record every other observation.
+		sample: func(oneIn uint32) bool {
+			if oneIn != 4 {
+				t.Errorf("sample called with oneIn=%d, want 4", oneIn)
+			}
+			calls++
+			return calls%2 == 1
+		},
+	})
+	for _, v := range []float64{0.5, 0.5, 5, 5, 5, 5, 20, 20} {
+		h.Observe(v)
+	}
+	h.(ExemplarObserver).ObserveWithExemplar(0.5, Labels{"id": "recorded"})
+	h.(ExemplarObserver).ObserveWithExemplar(0.5, Labels{"id": "dropped"})
+
+	m := &dto.Metric{}
+	if err := h.Write(m); err != nil {
+		t.Fatal(err)
+	}
+	his := m.GetHistogram()
+	if got, want := his.GetSampleCount(), uint64(20); got != want {
+		t.Errorf("got sample count %d, want %d", got, want)
+	}
+	if got, want := his.GetSampleSum(), 4*(0.5+5+5+20+0.5); got != want {
+		t.Errorf("got sample sum %v, want %v", got, want)
+	}
+	for i, want := range []uint64{8, 16} {
+		if got := his.GetBucket()[i].GetCumulativeCount(); got != want {
+			t.Errorf("bucket %d: got cumulative count %d, want %d", i, got, want)
+		}
+	}
+	if e := his.GetBucket()[0].GetExemplar(); e == nil || e.GetLabel()[0].GetValue() != "recorded" {
+		t.Errorf("unexpected exemplar %v", e)
+	}
+	var nativeCount, nativeTotal int64
+	for _, d := range his.GetPositiveDelta() {
+		nativeCount += d
+		if nativeCount%4 != 0 {
+			t.Errorf("native bucket count %d is not a multiple of 4", nativeCount)
+		}
+		nativeTotal += nativeCount
+	}
+	if got, want := uint64(nativeTotal)+his.GetZeroCount(), uint64(20); got != want {
+		t.Errorf("got total native bucket count %d, want %d", got, want)
+	}
+}
+
+func TestHistogramDefaultsApply(t *testing.T) {
+	d := HistogramDefaults{
+		Buckets:                        []float64{1, 2, 3},
+		NativeHistogramBucketFactor:    1.1,
+		NativeHistogramMaxBucketNumber: 100,
+	}
+
+	got := d.Apply(HistogramOpts{Name: "a"})
+	if len(got.Buckets) != 3 || got.NativeHistogramBucketFactor != 1.1 || got.NativeHistogramMaxBucketNumber != 100 {
+		t.Errorf("defaults not applied to empty opts: %+v", got)
+	}
+
+	got = d.Apply(HistogramOpts{Name: "b", Buckets: []float64{5}, NativeHistogramMaxBucketNumber: 10})
+	if len(got.Buckets) != 1 || got.NativeHistogramMaxBucketNumber != 10 {
+		t.Errorf("explicit values overridden: %+v", got)
+	}
+	if got.NativeHistogramBucketFactor != 1.1 {
+		t.Errorf("native buckets not added to classic histogram: %+v", got)
+	}
+
+	// A native-only histogram doesn't get classic buckets.
+	got = d.Apply(HistogramOpts{Name: "c", NativeHistogramBucketFactor: 2})
+	if len(got.Buckets) != 0 || got.NativeHistogramBucketFactor != 2 {
+		t.Errorf("unexpected opts for native-only histogram: %+v", got)
+	}
+
+	// Without default buckets, histograms without buckets keep DefBuckets
+	// rather than becoming native-only.
+	got = HistogramDefaults{NativeHistogramBucketFactor: 1.1}.Apply(HistogramOpts{Name: "d"})
+	if !reflect.DeepEqual(got.Buckets, DefBuckets) || got.NativeHistogramBucketFactor != 1.1 {
+		t.Errorf("unexpected opts for histogram without buckets: %+v", got)
+	}
+}
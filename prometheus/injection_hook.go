@@ -0,0 +1,131 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"fmt"
+	"sort"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// InjectionHook is a function returning metric families to inject into an
+// exposition. It has the signature of the hooks set with
+// SetMetricFamilyInjectionHook in very old versions (v0.8 and earlier) of this
+// package, which injected the returned metric families into the exposition of
+// the default registry without any checks. InjectionHook helps migrating
+// integrations built around such a hook (e.g. custom delivery of metric
+// families from another system) onto the supported APIs without rewriting the
+// hook first.
+//
+// An InjectionHook is a Gatherer, so it can be combined with a Registry using
+// Gatherers:
+//
+//	handler := promhttp.HandlerFor(
+//		prometheus.Gatherers{prometheus.DefaultGatherer, prometheus.InjectionHook(myHook)},
+//		promhttp.HandlerOpts{},
+//	)
+//
+// Alternatively, use NewInjectionHookCollector to register the hook with a
+// Registry like any other Collector.
+type InjectionHook func() []*dto.MetricFamily
+
+// Gather implements Gatherer. It calls the hook and checks the returned metric
+// families for consistency in the same way as Gatherers does. Inconsistent
+// metrics are dropped and reported in the returned error.
+func (h InjectionHook) Gather() ([]*dto.MetricFamily, error) {
+	return Gatherers{GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return h(), nil
+	})}.Gather()
+}
+
+// NewInjectionHookCollector returns a Collector that collects the metrics in the
+// metric families returned by the provided hook. As the metric families are
+// only known when the hook is called, the Collector is an unchecked Collector
+// (see Registry.Register). In contrast to using the InjectionHook as a
+// Gatherer, the metrics undergo all the checks a Registry performs for metrics
+// collected from a Collector, including the validation of metric and label
+// names, and they are checked for consistency with the metrics of all other
+// Collectors registered with the same Registry.
+//
+// Each call of Collect calls the hook. A metric with a metric type different
+// from the type of its metric family is collected as an invalid metric (see
+// NewInvalidMetric). The metric families returned by the hook are not
+// modified.
+func NewInjectionHookCollector(hook InjectionHook) Collector {
+	return &injectionHookCollector{hook: hook}
+}
+
+type injectionHookCollector struct {
+	hook InjectionHook
+}
+
+// Describe implements Collector. It sends no descriptors, making the
+// Collector an unchecked Collector.
+func (c *injectionHookCollector) Describe(chan<- *Desc) {}
+
+// Collect implements Collector.
+func (c *injectionHookCollector) Collect(ch chan<- Metric) {
+	for _, mf := range c.hook() {
+		for _, m := range mf.GetMetric() {
+			labelNames := make([]string, 0, len(m.GetLabel()))
+			for _, lp := range m.GetLabel() {
+				labelNames = append(labelNames, lp.GetName())
+			}
+			sort.Strings(labelNames)
+			desc := NewDesc(mf.GetName(), mf.GetHelp(), labelNames, nil)
+			if !hasMetricType(m, mf.GetType()) {
+				ch <- NewInvalidMetric(desc, fmt.Errorf(
+					"injected metric %s %s is not of type %s like its metric family",
+					mf.GetName(), m, mf.GetType(),
+				))
+				continue
+			}
+			ch <- &injectedMetric{desc: desc, metric: m}
+		}
+	}
+}
+
+// hasMetricType returns whether m has the value field for the metric type t.
+func hasMetricType(m *dto.Metric, t dto.MetricType) bool {
+	switch t {
+	case dto.MetricType_COUNTER:
+		return m.Counter != nil
+	case dto.MetricType_GAUGE:
+		return m.Gauge != nil
+	case dto.MetricType_SUMMARY:
+		return m.Summary != nil
+	case dto.MetricType_UNTYPED:
+		return m.Untyped != nil
+	case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+		return m.Histogram != nil
+	}
+	return false
+}
+
+// injectedMetric is a Metric returned by an InjectionHook.
+type injectedMetric struct {
+	desc   *Desc
+	metric *dto.Metric
+}
+
+func (m *injectedMetric) Desc() *Desc {
+	return m.desc
+}
+
+func (m *injectedMetric) Write(out *dto.Metric) error {
+	proto.Merge(out, m.metric)
+	return nil
+}
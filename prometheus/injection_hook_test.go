@@ -0,0 +1,93 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+func injectionTestHook() []*dto.MetricFamily {
+	return []*dto.MetricFamily{
+		{
+			Name: proto.String("injected_total"),
+			Help: proto.String("Injected counter."),
+			Type: dto.MetricType_COUNTER.Enum(),
+			Metric: []*dto.Metric{
+				{
+					Label:   []*dto.LabelPair{{Name: proto.String("b"), Value: proto.String("2")}, {Name: proto.String("a"), Value: proto.String("1")}},
+					Counter: &dto.Counter{Value: proto.Float64(42)},
+				},
+				{
+					// Duplicate of the above.
+					Label:   []*dto.LabelPair{{Name: proto.String("a"), Value: proto.String("1")}, {Name: proto.String("b"), Value: proto.String("2")}},
+					Counter: &dto.Counter{Value: proto.Float64(43)},
+				},
+				{
+					// Wrong type.
+					Gauge: &dto.Gauge{Value: proto.Float64(1)},
+				},
+			},
+		},
+	}
+}
+
+func TestInjectionHookGatherer(t *testing.T) {
+	mfs, err := InjectionHook(injectionTestHook).Gather()
+	if err == nil {
+		t.Error("expected error for inconsistent metrics")
+	}
+	if len(mfs) != 1 || len(mfs[0].Metric) != 1 {
+		t.Fatalf("unexpected metric families: %v", mfs)
+	}
+	if got := mfs[0].Metric[0].GetCounter().GetValue(); got != 42 {
+		t.Errorf("got value %v, want 42", got)
+	}
+}
+
+func TestInjectionHookCollector(t *testing.T) {
+	hookMFs := injectionTestHook()
+	reg := NewPedanticRegistry()
+	reg.MustRegister(NewInjectionHookCollector(func() []*dto.MetricFamily { return hookMFs }))
+	reg.MustRegister(NewCounter(CounterOpts{Name: "regular_total", Help: "Regular counter."}))
+
+	mfs, err := reg.Gather()
+	if err == nil {
+		t.Fatal("expected error for inconsistent metrics")
+	}
+	for _, want := range []string{"was collected before with the same name and label values", "is not of type COUNTER"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+	if len(mfs) != 2 {
+		t.Fatalf("got %d metric families, want 2", len(mfs))
+	}
+	injected := mfs[0]
+	if injected.GetName() != "injected_total" || len(injected.Metric) != 1 {
+		t.Fatalf("unexpected metric family: %v", injected)
+	}
+	m := injected.Metric[0]
+	if m.GetLabel()[0].GetName() != "a" || m.GetCounter().GetValue() != 42 {
+		t.Errorf("unexpected metric: %v", m)
+	}
+
+	// The metric families returned by the hook are not modified.
+	if got := hookMFs[0].Metric[0].GetLabel()[0].GetName(); got != "b" {
+		t.Errorf("label order of hook metric changed, first label is %q", got)
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// MaxExactInt is 2^53, the largest integer up to which all integers can be
+// represented exactly as a float64, the type of sample values in the
+// exposition formats. Beyond it, the exposed value of an IntCounter or IntGauge
+// is rounded to a nearby representable value.
+const MaxExactInt = 1 << 53
+
+// IntCounter is a Counter-like Metric restricted to integer increments. It
+// tracks its value as an exact uint64 and is meant for very large counts, e.g.
+// of bytes transferred by a long-lived high-throughput process, where the
+// silent precision loss of a float64 beyond 2^53 matters. The exposed value is
+// still a float64 (as required by the exposition formats), but Value returns
+// the exact value, and PrecisionLost reports whether the exposed value has
+// become imprecise.
+//
+// To create IntCounter instances, use NewIntCounter.
+type IntCounter interface {
+	Metric
+	Collector
+
+	// Inc increments the counter by 1.
+	Inc()
+	// Add adds the given value to the counter. If the counter would
+	// overflow a uint64, it saturates at math.MaxUint64 instead.
+	Add(uint64)
+	// Value returns the exact current value of the counter.
+	Value() uint64
+	// PrecisionLost returns true if the value has exceeded MaxExactInt (or
+	// has saturated), i.e. if the exposed value is not exact anymore.
+	PrecisionLost() bool
+}
+
+// NewIntCounter creates a new IntCounter based on the provided CounterOpts.
+// The resulting metric is exposed as a regular counter.
+func NewIntCounter(opts CounterOpts) IntCounter {
+	desc := NewDesc(
+		BuildFQName(opts.Namespace, opts.Subsystem, opts.Name),
+		opts.Help,
+		nil,
+		opts.ConstLabels,
+	).withMetadata(opts.Metadata)
+	if opts.now == nil {
+		opts.now = time.Now
+	}
+	result := &intCounter{desc: desc, labelPairs: desc.constLabelPairs}
+	result.init(result) // Init self-collection.
+	result.createdTs = timestamppb.New(opts.now())
+	return result
+}
+
+type intCounter struct {
+	// val and saturated have to go first in the struct to guarantee
+	// alignment for atomic operations.
+	// http://golang.org/pkg/sync/atomic/#pkg-note-BUG
+	val       uint64
+	saturated uint32
+
+	selfCollector
+	desc *Desc
+
+	createdTs  *timestamppb.Timestamp
+	labelPairs []*dto.LabelPair
+}
+
+func (c *intCounter) Desc() *Desc {
+	return c.desc
+}
+
+func (c *intCounter) Inc() {
+	c.Add(1)
+}
+
+func (c *intCounter) Add(v uint64) {
+	if n := atomic.AddUint64(&c.val, v); n < v {
+		// Wrapped around. Saturate instead. Concurrent additions might
+		// still wrap around again, which is why Value and Write check
+		// the saturated flag, too.
+		atomic.StoreUint32(&c.saturated, 1)
+		atomic.StoreUint64(&c.val, math.MaxUint64)
+	}
+}
+
+func (c *intCounter) Value() uint64 {
+	if atomic.LoadUint32(&c.saturated) == 1 {
+		return math.MaxUint64
+	}
+	return atomic.LoadUint64(&c.val)
+}
+
+func (c *intCounter) PrecisionLost() bool {
+	return c.Value() > MaxExactInt
+}
+
+func (c *intCounter) Write(out *dto.Metric) error {
+	return populateMetric(CounterValue, float64(c.Value()), c.labelPairs, nil, out, c.createdTs)
+}
+
+// IntCounterVec is a Collector that bundles a set of IntCounters that all share
+// the same Desc, but have different values for their variable labels. It works
+// like CounterVec. Create instances with NewIntCounterVec.
+type IntCounterVec struct {
+	*MetricVec
+}
+
+// NewIntCounterVec creates a new IntCounterVec based on the provided
+// CounterOpts and partitioned by the given label names.
+func NewIntCounterVec(opts CounterOpts, labelNames []string) *IntCounterVec {
+	desc := NewDesc(
+		BuildFQName(opts.Namespace, opts.Subsystem, opts.Name),
+		opts.Help,
+		labelNames,
+		opts.ConstLabels,
+	).withMetadata(opts.Metadata)
+	if opts.now == nil {
+		opts.now = time.Now
+	}
+	return &IntCounterVec{
+		MetricVec: NewMetricVec(desc, func(lvs ...string) Metric {
+			if len(lvs) != len(desc.variableLabels.names) {
+				panic(makeInconsistentCardinalityError(desc.fqName, desc.variableLabels.names, lvs))
+			}
+			result := &intCounter{desc: desc, labelPairs: MakeLabelPairs(desc, lvs)}
+			result.init(result) // Init self-collection.
+			result.createdTs = timestamppb.New(opts.now())
+			return result
+		}),
+	}
+}
+
+// GetMetricWithLabelValues works like CounterVec.GetMetricWithLabelValues.
+func (v *IntCounterVec) GetMetricWithLabelValues(lvs ...string) (IntCounter, error) {
+	metric, err := v.MetricVec.GetMetricWithLabelValues(lvs...)
+	if metric != nil {
+		return metric.(IntCounter), err
+	}
+	return nil, err
+}
+
+// GetMetricWith works like CounterVec.GetMetricWith.
+func (v *IntCounterVec) GetMetricWith(labels Labels) (IntCounter, error) {
+	metric, err := v.MetricVec.GetMetricWith(labels)
+	if metric != nil {
+		return metric.(IntCounter), err
+	}
+	return nil, err
+}
+
+// WithLabelValues works as GetMetricWithLabelValues, but panics where
+// GetMetricWithLabelValues would have returned an error.
+func (v *IntCounterVec) WithLabelValues(lvs ...string) IntCounter {
+	c, err := v.GetMetricWithLabelValues(lvs...)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// With works as GetMetricWith, but panics where GetMetricWithLabels would have
+// returned an error.
+func (v *IntCounterVec) With(labels Labels) IntCounter {
+	c, err := v.GetMetricWith(labels)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// CurryWith works like CounterVec.CurryWith.
+func (v *IntCounterVec) CurryWith(labels Labels) (*IntCounterVec, error) {
+	vec, err := v.MetricVec.CurryWith(labels)
+	if vec != nil {
+		return &IntCounterVec{vec}, err
+	}
+	return nil, err
+}
+
+// MustCurryWith works as CurryWith but panics where CurryWith would have
+// returned an error.
+func (v *IntCounterVec) MustCurryWith(labels Labels) *IntCounterVec {
+	vec, err := v.CurryWith(labels)
+	if err != nil {
+		panic(err)
+	}
+	return vec
+}
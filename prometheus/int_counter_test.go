@@ -0,0 +1,86 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"math"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestIntCounter(t *testing.T) {
+	c := NewIntCounter(CounterOpts{
+		Name: "test",
+		Help: "test help",
+	}).(*intCounter)
+	c.Inc()
+	c.Add(MaxExactInt - 1)
+	if got := c.Value(); got != MaxExactInt {
+		t.Errorf("got value %d, want %d", got, uint64(MaxExactInt))
+	}
+	if c.PrecisionLost() {
+		t.Error("precision lost at 2^53")
+	}
+	c.Inc()
+	if got := c.Value(); got != MaxExactInt+1 {
+		t.Errorf("got value %d, want %d", got, uint64(MaxExactInt+1))
+	}
+	if !c.PrecisionLost() {
+		t.Error("precision not lost beyond 2^53")
+	}
+
+	m := &dto.Metric{}
+	if err := c.Write(m); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.GetCounter().GetValue(); got != MaxExactInt {
+		t.Errorf("got exposed value %v, want %v", got, float64(MaxExactInt))
+	}
+	if m.GetCounter().GetCreatedTimestamp() == nil {
+		t.Error("created timestamp not set")
+	}
+}
+
+func TestIntCounterSaturates(t *testing.T) {
+	c := NewIntCounter(CounterOpts{Name: "test", Help: "test help"})
+	c.Add(math.MaxUint64 - 1)
+	c.Add(5)
+	if got := c.Value(); got != math.MaxUint64 {
+		t.Errorf("got value %d, want %d", got, uint64(math.MaxUint64))
+	}
+	c.Add(5)
+	if got := c.Value(); got != math.MaxUint64 {
+		t.Errorf("got value %d after further addition, want %d", got, uint64(math.MaxUint64))
+	}
+	if !c.PrecisionLost() {
+		t.Error("precision not lost after saturation")
+	}
+}
+
+func TestIntCounterVec(t *testing.T) {
+	vec := NewIntCounterVec(CounterOpts{Name: "test", Help: "test help"}, []string{"a", "b"})
+	vec.WithLabelValues("1", "2").Add(3)
+	vec.With(Labels{"a": "1", "b": "2"}).Inc()
+	if got := vec.WithLabelValues("1", "2").Value(); got != 4 {
+		t.Errorf("got value %d, want 4", got)
+	}
+	curried := vec.MustCurryWith(Labels{"a": "1"})
+	if got := curried.WithLabelValues("2").Value(); got != 4 {
+		t.Errorf("got value %d from curried vector, want 4", got)
+	}
+	if _, err := vec.GetMetricWithLabelValues("1"); err == nil {
+		t.Error("expected error for inconsistent cardinality")
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"math"
+	"sync/atomic"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// IntGauge is a Gauge-like Metric restricted to integer values. It tracks its
+// value as an exact int64. See IntCounter for the motivation. In addition to
+// the precision loss beyond MaxExactInt, IntGauge detects overflows of the
+// int64, in which case the value saturates at math.MaxInt64 or math.MinInt64.
+//
+// To create IntGauge instances, use NewIntGauge.
+type IntGauge interface {
+	Metric
+	Collector
+
+	// Set sets the gauge to the given value.
+	Set(int64)
+	// Inc increments the gauge by 1.
+	Inc()
+	// Dec decrements the gauge by 1.
+	Dec()
+	// Add adds the given value to the gauge. (The value can be negative,
+	// resulting in a decrease of the gauge.)
+	Add(int64)
+	// Sub subtracts the given value from the gauge. (The value can be
+	// negative, resulting in an increase of the gauge.)
+	Sub(int64)
+	// Value returns the exact current value of the gauge.
+	Value() int64
+	// PrecisionLost returns true if the absolute value of the gauge has
+	// ever exceeded MaxExactInt (or the gauge has ever overflowed), i.e. if
+	// an exposed value might not have been exact. Unlike the value, this
+	// indicator is never reset, so that a short-lived excursion is not
+	// missed.
+	PrecisionLost() bool
+}
+
+// NewIntGauge creates a new IntGauge based on the provided GaugeOpts. The
+// resulting metric is exposed as a regular gauge.
+func NewIntGauge(opts GaugeOpts) IntGauge {
+	desc := NewDesc(
+		BuildFQName(opts.Namespace, opts.Subsystem, opts.Name),
+		opts.Help,
+		nil,
+		opts.ConstLabels,
+	).withMetadata(opts.Metadata)
+	result := &intGauge{desc: desc, labelPairs: desc.constLabelPairs}
+	result.init(result) // Init self-collection.
+	return result
+}
+
+type intGauge struct {
+	// val and precisionLost have to go first in the struct to guarantee
+	// alignment for atomic operations.
+	// http://golang.org/pkg/sync/atomic/#pkg-note-BUG
+	val           int64
+	precisionLost uint32
+
+	selfCollector
+
+	desc       *Desc
+	labelPairs []*dto.LabelPair
+}
+
+func (g *intGauge) Desc() *Desc {
+	return g.desc
+}
+
+func (g *intGauge) Set(v int64) {
+	atomic.StoreInt64(&g.val, v)
+	g.checkPrecision(v)
+}
+
+func (g *intGauge) Inc() {
+	g.Add(1)
+}
+
+func (g *intGauge) Dec() {
+	g.Add(-1)
+}
+
+func (g *intGauge) Add(v int64) {
+	for {
+		old := atomic.LoadInt64(&g.val)
+		n := old + v
+		switch {
+		case v > 0 && n < old:
+			n = math.MaxInt64
+		case v < 0 && n > old:
+			n = math.MinInt64
+		}
+		if atomic.CompareAndSwapInt64(&g.val, old, n) {
+			g.checkPrecision(n)
+			return
+		}
+	}
+}
+
+func (g *intGauge) Sub(v int64) {
+	if v != math.MinInt64 {
+		g.Add(-v)
+		return
+	}
+	// -v would overflow. Subtracting MinInt64 saturates for any
+	// non-negative value.
+	for {
+		old := atomic.LoadInt64(&g.val)
+		n := int64(math.MaxInt64)
+		if old < 0 {
+			n = old + math.MaxInt64 + 1
+		}
+		if atomic.CompareAndSwapInt64(&g.val, old, n) {
+			g.checkPrecision(n)
+			return
+		}
+	}
+}
+
+// checkPrecision sets the precisionLost flag if v is beyond MaxExactInt.
+func (g *intGauge) checkPrecision(v int64) {
+	if (v > MaxExactInt || v < -MaxExactInt) && atomic.LoadUint32(&g.precisionLost) == 0 {
+		atomic.StoreUint32(&g.precisionLost, 1)
+	}
+}
+
+func (g *intGauge) Value() int64 {
+	return atomic.LoadInt64(&g.val)
+}
+
+func (g *intGauge) PrecisionLost() bool {
+	return atomic.LoadUint32(&g.precisionLost) == 1
+}
+
+func (g *intGauge) Write(out *dto.Metric) error {
+	return populateMetric(GaugeValue, float64(g.Value()), g.labelPairs, nil, out, nil)
+}
+
+// IntGaugeVec is a Collector that bundles a set of IntGauges that all share the
+// same Desc, but have different values for their variable labels. It works
+// like GaugeVec. Create instances with NewIntGaugeVec.
+type IntGaugeVec struct {
+	*MetricVec
+}
+
+// NewIntGaugeVec creates a new IntGaugeVec based on the provided GaugeOpts and
+// partitioned by the given label names.
+func NewIntGaugeVec(opts GaugeOpts, labelNames []string) *IntGaugeVec {
+	desc := NewDesc(
+		BuildFQName(opts.Namespace, opts.Subsystem, opts.Name),
+		opts.Help,
+		labelNames,
+		opts.ConstLabels,
+	).withMetadata(opts.Metadata)
+	return &IntGaugeVec{
+		MetricVec: NewMetricVec(desc, func(lvs ...string) Metric {
+			if len(lvs) != len(desc.variableLabels.names) {
+				panic(makeInconsistentCardinalityError(desc.fqName, desc.variableLabels.names, lvs))
+			}
+			result := &intGauge{desc: desc, labelPairs: MakeLabelPairs(desc, lvs)}
+			result.init(result) // Init self-collection.
+			return result
+		}),
+	}
+}
+
+// GetMetricWithLabelValues works like GaugeVec.GetMetricWithLabelValues.
+func (v *IntGaugeVec) GetMetricWithLabelValues(lvs ...string) (IntGauge, error) {
+	metric, err := v.MetricVec.GetMetricWithLabelValues(lvs...)
+	if metric != nil {
+		return metric.(IntGauge), err
+	}
+	return nil, err
+}
+
+// GetMetricWith works like GaugeVec.GetMetricWith.
+func (v *IntGaugeVec) GetMetricWith(labels Labels) (IntGauge, error) {
+	metric, err := v.MetricVec.GetMetricWith(labels)
+	if metric != nil {
+		return metric.(IntGauge), err
+	}
+	return nil, err
+}
+
+// WithLabelValues works as GetMetricWithLabelValues, but panics where
+// GetMetricWithLabelValues would have returned an error.
+func (v *IntGaugeVec) WithLabelValues(lvs ...string) IntGauge {
+	g, err := v.GetMetricWithLabelValues(lvs...)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// With works as GetMetricWith, but panics where GetMetricWithLabels would have
+// returned an error.
+func (v *IntGaugeVec) With(labels Labels) IntGauge {
+	g, err := v.GetMetricWith(labels)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// CurryWith works like GaugeVec.CurryWith.
+func (v *IntGaugeVec) CurryWith(labels Labels) (*IntGaugeVec, error) {
+	vec, err := v.MetricVec.CurryWith(labels)
+	if vec != nil {
+		return &IntGaugeVec{vec}, err
+	}
+	return nil, err
+}
+
+// MustCurryWith works as CurryWith but panics where CurryWith would have
+// returned an error.
+func (v *IntGaugeVec) MustCurryWith(labels Labels) *IntGaugeVec {
+	vec, err := v.CurryWith(labels)
+	if err != nil {
+		panic(err)
+	}
+	return vec
+}
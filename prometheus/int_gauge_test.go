@@ -0,0 +1,114 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"math"
+	"sync"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestIntGauge(t *testing.T) {
+	g := NewIntGauge(GaugeOpts{Name: "test", Help: "test help"})
+	g.Set(42)
+	g.Inc()
+	g.Dec()
+	g.Dec()
+	g.Add(10)
+	g.Sub(20)
+	if got := g.Value(); got != 31 {
+		t.Errorf("got value %d, want 31", got)
+	}
+
+	m := &dto.Metric{}
+	if err := g.Write(m); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.GetGauge().GetValue(); got != 31 {
+		t.Errorf("got exposed value %v, want 31", got)
+	}
+	if g.PrecisionLost() {
+		t.Error("precision lost for small values")
+	}
+
+	// A short excursion beyond 2^53 is remembered.
+	g.Set(-MaxExactInt - 1)
+	g.Set(0)
+	if !g.PrecisionLost() {
+		t.Error("precision loss not remembered")
+	}
+}
+
+func TestIntGaugeSaturates(t *testing.T) {
+	g := NewIntGauge(GaugeOpts{Name: "test", Help: "test help"})
+	g.Set(math.MaxInt64 - 1)
+	g.Add(5)
+	if got := g.Value(); got != math.MaxInt64 {
+		t.Errorf("got value %d, want %d", got, int64(math.MaxInt64))
+	}
+	g.Set(math.MinInt64 + 1)
+	g.Sub(5)
+	if got := g.Value(); got != math.MinInt64 {
+		t.Errorf("got value %d, want %d", got, int64(math.MinInt64))
+	}
+	g.Set(0)
+	g.Sub(math.MinInt64)
+	if got := g.Value(); got != math.MaxInt64 {
+		t.Errorf("got value %d after subtracting MinInt64, want %d", got, int64(math.MaxInt64))
+	}
+	g.Set(-5)
+	g.Sub(math.MinInt64)
+	if got, want := g.Value(), int64(math.MaxInt64-4); got != want {
+		t.Errorf("got value %d after subtracting MinInt64, want %d", got, want)
+	}
+}
+
+func TestIntGaugeSaturatesConcurrently(t *testing.T) {
+	const goroutines = 8
+
+	g := NewIntGauge(GaugeOpts{Name: "test", Help: "test help"})
+	g.Set(math.MaxInt64)
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10000; j++ {
+				g.Inc()
+				g.Dec()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Each Dec follows an Inc of the same goroutine, so the value can
+	// only drop below MaxInt64 by the increments lost to saturation.
+	if got, min := g.Value(), int64(math.MaxInt64-goroutines); got < min {
+		t.Errorf("got value %d, want at least %d", got, min)
+	}
+}
+
+func TestIntGaugeVec(t *testing.T) {
+	vec := NewIntGaugeVec(GaugeOpts{Name: "test", Help: "test help"}, []string{"a"})
+	vec.WithLabelValues("x").Set(-7)
+	if got := vec.With(Labels{"a": "x"}).Value(); got != -7 {
+		t.Errorf("got value %d, want -7", got)
+	}
+	if _, err := vec.GetMetricWith(Labels{"b": "x"}); err == nil {
+		t.Error("expected error for unknown label")
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+// modulePrefix is the prefix of the functions of all packages of this module.
+const modulePrefix = "github.com/prometheus/client_golang/"
+
+// deprecationHook is the function set with SetDeprecationHook, or nil.
+var deprecationHook atomic.Pointer[func(function, replacement, callSite string)]
+
+// SetDeprecationHook sets the function ReportDeprecated reports calls to. A nil
+// function disables reporting. It is used by prometheus.SetDeprecationReporter.
+func SetDeprecationHook(hook func(function, replacement, callSite string)) {
+	if hook == nil {
+		deprecationHook.Store(nil)
+		return
+	}
+	deprecationHook.Store(&hook)
+}
+
+// ReportDeprecated reports a call of the deprecated function calling it to the
+// hook set with SetDeprecationHook, if any. It has to be called directly by
+// every deprecated function of this module. function and replacement are
+// qualified with their package name, e.g. "prometheus.NewGoCollector".
+//
+// Calls from within this module (e.g. by a replacement calling the deprecated
+// function) are not reported, unless they are made by tests.
+func ReportDeprecated(function, replacement string) {
+	hook := deprecationHook.Load()
+	if hook == nil {
+		return
+	}
+	// Skip runtime.Callers, ReportDeprecated, and the deprecated function.
+	pcs := make([]uintptr, 2)
+	if runtime.Callers(3, pcs) == 0 {
+		return
+	}
+	frame, _ := runtime.CallersFrames(pcs).Next()
+	if strings.HasPrefix(frame.Function, modulePrefix) && !strings.HasSuffix(frame.File, "_test.go") {
+		return
+	}
+	(*hook)(function, replacement, fmt.Sprintf("%s:%d", frame.File, frame.Line))
+}
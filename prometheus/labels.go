@@ -186,3 +186,90 @@ func validateLabelValues(vals []string, expectedNumberOfValues int) error {
 func checkLabelName(l string) bool {
 	return model.LabelName(l).IsValid() && !strings.HasPrefix(l, reservedLabelPrefix)
 }
+
+// LabelSchema is an immutable, ordered list of variable label names, each with
+// an optional constraint. A LabelSchema is meant to be shared between all
+// metric vectors (and Descs) that are supposed to have the same label
+// dimensions, so that the label names are declared only once and cannot drift
+// apart between them. LabelSchema implements ConstrainableLabels and can
+// therefore be used as VariableLabels in the various ...VecOpts, e.g.:
+//
+//	requestLabels := prometheus.MustNewLabelSchema(prometheus.UnconstrainedLabels{"method", "code"})
+//	requests := prometheus.V2.NewCounterVec(prometheus.CounterVecOpts{
+//		CounterOpts:    prometheus.CounterOpts{Name: "http_requests_total", Help: "..."},
+//		VariableLabels: requestLabels,
+//	})
+//	errors := prometheus.V2.NewCounterVec(prometheus.CounterVecOpts{
+//		CounterOpts:    prometheus.CounterOpts{Name: "http_errors_total", Help: "..."},
+//		VariableLabels: requestLabels,
+//	})
+//
+// Create instances with NewLabelSchema or MustNewLabelSchema.
+type LabelSchema struct {
+	labels *compiledLabels
+}
+
+// NewLabelSchema creates a LabelSchema from the provided labels, which are
+// typically UnconstrainedLabels or ConstrainedLabels. In contrast to creating a
+// Desc, where errors are only reported at registration time, the label names
+// are validated right away. An error is returned if any label name is invalid
+// or reserved, or if a label name occurs more than once.
+func NewLabelSchema(labels ConstrainableLabels) (*LabelSchema, error) {
+	compiled := labels.compile()
+	names := make([]string, len(compiled.names))
+	copy(names, compiled.names)
+	seen := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		if !checkLabelName(name) {
+			return nil, fmt.Errorf("%q is not a valid label name", name)
+		}
+		if _, ok := seen[name]; ok {
+			return nil, fmt.Errorf("duplicate label name %q", name)
+		}
+		seen[name] = struct{}{}
+	}
+	constraints := make(map[string]LabelConstraint, len(compiled.labelConstraints))
+	for name, fn := range compiled.labelConstraints {
+		constraints[name] = fn
+	}
+	return &LabelSchema{labels: &compiledLabels{names: names, labelConstraints: constraints}}, nil
+}
+
+// MustNewLabelSchema works like NewLabelSchema but panics where
+// NewLabelSchema would have returned an error.
+func MustNewLabelSchema(labels ConstrainableLabels) *LabelSchema {
+	s, err := NewLabelSchema(labels)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Names returns the label names of the LabelSchema in declaration order.
+func (s *LabelSchema) Names() []string {
+	names := make([]string, len(s.labels.names))
+	copy(names, s.labels.names)
+	return names
+}
+
+// Extend returns a new LabelSchema with the provided labels appended to the
+// labels of s. s itself is not modified. The same validation as in
+// NewLabelSchema applies.
+func (s *LabelSchema) Extend(labels ConstrainableLabels) (*LabelSchema, error) {
+	extension := labels.compile()
+	combined := make(ConstrainedLabels, 0, len(s.labels.names)+len(extension.names))
+	for _, cl := range []*compiledLabels{s.labels, extension} {
+		for _, name := range cl.names {
+			combined = append(combined, ConstrainedLabel{Name: name, Constraint: cl.labelConstraints[name]})
+		}
+	}
+	return NewLabelSchema(combined)
+}
+
+func (s *LabelSchema) compile() *compiledLabels {
+	return s.labels
+}
+
+func (s *LabelSchema) labelNames() []string {
+	return s.labels.names
+}
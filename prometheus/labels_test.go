@@ -0,0 +1,73 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestLabelSchema(t *testing.T) {
+	if _, err := NewLabelSchema(UnconstrainedLabels{"a", "b", "a"}); err == nil {
+		t.Error("expected error for duplicate label name")
+	}
+	if _, err := NewLabelSchema(UnconstrainedLabels{"le-"}); err == nil {
+		t.Error("expected error for invalid label name")
+	}
+
+	names := UnconstrainedLabels{"method", "code"}
+	schema := MustNewLabelSchema(names)
+	names[0] = "changed" // Must not affect the schema.
+	if got, want := schema.Names(), []string{"method", "code"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got names %v, want %v", got, want)
+	}
+
+	extended, err := schema.Extend(ConstrainedLabels{{Name: "path", Constraint: strings.ToLower}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := extended.Names(), []string{"method", "code", "path"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got names %v, want %v", got, want)
+	}
+	if got, want := len(schema.Names()), 2; got != want {
+		t.Errorf("original schema modified, got %d names, want %d", got, want)
+	}
+	if _, err := schema.Extend(UnconstrainedLabels{"code"}); err == nil {
+		t.Error("expected error when extending with an existing label name")
+	}
+
+	// A schema can be shared between vectors, and constraints are applied.
+	v1 := V2.NewCounterVec(CounterVecOpts{CounterOpts: CounterOpts{Name: "a", Help: "a"}, VariableLabels: extended})
+	v2 := V2.NewGaugeVec(GaugeVecOpts{GaugeOpts: GaugeOpts{Name: "b", Help: "b"}, VariableLabels: extended})
+	v1.WithLabelValues("GET", "200", "/FOO").Inc()
+	v2.WithLabelValues("GET", "200", "/FOO").Set(1)
+	for _, c := range []Collector{v1, v2} {
+		ch := make(chan Metric, 1)
+		c.Collect(ch)
+		pb := &dto.Metric{}
+		if err := (<-ch).Write(pb); err != nil {
+			t.Fatal(err)
+		}
+		got := map[string]string{}
+		for _, lp := range pb.GetLabel() {
+			got[lp.GetName()] = lp.GetValue()
+		}
+		if want := map[string]string{"method": "GET", "code": "200", "path": "/foo"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got labels %v, want %v", got, want)
+		}
+	}
+}
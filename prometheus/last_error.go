@@ -0,0 +1,192 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// LastErrorOpts specifies the options for NewLastError.
+type LastErrorOpts struct {
+	// Namespace, Subsystem, and Name are components of the fully-qualified
+	// name of the metrics (created by joining these components with "_").
+	// Name is mandatory. The suffixes "_info" and "_timestamp_seconds" are
+	// appended for the two exported metrics, so a typical Name is
+	// "last_error".
+	Namespace string
+	Subsystem string
+	Name      string
+
+	// Help is used as a base for the help strings of the exported metrics,
+	// e.g. "Last error of the queue consumer."
+	Help string
+
+	// ConstLabels are used to attach fixed labels to the exported metrics,
+	// e.g. to distinguish subsystems sharing the same metric names.
+	ConstLabels Labels
+
+	// Classify maps an error to the value of the "class" label. It must
+	// return a value from a small, bounded set (e.g. "timeout",
+	// "connection_refused", "invalid_payload"), as each class creates a
+	// separate series over time. It should never return the error message
+	// itself. If nil, the dynamic type of the innermost wrapped error is
+	// used (e.g. "*net.OpError").
+	Classify func(error) string
+
+	// MinClassChangeInterval is the minimum time between two changes of
+	// the "class" label. An error of a different class recorded earlier
+	// updates the timestamp immediately, but the class change only
+	// becomes visible once the interval has passed since the previous
+	// change (upon the next Record or collection). This protects against
+	// label churn (i.e. many short-lived series) when a worker alternates
+	// quickly between different errors. If zero, the class changes
+	// immediately.
+	MinClassChangeInterval time.Duration
+
+	// now is for testing purposes, by default it's time.Now.
+	now func() time.Time
+}
+
+// LastError is a Collector that records the last error of a subsystem, e.g. of
+// a background worker loop or a queue consumer, a pattern that is otherwise
+// reimplemented over and over. It exports the following metrics:
+//
+//   - <fqName>_info (gauge, by "class"): Always 1 and only present while
+//     the most recent outcome is an error, i.e. after Record and before
+//     Clear. The "class" label identifies the class of the last error, see
+//     LastErrorOpts.Classify.
+//   - <fqName>_timestamp_seconds (gauge): The time of the last recorded
+//     error in seconds since the Unix epoch, or 0 if no error has been
+//     recorded yet. It is not reset by Clear.
+//
+// Alert on the presence of the info metric to find failing subsystems, and use
+// the timestamp to find out how long ago the last error happened. Create
+// instances with NewLastError. A LastError is safe for concurrent use.
+type LastError struct {
+	opts             LastErrorOpts
+	infoDesc, tsDesc *Desc
+
+	mtx             sync.Mutex
+	failing         bool
+	class           string // The class currently exposed.
+	pendingClass    string // The class of the last error, might not be exposed yet.
+	lastTimestamp   time.Time
+	lastClassChange time.Time
+}
+
+// NewLastError creates a new LastError based on the provided LastErrorOpts.
+func NewLastError(opts LastErrorOpts) *LastError {
+	fqName := BuildFQName(opts.Namespace, opts.Subsystem, opts.Name)
+	if opts.Classify == nil {
+		opts.Classify = defaultErrorClass
+	}
+	if opts.now == nil {
+		opts.now = time.Now
+	}
+	return &LastError{
+		opts: opts,
+		infoDesc: NewDesc(
+			fqName+"_info",
+			opts.Help+" Present with value 1 while failing, labeled with the error class.",
+			[]string{"class"}, opts.ConstLabels,
+		),
+		tsDesc: NewDesc(
+			fqName+"_timestamp_seconds",
+			opts.Help+" Time of the last error in seconds since the Unix epoch, 0 if none.",
+			nil, opts.ConstLabels,
+		),
+	}
+}
+
+// defaultErrorClass returns the dynamic type of the innermost error wrapped by
+// err.
+func defaultErrorClass(err error) string {
+	for {
+		unwrapped := errors.Unwrap(err)
+		if unwrapped == nil {
+			return fmt.Sprintf("%T", err)
+		}
+		err = unwrapped
+	}
+}
+
+// Record records err as the last error. It is a no-op if err is nil, so that
+// it can be called unconditionally with the result of an operation. (Call
+// Clear to record a success.)
+func (e *LastError) Record(err error) {
+	if err == nil {
+		return
+	}
+	class := e.opts.Classify(err)
+	now := e.opts.now()
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	e.failing = true
+	e.lastTimestamp = now
+	e.pendingClass = class
+	e.maybeChangeClass(now)
+}
+
+// Clear records that the subsystem has recovered, e.g. after a successful
+// iteration of a worker loop. The info metric is removed until the next error
+// is recorded.
+func (e *LastError) Clear() {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	e.failing = false
+}
+
+// maybeChangeClass exposes the pending class if the MinClassChangeInterval
+// allows it. The caller must have locked e.mtx.
+func (e *LastError) maybeChangeClass(now time.Time) {
+	if e.class == e.pendingClass {
+		return
+	}
+	if e.class != "" && now.Sub(e.lastClassChange) < e.opts.MinClassChangeInterval {
+		return
+	}
+	e.class = e.pendingClass
+	e.lastClassChange = now
+}
+
+// Describe implements Collector.
+func (e *LastError) Describe(ch chan<- *Desc) {
+	ch <- e.infoDesc
+	ch <- e.tsDesc
+}
+
+// Collect implements Collector.
+func (e *LastError) Collect(ch chan<- Metric) {
+	now := e.opts.now()
+
+	e.mtx.Lock()
+	e.maybeChangeClass(now)
+	failing, class, ts := e.failing, e.class, e.lastTimestamp
+	e.mtx.Unlock()
+
+	if failing {
+		ch <- MustNewConstMetric(e.infoDesc, GaugeValue, 1, class)
+	}
+	var tsValue float64
+	if !ts.IsZero() {
+		tsValue = float64(ts.UnixNano()) / 1e9
+	}
+	ch <- MustNewConstMetric(e.tsDesc, GaugeValue, tsValue)
+}
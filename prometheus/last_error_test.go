@@ -0,0 +1,130 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestLastError(t *testing.T) {
+	now := time.Unix(1000, 0)
+	le := NewLastError(LastErrorOpts{
+		Namespace:              "worker",
+		Name:                   "last_error",
+		Help:                   "Last error of the worker.",
+		MinClassChangeInterval: time.Minute,
+		now:                    func() time.Time { return now },
+	})
+
+	collect := func() (class string, failing bool, ts float64) {
+		t.Helper()
+		ch := make(chan Metric, 2)
+		le.Collect(ch)
+		close(ch)
+		for m := range ch {
+			var pb dto.Metric
+			if err := m.Write(&pb); err != nil {
+				t.Fatal(err)
+			}
+			switch m.Desc() {
+			case le.infoDesc:
+				failing = true
+				class = pb.GetLabel()[0].GetValue()
+				if pb.GetGauge().GetValue() != 1 {
+					t.Errorf("got info value %v, want 1", pb.GetGauge().GetValue())
+				}
+			case le.tsDesc:
+				ts = pb.GetGauge().GetValue()
+			default:
+				t.Fatalf("unexpected metric %v", m.Desc())
+			}
+		}
+		return class, failing, ts
+	}
+
+	if _, failing, ts := collect(); failing || ts != 0 {
+		t.Errorf("got failing=%v, timestamp=%v before any error, want false and 0", failing, ts)
+	}
+
+	le.Record(nil)
+	if _, failing, _ := collect(); failing {
+		t.Error("recording a nil error must be a no-op")
+	}
+
+	le.Record(fmt.Errorf("reading config: %w", fs.ErrNotExist))
+	if class, failing, ts := collect(); !failing || class != "*errors.errorString" || ts != 1000 {
+		t.Errorf("got class=%q, failing=%v, timestamp=%v, want *errors.errorString, true, 1000", class, failing, ts)
+	}
+
+	// A different class within the interval only updates the timestamp.
+	now = now.Add(10 * time.Second)
+	le.Record(context.DeadlineExceeded)
+	if class, _, ts := collect(); class != "*errors.errorString" || ts != 1010 {
+		t.Errorf("got class=%q, timestamp=%v, want *errors.errorString and 1010", class, ts)
+	}
+
+	// After the interval, the pending class is exposed upon collection.
+	now = now.Add(time.Minute)
+	if class, _, ts := collect(); class != "context.deadlineExceededError" || ts != 1010 {
+		t.Errorf("got class=%q, timestamp=%v, want context.deadlineExceededError and 1010", class, ts)
+	}
+
+	le.Clear()
+	if _, failing, ts := collect(); failing || ts != 1010 {
+		t.Errorf("got failing=%v, timestamp=%v after Clear, want false and 1010", failing, ts)
+	}
+}
+
+func TestLastErrorClassify(t *testing.T) {
+	errTimeout := errors.New("timeout")
+	le := NewLastError(LastErrorOpts{
+		Name: "last_error",
+		Help: "Last error.",
+		Classify: func(err error) string {
+			if errors.Is(err, errTimeout) {
+				return "timeout"
+			}
+			return "other"
+		},
+	})
+	reg := NewPedanticRegistry()
+	reg.MustRegister(le)
+
+	le.Record(fmt.Errorf("calling backend: %w", errTimeout))
+	le.Record(errors.New("boom")) // Without an interval, the class changes immediately.
+
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mfs) != 2 {
+		t.Fatalf("got %d metric families, want 2", len(mfs))
+	}
+	if got := mfs[0].GetName(); got != "last_error_info" {
+		t.Errorf("got metric family %q, want last_error_info", got)
+	}
+	if got := mfs[0].GetMetric()[0].GetLabel()[0].GetValue(); got != "other" {
+		t.Errorf("got class %q, want other", got)
+	}
+	if got := mfs[1].GetName(); got != "last_error_timestamp_seconds" {
+		t.Errorf("got metric family %q, want last_error_timestamp_seconds", got)
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// lockProfiling is true if the lock profiling mode is enabled.
+var lockProfiling atomic.Bool
+
+func init() {
+	lockProfiling.Store(lockProfilingDefault)
+}
+
+// SetLockProfilingMode enables or disables the lock profiling mode. In this
+// mode, the acquisitions of the internal locks of this package that protect
+// the children of metric vectors, the state of Summaries, and the state of a
+// Registry are instrumented: Each acquisition that has to wait for the lock is
+// counted, and the waiting time is accumulated. Use NewLockContentionCollector
+// to expose the results as metrics. The mode is disabled by default, unless
+// the program is built with the build tag "prometheus_lockprofiling", in which
+// case it is enabled by default.
+//
+// This is meant to find out if the locking within this package is a
+// bottleneck in the hot paths of a program (e.g. because a metric vector is
+// hammered with WithLabelValues calls creating new children), before opening
+// an issue about the performance of this package. While the mode is disabled,
+// the cost of the instrumentation is a single atomic load per lock
+// acquisition. While enabled, an uncontended acquisition is only slightly more
+// expensive, while a contended acquisition additionally has to read the clock
+// twice.
+//
+// The mode is checked for each lock acquisition, so it can be changed at any
+// time.
+func SetLockProfilingMode(enabled bool) {
+	lockProfiling.Store(enabled)
+}
+
+// LockProfilingMode returns whether the lock profiling mode is enabled. See
+// SetLockProfilingMode for details.
+func LockProfilingMode() bool {
+	return lockProfiling.Load()
+}
+
+// lockKind identifies the purpose of an instrumented lock. It is used as the
+// value of the "lock" label of the lock contention metrics.
+type lockKind int
+
+const (
+	lockKindVec lockKind = iota
+	lockKindSummary
+	lockKindRegistry
+	numLockKinds
+)
+
+var lockKindNames = [numLockKinds]string{
+	lockKindVec:      "vec",
+	lockKindSummary:  "summary",
+	lockKindRegistry: "registry",
+}
+
+// lockContention tracks the contended acquisitions of all locks of one kind.
+type lockContention struct {
+	contentions uint64
+	waitNanos   uint64
+}
+
+var lockContentions [numLockKinds]lockContention
+
+func (k lockKind) record(start time.Time) {
+	atomic.AddUint64(&lockContentions[k].contentions, 1)
+	atomic.AddUint64(&lockContentions[k].waitNanos, uint64(time.Since(start)))
+}
+
+// profiledMutex is a sync.Mutex that records contended acquisitions in lock
+// profiling mode. The zero value is an unlocked mutex of kind lockKindVec.
+type profiledMutex struct {
+	sync.Mutex
+	kind lockKind
+}
+
+func (m *profiledMutex) Lock() {
+	if !lockProfiling.Load() {
+		m.Mutex.Lock()
+		return
+	}
+	if m.Mutex.TryLock() {
+		return
+	}
+	start := time.Now()
+	m.Mutex.Lock()
+	m.kind.record(start)
+}
+
+// profiledRWMutex is a sync.RWMutex that records contended acquisitions (of
+// both the write and the read lock) in lock profiling mode. The zero value is
+// an unlocked mutex of kind lockKindVec.
+type profiledRWMutex struct {
+	sync.RWMutex
+	kind lockKind
+}
+
+func (m *profiledRWMutex) Lock() {
+	if !lockProfiling.Load() {
+		m.RWMutex.Lock()
+		return
+	}
+	if m.RWMutex.TryLock() {
+		return
+	}
+	start := time.Now()
+	m.RWMutex.Lock()
+	m.kind.record(start)
+}
+
+func (m *profiledRWMutex) RLock() {
+	if !lockProfiling.Load() {
+		m.RWMutex.RLock()
+		return
+	}
+	if m.RWMutex.TryRLock() {
+		return
+	}
+	start := time.Now()
+	m.RWMutex.RLock()
+	m.kind.record(start)
+}
+
+// NewLockContentionCollector returns a Collector exporting the results of the
+// lock profiling mode (see SetLockProfilingMode) as the following metrics, each
+// partitioned by the "lock" label with the values "vec", "summary", and
+// "registry":
+//   - prometheus_client_lock_contentions_total (counter): the number of lock
+//     acquisitions that had to wait for the lock
+//   - prometheus_client_lock_wait_seconds_total (counter): the total time
+//     spent waiting for the lock
+//
+// The metrics are global for the whole program (i.e. they cover all metric
+// vectors, Summaries, and Registries) and are exported regardless of the
+// mode. They stay at zero as long as the mode has never been enabled.
+func NewLockContentionCollector() Collector {
+	return &lockContentionCollector{
+		contentionsDesc: NewDesc(
+			"prometheus_client_lock_contentions_total",
+			"Total number of contended acquisitions of internal locks of the Prometheus client library (only counted in lock profiling mode).",
+			[]string{"lock"}, nil,
+		),
+		waitDesc: NewDesc(
+			"prometheus_client_lock_wait_seconds_total",
+			"Total time spent waiting for internal locks of the Prometheus client library (only measured in lock profiling mode).",
+			[]string{"lock"}, nil,
+		),
+	}
+}
+
+type lockContentionCollector struct {
+	contentionsDesc, waitDesc *Desc
+}
+
+// Describe implements Collector.
+func (c *lockContentionCollector) Describe(ch chan<- *Desc) {
+	ch <- c.contentionsDesc
+	ch <- c.waitDesc
+}
+
+// Collect implements Collector.
+func (c *lockContentionCollector) Collect(ch chan<- Metric) {
+	for k := lockKind(0); k < numLockKinds; k++ {
+		ch <- MustNewConstMetric(
+			c.contentionsDesc, CounterValue,
+			float64(atomic.LoadUint64(&lockContentions[k].contentions)),
+			lockKindNames[k],
+		)
+		ch <- MustNewConstMetric(
+			c.waitDesc, CounterValue,
+			time.Duration(atomic.LoadUint64(&lockContentions[k].waitNanos)).Seconds(),
+			lockKindNames[k],
+		)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestLockProfiling(t *testing.T) {
+	defer SetLockProfilingMode(LockProfilingMode())
+
+	reg := NewRegistry()
+	names := []string{"a", "b"}
+	contended := func() {
+		reg.mtx.Lock()
+		done := make(chan struct{})
+		go func() {
+			reg.MustRegister(NewCounter(CounterOpts{Name: names[0], Help: "help"}))
+			names = names[1:]
+			close(done)
+		}()
+		time.Sleep(10 * time.Millisecond)
+		reg.mtx.Unlock()
+		<-done
+	}
+
+	SetLockProfilingMode(false)
+	before := atomic.LoadUint64(&lockContentions[lockKindRegistry].contentions)
+	contended()
+	if got := atomic.LoadUint64(&lockContentions[lockKindRegistry].contentions); got != before {
+		t.Errorf("contention recorded with lock profiling disabled: %d before, %d after", before, got)
+	}
+
+	SetLockProfilingMode(true)
+	contended()
+	if got := atomic.LoadUint64(&lockContentions[lockKindRegistry].contentions); got != before+1 {
+		t.Errorf("got %d contentions, want %d", got, before+1)
+	}
+
+	c := NewLockContentionCollector()
+	ch := make(chan Metric, 2*int(numLockKinds))
+	c.Collect(ch)
+	close(ch)
+	var waitSeconds float64
+	for m := range ch {
+		pb := &dto.Metric{}
+		if err := m.Write(pb); err != nil {
+			t.Fatal(err)
+		}
+		if m.Desc() == c.(*lockContentionCollector).waitDesc && pb.GetLabel()[0].GetValue() == "registry" {
+			waitSeconds = pb.GetCounter().GetValue()
+		}
+	}
+	if waitSeconds < 0.005 {
+		t.Errorf("got %v seconds waited for the registry lock, want at least 0.005", waitSeconds)
+	}
+}
@@ -93,6 +93,14 @@ type Opts struct {
 	// https://prometheus.io/docs/instrumenting/writing_exporters/#target-labels-not-static-scraped-labels
 	ConstLabels Labels
 
+	// Metadata is an opaque set of key/value pairs attached to the Desc of
+	// this metric, e.g. the owning team, a runbook URL, or a linked SLO. It
+	// is meant for tooling within an organization (e.g. a metric catalog
+	// built with Registry.Descriptors and Desc.Metadata) and is neither
+	// exposed to Prometheus nor taken into account for the consistency
+	// checks of a Registry. The map is copied on creation of the metric.
+	Metadata map[string]string
+
 	// now is for testing purposes, by default it's time.Now.
 	now func() time.Time
 }
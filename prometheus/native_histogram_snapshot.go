@@ -0,0 +1,119 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// NativeHistogramSpan is a span of consecutive buckets of a native histogram,
+// as in the protobuf exposition format. Offset is the gap to the previous span
+// (or the starting bucket index for the first span), Length the number of
+// consecutive buckets.
+type NativeHistogramSpan struct {
+	Offset int32
+	Length uint32
+}
+
+// NativeHistogramSnapshot is a read-only snapshot of the state of a native
+// histogram. The buckets are represented as spans and deltas, exactly as in
+// the protobuf exposition format: Each delta is the difference between the
+// count of a bucket and the count of the previous bucket (with the first
+// bucket of the first span counting from zero). The index of a bucket is
+// interpreted according to the schema, see UpperBound.
+type NativeHistogramSnapshot struct {
+	Schema        int32
+	ZeroThreshold float64
+	ZeroCount     uint64
+	// Count and Sum of all observations.
+	Count uint64
+	Sum   float64
+
+	PositiveSpans  []NativeHistogramSpan
+	PositiveDeltas []int64
+	NegativeSpans  []NativeHistogramSpan
+	NegativeDeltas []int64
+}
+
+// UpperBound returns the upper bound of the positive bucket with the provided
+// index according to the schema of the snapshot. The lower bound is the upper
+// bound of the bucket with the index one less. For the negative buckets, the
+// bounds are mirrored, i.e. UpperBound returns the absolute value of the lower
+// bound.
+func (s NativeHistogramSnapshot) UpperBound(index int) float64 {
+	return getLe(index, s.Schema)
+}
+
+// NativeHistogramSnapshotter is implemented by the Histograms (and the
+// Observers returned by HistogramVecs) created by this package. Use a type
+// assertion to access it:
+//
+//	if s, ok := h.(prometheus.NativeHistogramSnapshotter); ok {
+//		if snap, ok := s.NativeHistogramSnapshot(); ok {
+//			// Analyze snap.
+//		}
+//	}
+//
+// This is meant for in-process analysis of distributions at a high frequency
+// (e.g. for auto-tuning timeouts), avoiding the cost of creating a dto.Metric
+// with Write, including its classic buckets and exemplars.
+type NativeHistogramSnapshotter interface {
+	// NativeHistogramSnapshot returns a snapshot of the native histogram
+	// state. The returned bool is false (and the snapshot empty) if the
+	// histogram has no native buckets configured. Taking a snapshot
+	// synchronizes with concurrent observations and calls of Write in the
+	// same way as Write does.
+	NativeHistogramSnapshot() (NativeHistogramSnapshot, bool)
+}
+
+// NativeHistogramSnapshot implements NativeHistogramSnapshotter.
+func (h *histogram) NativeHistogramSnapshot() (NativeHistogramSnapshot, bool) {
+	if h.nativeHistogramSchema == math.MinInt32 {
+		return NativeHistogramSnapshot{}, false
+	}
+	if h.resetCoordinator != nil {
+		// Must happen before locking h.mtx, see resetPendingIfDue.
+		h.resetCoordinator.resetPendingIfDue()
+	}
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	if !h.resetDeadline.IsZero() && !h.now().Before(h.resetDeadline) {
+		h.resetLocked()
+	}
+
+	// Switch the hot index as in Write.
+	n := atomic.AddUint64(&h.countAndHotIdx, 1<<63)
+	count := n & ((1 << 63) - 1)
+	hotCounts := h.counts[n>>63]
+	coldCounts := h.counts[(^n)>>63]
+
+	waitForCooldown(count, coldCounts)
+
+	snap := NativeHistogramSnapshot{
+		Schema:        atomic.LoadInt32(&coldCounts.nativeHistogramSchema),
+		ZeroThreshold: math.Float64frombits(atomic.LoadUint64(&coldCounts.nativeHistogramZeroThresholdBits)),
+		ZeroCount:     atomic.LoadUint64(&coldCounts.nativeHistogramZeroBucket),
+		Count:         count,
+		Sum:           math.Float64frombits(atomic.LoadUint64(&coldCounts.sumBits)),
+	}
+	snap.PositiveSpans, snap.PositiveDeltas = makeNativeHistogramSpans(&coldCounts.nativeHistogramBucketsPositive)
+	snap.NegativeSpans, snap.NegativeDeltas = makeNativeHistogramSpans(&coldCounts.nativeHistogramBucketsNegative)
+
+	addAndResetCounts(hotCounts, coldCounts)
+	coldCounts.nativeHistogramBucketsPositive.Range(addAndReset(&hotCounts.nativeHistogramBucketsPositive, &hotCounts.nativeHistogramBucketsNumber))
+	coldCounts.nativeHistogramBucketsNegative.Range(addAndReset(&hotCounts.nativeHistogramBucketsNegative, &hotCounts.nativeHistogramBucketsNumber))
+	return snap, true
+}
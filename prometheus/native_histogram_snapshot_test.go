@@ -0,0 +1,103 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestNativeHistogramSnapshot(t *testing.T) {
+	vec := NewHistogramVec(HistogramOpts{
+		Name:                         "test",
+		Help:                         "helpless",
+		NativeHistogramBucketFactor:  2,
+		NativeHistogramZeroThreshold: 0.5,
+	}, []string{"l"})
+	h := vec.WithLabelValues("a")
+	for _, v := range []float64{0.1, -0.2, 1, 1.5, 3, 100, -3, 1000} {
+		h.Observe(v)
+	}
+
+	s, ok := h.(NativeHistogramSnapshotter)
+	if !ok {
+		t.Fatal("histogram does not implement NativeHistogramSnapshotter")
+	}
+	snap, ok := s.NativeHistogramSnapshot()
+	if !ok {
+		t.Fatal("expected snapshot of native histogram")
+	}
+
+	// The snapshot has to agree with the result of Write.
+	m := &dto.Metric{}
+	if err := h.(Metric).Write(m); err != nil {
+		t.Fatal(err)
+	}
+	his := m.GetHistogram()
+	if snap.Schema != his.GetSchema() {
+		t.Errorf("got schema %d, want %d", snap.Schema, his.GetSchema())
+	}
+	if snap.ZeroThreshold != his.GetZeroThreshold() || snap.ZeroCount != his.GetZeroCount() {
+		t.Errorf("got zero bucket %v/%d, want %v/%d", snap.ZeroThreshold, snap.ZeroCount, his.GetZeroThreshold(), his.GetZeroCount())
+	}
+	if snap.Count != 8 || snap.Count != his.GetSampleCount() {
+		t.Errorf("got count %d, want 8", snap.Count)
+	}
+	if snap.Sum != his.GetSampleSum() {
+		t.Errorf("got sum %v, want %v", snap.Sum, his.GetSampleSum())
+	}
+	checkSpans := func(what string, spans []NativeHistogramSpan, deltas []int64, wantSpans []*dto.BucketSpan, wantDeltas []int64) {
+		t.Helper()
+		if len(spans) != len(wantSpans) {
+			t.Fatalf("%s: got %d spans, want %d", what, len(spans), len(wantSpans))
+		}
+		for i, span := range spans {
+			if span.Offset != wantSpans[i].GetOffset() || span.Length != wantSpans[i].GetLength() {
+				t.Errorf("%s: got span %v, want %v", what, span, wantSpans[i])
+			}
+		}
+		if len(deltas) != len(wantDeltas) {
+			t.Fatalf("%s: got deltas %v, want %v", what, deltas, wantDeltas)
+		}
+		for i := range deltas {
+			if deltas[i] != wantDeltas[i] {
+				t.Errorf("%s: got deltas %v, want %v", what, deltas, wantDeltas)
+				break
+			}
+		}
+	}
+	checkSpans("positive", snap.PositiveSpans, snap.PositiveDeltas, his.GetPositiveSpan(), his.GetPositiveDelta())
+	checkSpans("negative", snap.NegativeSpans, snap.NegativeDeltas, his.GetNegativeSpan(), his.GetNegativeDelta())
+
+	// Schema 0: The bucket with index 2 is (2, 4].
+	if got := snap.UpperBound(2); got != 4 {
+		t.Errorf("got upper bound %v for index 2, want 4", got)
+	}
+
+	// Taking a snapshot doesn't lose any observations.
+	h.Observe(1)
+	snap, _ = s.NativeHistogramSnapshot()
+	if snap.Count != 9 {
+		t.Errorf("got count %d after another observation, want 9", snap.Count)
+	}
+}
+
+func TestNativeHistogramSnapshotClassic(t *testing.T) {
+	h := NewHistogram(HistogramOpts{Name: "test", Help: "helpless"})
+	h.Observe(1)
+	if _, ok := h.(NativeHistogramSnapshotter).NativeHistogramSnapshot(); ok {
+		t.Error("expected no snapshot for a classic histogram")
+	}
+}
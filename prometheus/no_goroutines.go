@@ -0,0 +1,63 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import "sync/atomic"
+
+// noGoroutines is true if the no-goroutines mode is enabled.
+var noGoroutines atomic.Bool
+
+func init() {
+	noGoroutines.Store(noGoroutinesDefault)
+}
+
+// SetNoGoroutinesMode enables or disables the no-goroutines mode. In this mode,
+// the metric implementations of this package never spawn goroutines or timers
+// that outlive the method call that caused them. This is useful for
+// environments where deterministic shutdown or snapshotting of the process is
+// required, e.g. in serverless or WebAssembly runtimes. The mode is disabled by
+// default, unless the program is built with the build tag
+// "prometheus_nogoroutines", in which case it is enabled by default.
+//
+// The mode affects the following behavior:
+//   - Summaries with objectives flush their observation buffers synchronously
+//     within the Observe call that fills up the buffer (rather than
+//     asynchronously in a separate goroutine). This makes those Observe calls
+//     slower.
+//   - Native histograms do not use timers to schedule the resets described
+//     for NativeHistogramMinResetDuration in HistogramOpts (including the
+//     coordinated resets across a HistogramVec). Instead, a due reset is
+//     performed lazily by the next call of the Write method, i.e. typically
+//     during the next scrape.
+//   - The Go collector for Go versions before 1.17 reads the memory
+//     statistics synchronously (rather than in a goroutine that might
+//     outlive the Collect call if it takes longer than a second), which
+//     might make the Collect call slower.
+//
+// Goroutines that are started and finished within a single method call (as
+// used by Registry.Gather to collect from multiple Collectors concurrently)
+// are not affected. Neither are helpers that are explicitly meant to run in
+// the background, like the Bridge in the graphite package.
+//
+// The mode is checked when the affected behavior happens, so it can be changed
+// at any time. Usually, it should be set once at program start.
+func SetNoGoroutinesMode(enabled bool) {
+	noGoroutines.Store(enabled)
+}
+
+// NoGoroutinesMode returns whether the no-goroutines mode is enabled. See
+// SetNoGoroutinesMode for details.
+func NoGoroutinesMode() bool {
+	return noGoroutines.Load()
+}
@@ -0,0 +1,143 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/common/model"
+)
+
+// PrebindConfig lists, by fully-qualified metric name, the label sets whose
+// metrics a VecPrebinder keeps pre-created in the respective vectors.
+type PrebindConfig map[string][]Labels
+
+// LoadPrebindConfig reads a JSON-encoded PrebindConfig from the provided file,
+// e.g. {"http_requests_total": [{"code": "200"}, {"code": "500"}]}.
+func LoadPrebindConfig(filename string) (PrebindConfig, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var cfg PrebindConfig
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing prebind config %s: %w", filename, err)
+	}
+	return cfg, nil
+}
+
+// VecPrebinder keeps the metrics of vectors pre-created (“pre-bound”) for the
+// label sets listed in a PrebindConfig. Pre-created metrics are exposed with
+// their initial value (usually 0) before they are first used, so that the
+// presence of their series is stable, e.g. for dashboards driven by a fixed
+// enumeration of label values, and absent() alerts don't fire for label sets
+// that have simply not been used yet.
+//
+// Apply a new PrebindConfig upon each reload of the configuration it is
+// derived from. VecPrebinder is safe for concurrent use.
+type VecPrebinder struct {
+	deleteRemoved bool
+
+	mtx   sync.Mutex
+	vecs  map[string]*MetricVec
+	bound map[string]map[string]Labels // By metric name and labels key.
+}
+
+// NewVecPrebinder returns a VecPrebinder without any vectors. If
+// deleteRemoved is true, Apply deletes the metrics of label sets it has
+// pre-created before but which are not listed in the applied PrebindConfig
+// anymore. Metrics created by other means are never deleted.
+func NewVecPrebinder(deleteRemoved bool) *VecPrebinder {
+	return &VecPrebinder{
+		deleteRemoved: deleteRemoved,
+		vecs:          map[string]*MetricVec{},
+		bound:         map[string]map[string]Labels{},
+	}
+}
+
+// Add adds vectors to the VecPrebinder, identified by their fully-qualified
+// name. For the vectors of this package, provide the embedded MetricVec, e.g.
+// myCounterVec.MetricVec. Adding a vector with the same name as a previously
+// added one replaces the latter. The vectors are only pre-bound upon the next
+// call of Apply.
+func (p *VecPrebinder) Add(vecs ...*MetricVec) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	for _, v := range vecs {
+		p.vecs[v.desc.fqName] = v
+	}
+}
+
+// Apply creates the metrics for all label sets listed in cfg in the added
+// vectors and, if the VecPrebinder deletes removed label sets, deletes the
+// metrics of label sets pre-created by a previous call but not listed in cfg
+// anymore. Values of existing metrics are not touched.
+//
+// Invalid label sets and metric names without an added vector are skipped, and
+// the errors are returned (as a MultiError if there are several). All other
+// label sets are still applied.
+func (p *VecPrebinder) Apply(cfg PrebindConfig) error {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	var errs MultiError
+	names := make([]string, 0, len(cfg))
+	for name := range cfg {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := p.vecs[name]; !ok {
+			errs.Append(fmt.Errorf("prebind config lists unknown metric %q", name))
+		}
+	}
+
+	for name, vec := range p.vecs {
+		previous := p.bound[name]
+		current := make(map[string]Labels, len(cfg[name]))
+		for _, labels := range cfg[name] {
+			if _, err := vec.GetMetricWith(labels); err != nil {
+				errs.Append(fmt.Errorf("prebinding %s with labels %v: %w", name, labels, err))
+				continue
+			}
+			current[prebindKey(labels)] = labels
+		}
+		if p.deleteRemoved {
+			for key, labels := range previous {
+				if _, ok := current[key]; !ok {
+					vec.Delete(labels)
+				}
+			}
+		}
+		p.bound[name] = current
+	}
+	return errs.MaybeUnwrap()
+}
+
+// prebindKey returns a string identifying labels independently of the
+// iteration order of the map.
+func prebindKey(labels Labels) string {
+	pairs := make([]string, 0, len(labels))
+	for name, value := range labels {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, string([]byte{model.SeparatorByte}))
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestVecPrebinder(t *testing.T) {
+	reqs := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests_total", Help: "Requests."}, []string{"code"})
+	tenants := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "tenant_up", Help: "Tenant up."}, []string{"tenant"})
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(reqs, tenants)
+
+	p := prometheus.NewVecPrebinder(true)
+	p.Add(reqs.MetricVec, tenants.MetricVec)
+
+	filename := filepath.Join(t.TempDir(), "prebind.json")
+	load := func(content string) prometheus.PrebindConfig {
+		t.Helper()
+		if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := prometheus.LoadPrebindConfig(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cfg
+	}
+
+	if err := p.Apply(load(`{
+		"requests_total": [{"code": "200"}, {"code": "500"}],
+		"tenant_up": [{"tenant": "a"}, {"tenant": "b"}]
+	}`)); err != nil {
+		t.Fatal(err)
+	}
+	reqs.WithLabelValues("200").Inc()
+	reqs.WithLabelValues("404").Inc() // Not pre-bound.
+
+	// Reload: 500 and tenant b are removed, tenant c is added, an invalid
+	// label set and an unknown metric are reported.
+	err := p.Apply(load(`{
+		"requests_total": [{"code": "200"}],
+		"tenant_up": [{"tenant": "a"}, {"tenant": "c"}, {"zone": "x"}],
+		"unknown": [{"a": "b"}]
+	}`))
+	if err == nil || !strings.Contains(err.Error(), `unknown metric "unknown"`) || !strings.Contains(err.Error(), "tenant_up") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	want := `
+# HELP requests_total Requests.
+# TYPE requests_total counter
+requests_total{code="200"} 1
+requests_total{code="404"} 1
+# HELP tenant_up Tenant up.
+# TYPE tenant_up gauge
+tenant_up{tenant="a"} 0
+tenant_up{tenant="c"} 0
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLoadPrebindConfigMalformed(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "prebind.json")
+	if err := os.WriteFile(filename, []byte(`{"x": [`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := prometheus.LoadPrebindConfig(filename); err == nil || !strings.Contains(err.Error(), "parsing prebind config") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -19,6 +19,8 @@ import (
 	"os"
 	"strconv"
 	"strings"
+
+	"github.com/prometheus/client_golang/prometheus/internal"
 )
 
 type processCollector struct {
@@ -60,6 +62,8 @@ type ProcessCollectorOpts struct {
 //
 // Deprecated: Use collectors.NewProcessCollector instead.
 func NewProcessCollector(opts ProcessCollectorOpts) Collector {
+	internal.ReportDeprecated("prometheus.NewProcessCollector", "collectors.NewProcessCollector")
+
 	ns := ""
 	if len(opts.Namespace) > 0 {
 		ns = opts.Namespace + "_"
@@ -11,8 +11,8 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-//go:build wasip1 || js
-// +build wasip1 js
+//go:build wasip1 || js || (tinygo && !darwin && !windows)
+// +build wasip1 js tinygo,!darwin,!windows
 
 package prometheus
 
@@ -24,7 +24,7 @@ func (c *processCollector) processCollect(ch chan<- Metric) {
 	c.errorCollectFn(ch)
 }
 
-// describe returns all descriptions of the collector for wasip1 and js.
+// describe returns all descriptions of the collector for wasip1, js, and tinygo.
 // Ensure that this list of descriptors is kept in sync with the metrics collected
 // in the processCollect method. Any changes to the metrics in processCollect
 // (such as adding or removing metrics) should be reflected in this list of descriptors.
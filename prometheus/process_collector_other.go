@@ -11,8 +11,8 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-//go:build !windows && !js && !wasip1 && !darwin
-// +build !windows,!js,!wasip1,!darwin
+//go:build !windows && !js && !wasip1 && !darwin && !tinygo
+// +build !windows,!js,!wasip1,!darwin,!tinygo
 
 package prometheus
 
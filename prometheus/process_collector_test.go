@@ -11,8 +11,8 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-//go:build linux
-// +build linux
+//go:build linux && !tinygo
+// +build linux,!tinygo
 
 package prometheus
 
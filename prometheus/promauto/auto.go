@@ -342,11 +342,23 @@ func (f Factory) NewSummaryVec(opts prometheus.SummaryOpts, labelNames []string)
 	return s
 }
 
+// histogramDefaults returns the HistogramDefaults of the Factory's Registerer
+// (see prometheus.Registry.SetHistogramDefaults), if it has any.
+func (f Factory) histogramDefaults() prometheus.HistogramDefaults {
+	if hd, ok := f.r.(interface {
+		HistogramDefaults() prometheus.HistogramDefaults
+	}); ok {
+		return hd.HistogramDefaults()
+	}
+	return prometheus.HistogramDefaults{}
+}
+
 // NewHistogram works like the function of the same name in the prometheus
 // package but it automatically registers the Histogram with the Factory's
-// Registerer.
+// Registerer. The HistogramDefaults of the Registerer, if any, are applied to
+// the provided HistogramOpts.
 func (f Factory) NewHistogram(opts prometheus.HistogramOpts) prometheus.Histogram {
-	h := prometheus.NewHistogram(opts)
+	h := prometheus.NewHistogram(f.histogramDefaults().Apply(opts))
 	if f.r != nil {
 		f.r.MustRegister(h)
 	}
@@ -355,9 +367,10 @@ func (f Factory) NewHistogram(opts prometheus.HistogramOpts) prometheus.Histogra
 
 // NewHistogramVec works like the function of the same name in the prometheus
 // package but it automatically registers the HistogramVec with the Factory's
-// Registerer.
+// Registerer. The HistogramDefaults of the Registerer, if any, are applied to
+// the provided HistogramOpts.
 func (f Factory) NewHistogramVec(opts prometheus.HistogramOpts, labelNames []string) *prometheus.HistogramVec {
-	h := prometheus.NewHistogramVec(opts, labelNames)
+	h := prometheus.NewHistogramVec(f.histogramDefaults().Apply(opts), labelNames)
 	if f.r != nil {
 		f.r.MustRegister(h)
 	}
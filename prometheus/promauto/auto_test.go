@@ -23,3 +23,30 @@ func TestNil(t *testing.T) {
 	// A nil registerer should be treated as a no-op by promauto.
 	With(nil).NewCounter(prometheus.CounterOpts{Name: "test"}).Inc()
 }
+
+func TestHistogramDefaults(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.SetHistogramDefaults(prometheus.HistogramDefaults{
+		Buckets:                     []float64{1, 2},
+		NativeHistogramBucketFactor: 1.1,
+	})
+	f := With(prometheus.WrapRegistererWith(prometheus.Labels{"a": "b"}, reg))
+
+	f.NewHistogram(prometheus.HistogramOpts{Name: "defaulted", Help: "help"}).Observe(1)
+	f.NewHistogramVec(prometheus.HistogramOpts{Name: "explicit", Help: "help", Buckets: []float64{5}}, []string{"l"}).WithLabelValues("x").Observe(1)
+
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mf := range mfs {
+		h := mf.GetMetric()[0].GetHistogram()
+		wantBuckets := map[string]int{"defaulted": 2, "explicit": 1}[mf.GetName()]
+		if got := len(h.GetBucket()); got != wantBuckets {
+			t.Errorf("%s: got %d buckets, want %d", mf.GetName(), got, wantBuckets)
+		}
+		if got, want := h.GetSchema(), int32(3); got != want {
+			t.Errorf("%s: got schema %d, want %d", mf.GetName(), got, want)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package promhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Kinds of drift between an exposition and a Catalog, used as CatalogDrift.Kind
+// and as values of the "kind" label of the promhttp_metric_handler_catalog_drift
+// metric.
+const (
+	CatalogUnexpectedMetric = "unexpected_metric"
+	CatalogMissingMetric    = "missing_metric"
+	CatalogTypeMismatch     = "type_mismatch"
+	CatalogLabelMismatch    = "label_mismatch"
+)
+
+// maxCatalogExamples is the maximum number of differences listed in the log
+// line of a single scrape differing from the catalog.
+const maxCatalogExamples = 5
+
+// Catalog declares the metric families an exposition is expected to contain.
+// Use it with HandlerOpts.Catalog to validate the exposition of each scrape, or
+// call Diff directly, e.g. in a test validating the metrics of a program in
+// CI.
+type Catalog struct {
+	Metrics []CatalogMetric `json:"metrics"`
+}
+
+// CatalogMetric declares a metric family in a Catalog.
+type CatalogMetric struct {
+	// Name is the name of the metric family.
+	Name string `json:"name"`
+	// Type is the type of the metric family in lower case as in the text
+	// format, i.e. one of counter, gauge, summary, untyped, histogram, and
+	// gauge_histogram. If empty, any type is accepted.
+	Type string `json:"type,omitempty"`
+	// Labels are the names of the labels (constant and variable) of each
+	// metric in the family, in any order. Labels specific to the type
+	// (like "le" and "quantile") are not included.
+	Labels []string `json:"labels,omitempty"`
+	// Optional metric families are not reported as missing, e.g. vectors
+	// that might not have any metrics yet.
+	Optional bool `json:"optional,omitempty"`
+}
+
+// CatalogDrift is a difference between an exposition and a Catalog.
+type CatalogDrift struct {
+	// Kind is one of CatalogUnexpectedMetric, CatalogMissingMetric,
+	// CatalogTypeMismatch, and CatalogLabelMismatch.
+	Kind string
+	// Metric is the name of the metric family.
+	Metric string
+	// Detail describes a mismatch in a human-readable way. It is empty for
+	// unexpected and missing metric families.
+	Detail string
+}
+
+func (d CatalogDrift) String() string {
+	if d.Detail == "" {
+		return fmt.Sprintf("%s %s", d.Kind, d.Metric)
+	}
+	return fmt.Sprintf("%s %s: %s", d.Kind, d.Metric, d.Detail)
+}
+
+// LoadCatalog reads a JSON-encoded Catalog from the provided file and
+// validates it.
+func LoadCatalog(filename string) (*Catalog, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var c Catalog
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("parsing catalog %s: %w", filename, err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid catalog %s: %w", filename, err)
+	}
+	return &c, nil
+}
+
+// Validate returns an error if the Catalog declares a metric family without
+// name or more than once, or with an unknown type.
+func (c *Catalog) Validate() error {
+	seen := make(map[string]struct{}, len(c.Metrics))
+	for _, m := range c.Metrics {
+		if m.Name == "" {
+			return fmt.Errorf("metric without name")
+		}
+		if _, ok := seen[m.Name]; ok {
+			return fmt.Errorf("metric %q declared more than once", m.Name)
+		}
+		seen[m.Name] = struct{}{}
+		if _, ok := dto.MetricType_value[strings.ToUpper(m.Type)]; m.Type != "" && !ok {
+			return fmt.Errorf("metric %q has unknown type %q", m.Name, m.Type)
+		}
+	}
+	return nil
+}
+
+// Diff compares the provided metric families (as returned by a Gatherer) with
+// the Catalog and returns the drift, sorted by metric family name and kind. A
+// label mismatch is reported once per metric family, for the first metric
+// with a different set of label names.
+func (c *Catalog) Diff(mfs []*dto.MetricFamily) []CatalogDrift {
+	declared := make(map[string]CatalogMetric, len(c.Metrics))
+	for _, m := range c.Metrics {
+		declared[m.Name] = m
+	}
+
+	var drift []CatalogDrift
+	for _, mf := range mfs {
+		name := mf.GetName()
+		cm, ok := declared[name]
+		if !ok {
+			drift = append(drift, CatalogDrift{Kind: CatalogUnexpectedMetric, Metric: name})
+			continue
+		}
+		delete(declared, name)
+		if got := strings.ToLower(mf.GetType().String()); cm.Type != "" && got != cm.Type {
+			drift = append(drift, CatalogDrift{
+				Kind:   CatalogTypeMismatch,
+				Metric: name,
+				Detail: fmt.Sprintf("got type %s, want %s", got, cm.Type),
+			})
+		}
+		want := sortedCopy(cm.Labels)
+		for _, m := range mf.GetMetric() {
+			got := make([]string, 0, len(m.GetLabel()))
+			for _, lp := range m.GetLabel() {
+				got = append(got, lp.GetName())
+			}
+			sort.Strings(got)
+			if !equalStrings(got, want) {
+				drift = append(drift, CatalogDrift{
+					Kind:   CatalogLabelMismatch,
+					Metric: name,
+					Detail: fmt.Sprintf("got labels [%s], want [%s]", strings.Join(got, ", "), strings.Join(want, ", ")),
+				})
+				break
+			}
+		}
+	}
+	for name, cm := range declared {
+		if !cm.Optional {
+			drift = append(drift, CatalogDrift{Kind: CatalogMissingMetric, Metric: name})
+		}
+	}
+
+	sort.Slice(drift, func(i, j int) bool {
+		if drift[i].Metric != drift[j].Metric {
+			return drift[i].Metric < drift[j].Metric
+		}
+		return drift[i].Kind < drift[j].Kind
+	})
+	return drift
+}
+
+func sortedCopy(s []string) []string {
+	c := append([]string(nil), s...)
+	sort.Strings(c)
+	return c
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// catalogValidator validates the exposition of each scrape against a Catalog
+// for HandlerOpts.Catalog.
+type catalogValidator struct {
+	catalog  *Catalog
+	drift    *prometheus.GaugeVec
+	errorLog Logger
+}
+
+func newCatalogValidator(catalog *Catalog, reg prometheus.Registerer, errorLog Logger) *catalogValidator {
+	v := &catalogValidator{
+		catalog:  catalog,
+		errorLog: errorLog,
+		drift: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "promhttp_metric_handler_catalog_drift",
+				Help: "Number of differences between the exposition of the last scrape and the metric catalog, by kind.",
+			},
+			[]string{"kind"},
+		),
+	}
+	if reg != nil {
+		// Initialize all possibilities that can occur below.
+		v.drift.WithLabelValues(CatalogUnexpectedMetric)
+		v.drift.WithLabelValues(CatalogMissingMetric)
+		v.drift.WithLabelValues(CatalogTypeMismatch)
+		v.drift.WithLabelValues(CatalogLabelMismatch)
+		v.drift = registerOrExisting(reg, v.drift).(*prometheus.GaugeVec)
+	}
+	return v
+}
+
+// validate diffs mfs against the catalog, sets the drift metric accordingly,
+// and logs (some of) the drift.
+func (v *catalogValidator) validate(mfs []*dto.MetricFamily) {
+	drift := v.catalog.Diff(mfs)
+	counts := map[string]int{
+		CatalogUnexpectedMetric: 0,
+		CatalogMissingMetric:    0,
+		CatalogTypeMismatch:     0,
+		CatalogLabelMismatch:    0,
+	}
+	for _, d := range drift {
+		counts[d.Kind]++
+	}
+	for kind, n := range counts {
+		v.drift.WithLabelValues(kind).Set(float64(n))
+	}
+	if v.errorLog == nil || len(drift) == 0 {
+		return
+	}
+	examples := make([]string, 0, maxCatalogExamples)
+	for _, d := range drift {
+		if len(examples) == maxCatalogExamples {
+			break
+		}
+		examples = append(examples, d.String())
+	}
+	v.errorLog.Println(fmt.Sprintf(
+		"exposition differs from metric catalog in %d places, e.g.: %s",
+		len(drift), strings.Join(examples, "; "),
+	))
+}
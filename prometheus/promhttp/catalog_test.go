@@ -0,0 +1,129 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package promhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestLoadCatalog(t *testing.T) {
+	dir := t.TempDir()
+	for name, tc := range map[string]struct {
+		content string
+		wantErr string
+	}{
+		"valid":     {content: `{"metrics": [{"name": "up", "type": "gauge"}, {"name": "x", "labels": ["a"], "optional": true}]}`},
+		"malformed": {content: `{"metrics": [`, wantErr: "parsing catalog"},
+		"no name":   {content: `{"metrics": [{"type": "gauge"}]}`, wantErr: "metric without name"},
+		"duplicate": {content: `{"metrics": [{"name": "up"}, {"name": "up"}]}`, wantErr: "declared more than once"},
+		"bad type":  {content: `{"metrics": [{"name": "up", "type": "meter"}]}`, wantErr: "unknown type"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			filename := filepath.Join(dir, strings.ReplaceAll(name, " ", "_")+".json")
+			if err := os.WriteFile(filename, []byte(tc.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			c, err := LoadCatalog(filename)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(c.Metrics) != 2 || !c.Metrics[1].Optional {
+					t.Errorf("unexpected catalog: %+v", c)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("got error %v, want error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func newCatalogTestRegistry() *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		prometheus.NewGauge(prometheus.GaugeOpts{Name: "up", Help: "Up."}),
+		prometheus.NewCounter(prometheus.CounterOpts{Name: "requests", Help: "Requests.", ConstLabels: prometheus.Labels{"env": "prod"}}),
+		prometheus.NewGauge(prometheus.GaugeOpts{Name: "surprise", Help: "Surprise."}),
+	)
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "errors_total", Help: "Errors."}, []string{"code", "path"})
+	vec.WithLabelValues("500", "/").Inc()
+	reg.MustRegister(vec)
+	return reg
+}
+
+var catalogTestCatalog = &Catalog{Metrics: []CatalogMetric{
+	{Name: "up", Type: "gauge"},
+	{Name: "requests", Type: "gauge", Labels: []string{"env"}},
+	{Name: "errors_total", Type: "counter", Labels: []string{"code"}},
+	{Name: "gone", Type: "counter"},
+	{Name: "maybe", Optional: true},
+}}
+
+func TestCatalogDiff(t *testing.T) {
+	mfs, err := newCatalogTestRegistry().Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := catalogTestCatalog.Diff(mfs)
+	want := []CatalogDrift{
+		{Kind: CatalogLabelMismatch, Metric: "errors_total", Detail: "got labels [code, path], want [code]"},
+		{Kind: CatalogMissingMetric, Metric: "gone"},
+		{Kind: CatalogTypeMismatch, Metric: "requests", Detail: "got type counter, want gauge"},
+		{Kind: CatalogUnexpectedMetric, Metric: "surprise"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got drift %v, want %v", got, want)
+	}
+}
+
+func TestHandlerCatalog(t *testing.T) {
+	self := prometheus.NewRegistry()
+	var log logRecorder
+	handler := HandlerFor(newCatalogTestRegistry(), HandlerOpts{
+		Catalog:  catalogTestCatalog,
+		Registry: self,
+		ErrorLog: &log,
+	})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d", rec.Code)
+	}
+	if len(log) != 1 || !strings.Contains(log[0], "differs from metric catalog in 4 places") {
+		t.Errorf("unexpected log lines: %q", log)
+	}
+
+	want := `
+# HELP promhttp_metric_handler_catalog_drift Number of differences between the exposition of the last scrape and the metric catalog, by kind.
+# TYPE promhttp_metric_handler_catalog_drift gauge
+promhttp_metric_handler_catalog_drift{kind="label_mismatch"} 1
+promhttp_metric_handler_catalog_drift{kind="missing_metric"} 1
+promhttp_metric_handler_catalog_drift{kind="type_mismatch"} 1
+promhttp_metric_handler_catalog_drift{kind="unexpected_metric"} 1
+`
+	if err := testutil.GatherAndCompare(self, strings.NewReader(want), "promhttp_metric_handler_catalog_drift"); err != nil {
+		t.Error(err)
+	}
+}
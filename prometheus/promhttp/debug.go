@@ -0,0 +1,176 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package promhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DebugHandlerOpts specifies options for DebugHandlerFor. The zero value of
+// DebugHandlerOpts is a reasonable default.
+type DebugHandlerOpts struct {
+	// The number of concurrent HTTP requests is limited to
+	// MaxRequestsInFlight. Additional requests are responded to with 503
+	// Service Unavailable. If MaxRequestsInFlight is 0 or negative, no
+	// limit is applied.
+	MaxRequestsInFlight int
+}
+
+// DebugInfo is the information served by the handler returned by
+// DebugHandlerFor, encoded as JSON.
+type DebugInfo struct {
+	// Collectors contains an entry for each registered Collector, sorted
+	// by name.
+	Collectors []CollectorDebugInfo `json:"collectors"`
+	// Descriptors, Series, and Errors are the totals over all Collectors.
+	Descriptors int `json:"descriptors"`
+	Series      int `json:"series"`
+	Errors      int `json:"errors"`
+}
+
+// CollectorDebugInfo is the debug information about a single Collector. All
+// fields but Name, Unchecked, and Descriptors are taken from the last
+// collection of the Collector by the Registry, see
+// prometheus.RegisteredCollector.LastGather. They are left empty if there has
+// been no such collection.
+type CollectorDebugInfo struct {
+	// Name identifies the Collector, see prometheus.RegisteredCollector.
+	Name string `json:"name"`
+	// Unchecked is true for unchecked Collectors.
+	Unchecked bool `json:"unchecked"`
+	// Descriptors is the number of distinct Descs the Collector has
+	// described upon registration.
+	Descriptors int `json:"descriptors"`
+	// LastGather is the time the last collection has started.
+	LastGather *time.Time `json:"last_gather,omitempty"`
+	// CollectDurationSeconds is the time it took to collect the Collector
+	// and to process the collected metrics.
+	CollectDurationSeconds float64 `json:"collect_duration_seconds"`
+	// Series is the number of collected series (i.e. metrics, not
+	// counting the individual buckets of histograms and summaries).
+	Series int `json:"series"`
+	// MetricFamilies lists the collected metric families with their
+	// number of series, sorted by decreasing number of series.
+	MetricFamilies []MetricFamilyDebugInfo `json:"metric_families"`
+	// Error is the error that occurred during collection, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// MetricFamilyDebugInfo is the debug information about a metric family.
+type MetricFamilyDebugInfo struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Series int    `json:"series"`
+}
+
+// CollectorLister lists the Collectors registered with a Registry. It is
+// implemented by *prometheus.Registry and can be implemented by types wrapping
+// a Registry to use them with DebugHandlerFor.
+type CollectorLister interface {
+	RegisteredCollectors() []prometheus.RegisteredCollector
+}
+
+// DebugHandlerFor returns an http.Handler serving a JSON-encoded DebugInfo
+// about the Registry listed by the provided CollectorLister. It lists the registered Collectors, each with
+// the number of its Descs and, as recorded during the last Gather call of the
+// Registry, the duration of its collection, its errors, and the cardinality of
+// the metric families it has collected. This gives a quick live view of a
+// Registry (e.g. to find the Collector responsible for a cardinality explosion
+// or a slow scrape) without parsing the exposition.
+//
+// The handler does not collect any Collector itself. The Registry only records
+// the collections if provenance is enabled (see
+// prometheus.Registry.SetCollectorProvenance), so without it, only the names
+// and Desc counts of the Collectors are served. Metric families collected by
+// different Collectors are listed separately, and inconsistencies between
+// Collectors are not detected. The handler is meant for debugging purposes and
+// should not be exposed publicly.
+func DebugHandlerFor(reg CollectorLister, opts DebugHandlerOpts) http.Handler {
+	var inFlightSem chan struct{}
+	if opts.MaxRequestsInFlight > 0 {
+		inFlightSem = make(chan struct{}, opts.MaxRequestsInFlight)
+	}
+
+	return http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {
+		if inFlightSem != nil {
+			select {
+			case inFlightSem <- struct{}{}: // All good, carry on.
+				defer func() { <-inFlightSem }()
+			default:
+				http.Error(rsp, fmt.Sprintf(
+					"Limit of concurrent requests reached (%d), try again later.", opts.MaxRequestsInFlight,
+				), http.StatusServiceUnavailable)
+				return
+			}
+		}
+
+		info := DebugInfo{Collectors: []CollectorDebugInfo{}}
+		for _, rc := range reg.RegisteredCollectors() {
+			ci := debugCollector(rc)
+			info.Collectors = append(info.Collectors, ci)
+			info.Descriptors += ci.Descriptors
+			info.Series += ci.Series
+			if ci.Error != "" {
+				info.Errors++
+			}
+		}
+
+		rsp.Header().Set(contentTypeHeader, "application/json")
+		enc := json.NewEncoder(rsp)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(info); err != nil {
+			httpError(rsp, err)
+		}
+	})
+}
+
+// debugCollector returns the CollectorDebugInfo of rc, based on what has been
+// recorded at its registration and its last gather stats.
+func debugCollector(rc prometheus.RegisteredCollector) CollectorDebugInfo {
+	ci := CollectorDebugInfo{
+		Name:           rc.Name,
+		Unchecked:      rc.Unchecked,
+		Descriptors:    rc.Descriptors,
+		MetricFamilies: []MetricFamilyDebugInfo{},
+	}
+
+	stats := rc.LastGather
+	if stats == nil {
+		return ci
+	}
+	start := stats.Start
+	ci.LastGather = &start
+	ci.CollectDurationSeconds = stats.Duration.Seconds()
+	if stats.Err != nil {
+		ci.Error = stats.Err.Error()
+	}
+	for _, mf := range stats.MetricFamilies {
+		ci.Series += mf.Metrics
+		ci.MetricFamilies = append(ci.MetricFamilies, MetricFamilyDebugInfo{
+			Name:   mf.Name,
+			Type:   mf.Type.String(),
+			Series: mf.Metrics,
+		})
+	}
+	sort.SliceStable(ci.MetricFamilies, func(i, j int) bool {
+		return ci.MetricFamilies[i].Series > ci.MetricFamilies[j].Series
+	})
+	return ci
+}
@@ -0,0 +1,111 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package promhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestDebugHandler(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.SetCollectorProvenance(prometheus.CollectorProvenanceOpts{Enabled: true})
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests_total", Help: "Requests."}, []string{"code"})
+	vec.WithLabelValues("200").Inc()
+	vec.WithLabelValues("500").Inc()
+	vec.WithLabelValues("404").Inc()
+	reg.MustRegister(
+		vec,
+		prometheus.NewGauge(prometheus.GaugeOpts{Name: "up", Help: "Up."}),
+		errorCollector{},
+	)
+	handler := DebugHandlerFor(reg, DebugHandlerOpts{})
+
+	debugInfo := func() DebugInfo {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/metrics", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d: %s", rec.Code, rec.Body)
+		}
+		if got := rec.Header().Get(contentTypeHeader); got != "application/json" {
+			t.Errorf("got Content-Type %q", got)
+		}
+		var info DebugInfo
+		if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+			t.Fatal(err)
+		}
+		if len(info.Collectors) != 3 {
+			t.Fatalf("got %d collectors, want 3", len(info.Collectors))
+		}
+		return info
+	}
+
+	// Nothing has been gathered yet, and the handler must not collect.
+	info := debugInfo()
+	if info.Descriptors != 3 || info.Series != 0 || info.Errors != 0 {
+		t.Errorf("got totals %d/%d/%d before gathering, want 3 descriptors, 0 series, 0 errors", info.Descriptors, info.Series, info.Errors)
+	}
+	for _, ci := range info.Collectors {
+		if ci.LastGather != nil {
+			t.Errorf("unexpected last gather for %s before gathering", ci.Name)
+		}
+	}
+
+	if _, err := reg.Gather(); err == nil {
+		t.Fatal("expected gather error")
+	}
+	info = debugInfo()
+	if info.Descriptors != 3 || info.Series != 4 || info.Errors != 1 {
+		t.Errorf("got totals %d/%d/%d, want 3 descriptors, 4 series, 1 error", info.Descriptors, info.Series, info.Errors)
+	}
+	byName := map[string]CollectorDebugInfo{}
+	for _, ci := range info.Collectors {
+		byName[ci.Name] = ci
+	}
+	vecInfo := byName["*prometheus.CounterVec [requests_total]"]
+	if vecInfo.Series != 3 || len(vecInfo.MetricFamilies) != 1 || vecInfo.LastGather == nil {
+		t.Errorf("unexpected info for counter vector: %+v", vecInfo)
+	} else if mf := vecInfo.MetricFamilies[0]; mf.Name != "requests_total" || mf.Type != "COUNTER" || mf.Series != 3 {
+		t.Errorf("unexpected metric family info: %+v", mf)
+	}
+	if errInfo := byName["promhttp.errorCollector [invalid_metric]"]; errInfo.Error == "" || errInfo.Descriptors != 1 {
+		t.Errorf("unexpected info for broken collector: %+v", errInfo)
+	}
+}
+
+// debugRegistry wraps a Registry, e.g. to add application-specific behavior.
+type debugRegistry struct {
+	*prometheus.Registry
+}
+
+func TestDebugHandlerWrappedRegistry(t *testing.T) {
+	reg := debugRegistry{prometheus.NewRegistry()}
+	reg.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{Name: "a_total", Help: "A."}, []string{"l"}))
+	reg.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "b", Help: "B."}))
+
+	rec := httptest.NewRecorder()
+	DebugHandlerFor(reg, DebugHandlerOpts{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/metrics", nil))
+	var info DebugInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Collectors) != 2 || info.Descriptors != 2 {
+		t.Errorf("got %d collectors with %d descriptors, want 2 with 2", len(info.Collectors), info.Descriptors)
+	}
+}
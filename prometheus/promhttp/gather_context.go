@@ -0,0 +1,111 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package promhttp
+
+import (
+	"context"
+	"net/http"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ContextGatherer is a Gatherer that can make use of the context of the scrape
+// request it is gathering for. If the Gatherer passed to HandlerFor implements
+// ContextGatherer, the handler calls GatherWithContext (rather than Gather)
+// with the context of the scrape request. The values of the request headers
+// listed in HandlerOpts.PropagatedRequestHeaders can be retrieved from that
+// context with ScrapeHeadersFromContext.
+//
+// A typical use case is a tenant-aware Gatherer that returns a different
+// subset of metrics depending on a tenant header set by the scraper, so that a
+// single endpoint can serve multiple scrapers with different views.
+//
+// Note that the context only reaches Gatherers implementing ContextGatherer
+// (or ContextTransactionalGatherer, see HandlerForTransactional).
+// prometheus.Registry does not implement it, and the Collect method of a
+// Collector has no context parameter. Thus, the context never reaches the
+// Collectors of a Registry. Implement ContextGatherer to act on the context
+// before gathering, e.g. by selecting a Registry per tenant as shown above.
+type ContextGatherer interface {
+	prometheus.Gatherer
+	// GatherWithContext works like Gather but receives the context of the
+	// scrape request.
+	GatherWithContext(ctx context.Context) ([]*dto.MetricFamily, error)
+}
+
+type scrapeHeadersKey struct{}
+
+// ScrapeHeadersFromContext returns the request headers that have been
+// propagated to the provided context by a handler created with HandlerFor,
+// according to HandlerOpts.PropagatedRequestHeaders. Header names are in
+// canonical form. Headers listed in PropagatedRequestHeaders but not present in
+// the request are missing from the returned http.Header. If no headers have
+// been propagated at all, nil is returned. The returned http.Header must not be
+// modified.
+func ScrapeHeadersFromContext(ctx context.Context) http.Header {
+	h, _ := ctx.Value(scrapeHeadersKey{}).(http.Header)
+	return h
+}
+
+// withScrapeHeaders returns a context with the values of the listed headers
+// from h attached.
+func withScrapeHeaders(ctx context.Context, h http.Header, names []string) context.Context {
+	propagated := make(http.Header, len(names))
+	for _, name := range names {
+		name = http.CanonicalHeaderKey(name)
+		if values, ok := h[name]; ok {
+			propagated[name] = append([]string(nil), values...)
+		}
+	}
+	return context.WithValue(ctx, scrapeHeadersKey{}, propagated)
+}
+
+// ContextTransactionalGatherer is the transactional counterpart of
+// ContextGatherer. If the TransactionalGatherer passed to
+// HandlerForTransactional implements ContextTransactionalGatherer, the handler
+// calls GatherWithContext (rather than Gather) with the context of the scrape
+// request.
+type ContextTransactionalGatherer interface {
+	prometheus.TransactionalGatherer
+	// GatherWithContext works like Gather but receives the context of the
+	// scrape request.
+	GatherWithContext(ctx context.Context) ([]*dto.MetricFamily, func(), error)
+}
+
+// contextTransactionalGatherer adapts a ContextGatherer to a
+// ContextTransactionalGatherer.
+type contextTransactionalGatherer struct {
+	g ContextGatherer
+}
+
+func (g contextTransactionalGatherer) Gather() ([]*dto.MetricFamily, func(), error) {
+	mfs, err := g.g.Gather()
+	return mfs, func() {}, err
+}
+
+func (g contextTransactionalGatherer) GatherWithContext(ctx context.Context) ([]*dto.MetricFamily, func(), error) {
+	mfs, err := g.g.GatherWithContext(ctx)
+	return mfs, func() {}, err
+}
+
+// gatherWithContext calls GatherWithContext on g if g implements
+// ContextTransactionalGatherer, and Gather otherwise.
+func gatherWithContext(ctx context.Context, g prometheus.TransactionalGatherer) ([]*dto.MetricFamily, func(), error) {
+	if cg, ok := g.(ContextTransactionalGatherer); ok {
+		return cg.GatherWithContext(ctx)
+	}
+	return g.Gather()
+}
@@ -32,15 +32,18 @@
 package promhttp
 
 import (
+	"bytes"
 	"compress/gzip"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/cespare/xxhash/v2"
 	"github.com/klauspost/compress/zstd"
 	"github.com/prometheus/common/expfmt"
 
@@ -51,8 +54,11 @@ import (
 const (
 	contentTypeHeader      = "Content-Type"
 	contentEncodingHeader  = "Content-Encoding"
+	contentLengthHeader    = "Content-Length"
 	acceptEncodingHeader   = "Accept-Encoding"
 	processStartTimeHeader = "Process-Start-Time-Unix"
+	etagHeader             = "ETag"
+	ifNoneMatchHeader      = "If-None-Match"
 )
 
 // Compression represents the content encodings handlers support for the HTTP
@@ -99,17 +105,30 @@ func Handler() http.Handler {
 // Gatherers, with non-default HandlerOpts, and/or with custom (or no)
 // instrumentation. Use the InstrumentMetricHandler function to apply the same
 // kind of instrumentation as it is used by the Handler function.
+//
+// If the provided Gatherer implements ContextGatherer, its GatherWithContext
+// method is called with the context of the scrape request. A
+// prometheus.Registry does not implement ContextGatherer, see there.
 func HandlerFor(reg prometheus.Gatherer, opts HandlerOpts) http.Handler {
+	if cg, ok := reg.(ContextGatherer); ok {
+		return HandlerForTransactional(contextTransactionalGatherer{cg}, opts)
+	}
 	return HandlerForTransactional(prometheus.ToTransactionalGatherer(reg), opts)
 }
 
 // HandlerForTransactional is like HandlerFor, but it uses transactional gather, which
 // can safely change in-place returned *dto.MetricFamily before call to `Gather` and after
 // call to `done` of that `Gather`.
+//
+// If the provided TransactionalGatherer implements
+// ContextTransactionalGatherer, its GatherWithContext method is called with the
+// context of the scrape request.
 func HandlerForTransactional(reg prometheus.TransactionalGatherer, opts HandlerOpts) http.Handler {
 	var (
-		inFlightSem chan struct{}
-		errCnt      = prometheus.NewCounterVec(
+		inFlightSem                  chan struct{}
+		encodeDuration, responseSize *prometheus.HistogramVec // Only set if opts.Registry is set.
+		catalog                      *catalogValidator        // Only set if opts.Catalog is set.
+		errCnt                       = prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "promhttp_metric_handler_errors_total",
 				Help: "Total number of internal errors encountered by the promhttp metric handler.",
@@ -133,6 +152,26 @@ func HandlerForTransactional(reg prometheus.TransactionalGatherer, opts HandlerO
 				panic(err)
 			}
 		}
+		encodeDuration = registerOrExisting(opts.Registry, prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "promhttp_metric_handler_encode_duration_seconds",
+				Help:    "Duration of encoding, compressing, and sending the metrics of a scrape, by negotiated format and compression.",
+				Buckets: prometheus.DefBuckets,
+			},
+			[]string{"format", "compression"},
+		)).(*prometheus.HistogramVec)
+		responseSize = registerOrExisting(opts.Registry, prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "promhttp_metric_handler_response_size_bytes",
+				Help:    "Size of the (possibly compressed) response body of a scrape, by negotiated format and compression.",
+				Buckets: prometheus.ExponentialBuckets(1024, 4, 8),
+			},
+			[]string{"format", "compression"},
+		)).(*prometheus.HistogramVec)
+	}
+
+	if opts.Catalog != nil {
+		catalog = newCatalogValidator(opts.Catalog, opts.Registry, opts.ErrorLog)
 	}
 
 	// Select compression formats to offer based on default or user choice.
@@ -162,7 +201,11 @@ func HandlerForTransactional(reg prometheus.TransactionalGatherer, opts HandlerO
 				return
 			}
 		}
-		mfs, done, err := reg.Gather()
+		ctx := req.Context()
+		if len(opts.PropagatedRequestHeaders) > 0 {
+			ctx = withScrapeHeaders(ctx, req.Header, opts.PropagatedRequestHeaders)
+		}
+		mfs, done, err := gatherWithContext(ctx, reg)
 		defer done()
 		if err != nil {
 			if opts.ErrorLog != nil {
@@ -183,6 +226,9 @@ func HandlerForTransactional(reg prometheus.TransactionalGatherer, opts HandlerO
 				return
 			}
 		}
+		if catalog != nil {
+			catalog.validate(mfs)
+		}
 
 		var contentType expfmt.Format
 		if opts.EnableOpenMetrics {
@@ -192,28 +238,10 @@ func HandlerForTransactional(reg prometheus.TransactionalGatherer, opts HandlerO
 		}
 		rsp.Header().Set(contentTypeHeader, string(contentType))
 
-		w, encodingHeader, closeWriter, err := negotiateEncodingWriter(req, rsp, compressions)
-		if err != nil {
-			if opts.ErrorLog != nil {
-				opts.ErrorLog.Println("error getting writer", err)
-			}
-			w = io.Writer(rsp)
-			encodingHeader = string(Identity)
-		}
-
-		defer closeWriter()
-
-		// Set Content-Encoding only when data is compressed
-		if encodingHeader != string(Identity) {
-			rsp.Header().Set(contentEncodingHeader, encodingHeader)
-		}
-
-		var enc expfmt.Encoder
-		if opts.EnableOpenMetricsTextCreatedSamples {
-			enc = expfmt.NewEncoder(w, contentType, expfmt.WithCreatedLines())
-		} else {
-			enc = expfmt.NewEncoder(w, contentType)
-		}
+		var (
+			clw      *contentLengthWriter
+			buffered bool // True while encoding into the ETag buffer.
+		)
 
 		// handleError handles the error according to opts.ErrorHandling
 		// and returns true if we have to abort after the handling.
@@ -229,27 +257,111 @@ func HandlerForTransactional(reg prometheus.TransactionalGatherer, opts HandlerO
 			case PanicOnError:
 				panic(err)
 			case HTTPErrorOnError:
-				// We cannot really send an HTTP error at this
-				// point because we most likely have written
-				// something to rsp already. But at least we can
-				// stop sending.
+				// Unless the body is still buffered, we cannot
+				// really send an HTTP error at this point
+				// because we most likely have written something
+				// to rsp already. But at least we can stop
+				// sending.
+				if buffered {
+					httpError(rsp, err)
+				} else if clw != nil {
+					clw.abort(err)
+				}
 				return true
 			}
 			// Do nothing in all other cases, including ContinueOnError.
 			return false
 		}
 
-		for _, mf := range mfs {
-			if handleError(enc.Encode(mf)) {
-				return
+		// encode encodes mfs to w and returns true if we have to abort.
+		encode := func(w io.Writer) bool {
+			var enc expfmt.Encoder
+			if opts.EnableOpenMetricsTextCreatedSamples {
+				enc = expfmt.NewEncoder(w, contentType, expfmt.WithCreatedLines())
+			} else {
+				enc = expfmt.NewEncoder(w, contentType)
+			}
+			for _, mf := range mfs {
+				if handleError(enc.Encode(mf)) {
+					return true
+				}
+			}
+			if closer, ok := enc.(expfmt.Closer); ok {
+				// This in particular takes care of the final "# EOF\n" line for OpenMetrics.
+				if handleError(closer.Close()) {
+					return true
+				}
 			}
+			return false
 		}
-		if closer, ok := enc.(expfmt.Closer); ok {
-			// This in particular takes care of the final "# EOF\n" line for OpenMetrics.
-			if handleError(closer.Close()) {
+
+		var body *bytes.Buffer // Only set if opts.EnableETag is set.
+		if opts.EnableETag {
+			body = &bytes.Buffer{}
+			buffered = true
+			if encode(body) {
+				return
+			}
+			buffered = false
+			etag := expositionETag(contentType, body.Bytes())
+			rsp.Header().Set(etagHeader, etag)
+			if etagMatches(req.Header.Get(ifNoneMatchHeader), etag) {
+				rsp.Header().Del(contentTypeHeader)
+				rsp.WriteHeader(http.StatusNotModified)
 				return
 			}
 		}
+
+		var (
+			rw             io.Writer = rsp
+			cw             *countingWriter
+			encodingHeader string
+			encodeStart    = time.Now()
+		)
+		if encodeDuration != nil {
+			// Deferred first so that it runs last, i.e. after the
+			// compressed stream has been finalized and the
+			// Content-Length buffer has been flushed.
+			defer func() {
+				format := formatLabel(contentType)
+				encodeDuration.WithLabelValues(format, encodingHeader).Observe(time.Since(encodeStart).Seconds())
+				responseSize.WithLabelValues(format, encodingHeader).Observe(float64(cw.n))
+			}()
+		}
+		if opts.ContentLengthBufferSize > 0 {
+			clw = &contentLengthWriter{rsp: rsp, max: opts.ContentLengthBufferSize}
+			// Deferred before closeWriter so that it runs after the
+			// compressed stream has been finalized.
+			defer clw.flush()
+			rw = clw
+		}
+
+		if responseSize != nil {
+			cw = &countingWriter{w: rw}
+			rw = cw
+		}
+
+		w, encodingHeader, closeWriter, err := negotiateEncodingWriter(req, rw, compressions)
+		if err != nil {
+			if opts.ErrorLog != nil {
+				opts.ErrorLog.Println("error getting writer", err)
+			}
+			w = rw
+			encodingHeader = string(Identity)
+		}
+		defer closeWriter()
+
+		// Set Content-Encoding only when data is compressed
+		if encodingHeader != string(Identity) {
+			rsp.Header().Set(contentEncodingHeader, encodingHeader)
+		}
+
+		if body != nil {
+			_, err := w.Write(body.Bytes())
+			handleError(err)
+			return
+		}
+		encode(w)
 	})
 
 	if opts.Timeout <= 0 {
@@ -375,6 +487,19 @@ type HandlerOpts struct {
 	// should only happen with custom collectors. (2) Collection errors with
 	// no effect on the HTTP status code because ErrorHandling is set to
 	// ContinueOnError.
+	//
+	// Furthermore, the histograms
+	// "promhttp_metric_handler_encode_duration_seconds" and
+	// "promhttp_metric_handler_response_size_bytes" are registered, both
+	// partitioned by the negotiated "format" (text, openmetrics, protobuf,
+	// …) and "compression" (identity, gzip, zstd). They allow to quantify
+	// the cost of the different exposition formats, e.g. before enabling
+	// OpenMetrics or native histograms (which are only exposed in the
+	// protobuf format) fleet-wide. The encode duration includes the
+	// compression and the time spent writing to the connection, so slow
+	// scrapers increase it, too. The response size is measured after
+	// compression. If the metrics are already registered with the Registry
+	// (e.g. by another handler), the existing metrics are used.
 	Registry prometheus.Registerer
 	// DisableCompression disables the response encoding (compression) and
 	// encoding negotiation. If true, the handler will
@@ -437,6 +562,167 @@ type HandlerOpts struct {
 	// NOTE: This feature is experimental and not covered by OpenMetrics or Prometheus
 	// exposition format.
 	ProcessStartTime time.Time
+	// PropagatedRequestHeaders lists the names of request headers (e.g.
+	// "X-Prometheus-Scrape-Timeout-Seconds" or a custom tenant header)
+	// whose values are attached to the context passed to the
+	// GatherWithContext method of a ContextGatherer (or a
+	// ContextTransactionalGatherer). Use ScrapeHeadersFromContext to
+	// retrieve them. Headers not listed here are not propagated.
+	// PropagatedRequestHeaders has no effect if the Gatherer does not
+	// implement one of these interfaces, which is the case for
+	// prometheus.Registry, i.e. the headers never reach the Collectors of a
+	// Registry.
+	PropagatedRequestHeaders []string
+	// If ContentLengthBufferSize is greater than zero, the handler buffers
+	// the (possibly compressed) response body up to the given number of
+	// bytes before sending it, so that it can set an accurate
+	// Content-Length header. Some proxies and load balancers handle
+	// responses with a Content-Length header better than responses with
+	// chunked transfer encoding. If the response body turns out to be
+	// larger than ContentLengthBufferSize, the handler falls back to
+	// sending the body without Content-Length header (as if
+	// ContentLengthBufferSize were zero) once the buffer is full. Note that
+	// each request in flight might allocate a buffer of the given size, so
+	// consider limiting MaxRequestsInFlight, too. With HTTPErrorOnError,
+	// an error during encoding results in an HTTP error as long as the
+	// body is still buffered.
+	ContentLengthBufferSize int
+	// If Catalog is not nil, the gathered metrics of each scrape are
+	// compared with it (see Catalog.Diff), which allows to detect drift
+	// of the instrumentation in canaries or CI. The result does not
+	// influence the response. If Registry is set, the gauge vector
+	// "promhttp_metric_handler_catalog_drift" is registered with it,
+	// partitioned by "kind" (unexpected_metric, missing_metric,
+	// type_mismatch, label_mismatch), counting the differences found in
+	// the last scrape. If ErrorLog is set, (some of) the differences are
+	// logged for each scrape with differences. Comparing takes time
+	// proportional to the number of gathered metrics, so consider only
+	// setting Catalog where the validation is needed.
+	Catalog *Catalog
+	// If EnableETag is true, the handler encodes the complete exposition
+	// before sending it and sets a weak ETag header, computed from the
+	// negotiated format and the (uncompressed) exposition. If the ETag
+	// matches the If-None-Match header of the request, the handler
+	// responds with 304 Not Modified and no body. This saves bandwidth
+	// for large expositions that rarely change between scrapes (e.g.
+	// consisting of constant metrics), in particular if scraped by
+	// multiple consumers. As all compressions of the same exposition
+	// share the ETag, a scraper revalidating with a different
+	// Accept-Encoding still gets a 304. Note that each request in flight
+	// buffers the exposition, and that encoding and hashing it still
+	// happens for each scrape. Responses with status 304 are not observed
+	// by the encode duration and response size histograms (see
+	// Registry).
+	EnableETag bool
+}
+
+// contentLengthWriter buffers everything written to it up to max bytes. If
+// flush is called before the buffer overflows, it sets the Content-Length
+// header of rsp and writes the buffered bytes. Once the buffer would
+// overflow, it writes the buffered bytes to rsp (without a Content-Length
+// header) and passes all further writes through. If abort is called before
+// the buffer overflows, the buffered bytes are dropped in favor of an HTTP
+// error.
+type contentLengthWriter struct {
+	rsp         http.ResponseWriter
+	max         int
+	buf         bytes.Buffer
+	passThrough bool
+	aborted     bool
+}
+
+func (w *contentLengthWriter) Write(p []byte) (int, error) {
+	if w.aborted {
+		// Discard, e.g. the end of a compressed stream.
+		return len(p), nil
+	}
+	if w.passThrough {
+		return w.rsp.Write(p)
+	}
+	if w.buf.Len()+len(p) <= w.max {
+		return w.buf.Write(p)
+	}
+	w.passThrough = true
+	if _, err := w.rsp.Write(w.buf.Bytes()); err != nil {
+		return 0, err
+	}
+	w.buf = bytes.Buffer{}
+	return w.rsp.Write(p)
+}
+
+func (w *contentLengthWriter) flush() {
+	if w.passThrough || w.aborted {
+		return
+	}
+	w.rsp.Header().Set(contentLengthHeader, strconv.Itoa(w.buf.Len()))
+	_, _ = w.rsp.Write(w.buf.Bytes())
+}
+
+// abort responds with an HTTP error for err instead of the buffered bytes,
+// which are dropped. It does nothing if the bytes have already been written.
+func (w *contentLengthWriter) abort(err error) {
+	if w.passThrough || w.aborted {
+		return
+	}
+	w.aborted = true
+	w.buf = bytes.Buffer{}
+	httpError(w.rsp, err)
+}
+
+// expositionETag returns a weak ETag for body encoded in format.
+func expositionETag(format expfmt.Format, body []byte) string {
+	h := xxhash.New()
+	_, _ = h.WriteString(string(format))
+	_, _ = h.Write([]byte{0xff})
+	_, _ = h.Write(body)
+	return fmt.Sprintf(`W/"%016x"`, h.Sum64())
+}
+
+// etagMatches returns whether the provided If-None-Match header value matches
+// etag, using the weak comparison (as required for If-None-Match).
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	opaque := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == opaque {
+			return true
+		}
+	}
+	return false
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// formatLabel returns the value of the "format" label of the handler's
+// self-metrics for the negotiated format f.
+func formatLabel(f expfmt.Format) string {
+	switch f.FormatType() {
+	case expfmt.TypeTextPlain:
+		return "text"
+	case expfmt.TypeOpenMetrics:
+		return "openmetrics"
+	case expfmt.TypeProtoDelim:
+		return "protobuf"
+	case expfmt.TypeProtoText:
+		return "prototext"
+	case expfmt.TypeProtoCompact:
+		return "protocompact"
+	default:
+		return "unknown"
+	}
 }
 
 // httpError removes any content-encoding header and then calls http.Error with
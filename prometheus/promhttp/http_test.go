@@ -16,18 +16,21 @@ package promhttp
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/klauspost/compress/zstd"
 	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -639,3 +642,393 @@ func BenchmarkCompression(b *testing.B) {
 		}
 	}
 }
+
+type tenantGatherer struct {
+	tenants map[string]prometheus.Gatherer
+}
+
+func (g tenantGatherer) Gather() ([]*dto.MetricFamily, error) {
+	return nil, errors.New("no tenant")
+}
+
+func (g tenantGatherer) GatherWithContext(ctx context.Context) ([]*dto.MetricFamily, error) {
+	tenant := ScrapeHeadersFromContext(ctx).Get("X-Tenant")
+	if reg, ok := g.tenants[tenant]; ok {
+		return reg.Gather()
+	}
+	return nil, fmt.Errorf("unknown tenant %q", tenant)
+}
+
+func TestHandlerWithContextGatherer(t *testing.T) {
+	regA := prometheus.NewRegistry()
+	regA.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: "tenant_a", Help: "A."}, func() float64 { return 1 }))
+	regB := prometheus.NewRegistry()
+	regB.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: "tenant_b", Help: "B."}, func() float64 { return 2 }))
+
+	handler := HandlerFor(
+		tenantGatherer{tenants: map[string]prometheus.Gatherer{"a": regA, "b": regB}},
+		HandlerOpts{PropagatedRequestHeaders: []string{"x-tenant"}},
+	)
+
+	for tenant, want := range map[string]string{
+		"a": "# HELP tenant_a A.\n# TYPE tenant_a gauge\ntenant_a 1\n",
+		"b": "# HELP tenant_b B.\n# TYPE tenant_b gauge\ntenant_b 2\n",
+	} {
+		writer := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Add(acceptHeader, acceptTextPlain)
+		request.Header.Add("X-Tenant", tenant)
+		request.Header.Add("X-Not-Propagated", "foo")
+		handler.ServeHTTP(writer, request)
+		if got := writer.Body.String(); got != want {
+			t.Errorf("tenant %s: got body %q, want %q", tenant, got, want)
+		}
+	}
+
+	// Without PropagatedRequestHeaders, the context carries no headers.
+	handler = HandlerFor(
+		tenantGatherer{tenants: map[string]prometheus.Gatherer{"a": regA}},
+		HandlerOpts{},
+	)
+	writer := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Add("X-Tenant", "a")
+	handler.ServeHTTP(writer, request)
+	if got, want := writer.Code, http.StatusInternalServerError; got != want {
+		t.Errorf("got status %d, want %d", got, want)
+	}
+}
+
+// transactionalTenantGatherer is a tenantGatherer implementing
+// ContextTransactionalGatherer.
+type transactionalTenantGatherer struct {
+	tenantGatherer
+	done *int
+}
+
+func (g transactionalTenantGatherer) Gather() ([]*dto.MetricFamily, func(), error) {
+	mfs, err := g.tenantGatherer.Gather()
+	return mfs, func() { *g.done++ }, err
+}
+
+func (g transactionalTenantGatherer) GatherWithContext(ctx context.Context) ([]*dto.MetricFamily, func(), error) {
+	mfs, err := g.tenantGatherer.GatherWithContext(ctx)
+	return mfs, func() { *g.done++ }, err
+}
+
+func TestHandlerForTransactionalWithContext(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: "tenant_a", Help: "A."}, func() float64 { return 1 }))
+
+	var done int
+	handler := HandlerForTransactional(
+		transactionalTenantGatherer{tenantGatherer{tenants: map[string]prometheus.Gatherer{"a": reg}}, &done},
+		HandlerOpts{PropagatedRequestHeaders: []string{"X-Tenant"}},
+	)
+	writer := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Add(acceptHeader, acceptTextPlain)
+	request.Header.Add("X-Tenant", "a")
+	handler.ServeHTTP(writer, request)
+	if got, want := writer.Body.String(), "# HELP tenant_a A.\n# TYPE tenant_a gauge\ntenant_a 1\n"; got != want {
+		t.Errorf("got body %q, want %q", got, want)
+	}
+	if done != 1 {
+		t.Errorf("done called %d times, want 1", done)
+	}
+}
+
+func TestScrapeHeadersFromContext(t *testing.T) {
+	if h := ScrapeHeadersFromContext(context.Background()); h != nil {
+		t.Errorf("expected nil header, got %v", h)
+	}
+	ctx := withScrapeHeaders(context.Background(), http.Header{
+		"X-Prometheus-Scrape-Timeout-Seconds": {"10"},
+		"X-Other":                             {"x"},
+	}, []string{"x-prometheus-scrape-timeout-seconds", "X-Missing"})
+	want := http.Header{"X-Prometheus-Scrape-Timeout-Seconds": {"10"}}
+	if got := ScrapeHeadersFromContext(ctx); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestHandlerContentLength(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	g := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "some_gauge", Help: "Some gauge."}, []string{"id"})
+	for i := 0; i < 500; i++ {
+		g.WithLabelValues(fmt.Sprint(i)).Set(float64(i))
+	}
+	reg.MustRegister(g)
+
+	for _, tc := range []struct {
+		name          string
+		bufferSize    int
+		acceptEnc     string
+		wantLengthSet bool
+	}{
+		{name: "identity", bufferSize: 1 << 20, acceptEnc: "identity", wantLengthSet: true},
+		{name: "gzip", bufferSize: 1 << 20, acceptEnc: "gzip", wantLengthSet: true},
+		{name: "exceeding buffer", bufferSize: 1000, acceptEnc: "identity"},
+		{name: "disabled", acceptEnc: "identity"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewServer(HandlerFor(reg, HandlerOpts{ContentLengthBufferSize: tc.bufferSize}))
+			defer ts.Close()
+
+			req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+			req.Header.Set(acceptHeader, acceptTextPlain)
+			req.Header.Set(acceptEncodingHeader, tc.acceptEnc)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.acceptEnc == "gzip" {
+				gz, err := gzip.NewReader(bytes.NewReader(body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if body, err = io.ReadAll(gz); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := strings.Count(string(body), "\nsome_gauge{"); got != 500 {
+				t.Errorf("got %d samples, want 500", got)
+			}
+
+			if tc.wantLengthSet {
+				if resp.ContentLength < 0 || len(resp.TransferEncoding) != 0 {
+					t.Errorf("got Content-Length %d and transfer encoding %v, want Content-Length set", resp.ContentLength, resp.TransferEncoding)
+				}
+			} else if resp.ContentLength != -1 {
+				t.Errorf("got Content-Length %d, want none", resp.ContentLength)
+			}
+		})
+	}
+}
+
+func TestHandlerContentLengthEncodingError(t *testing.T) {
+	// A counter family without counter values cannot be encoded.
+	gatherer := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return []*dto.MetricFamily{
+			{
+				Name:   proto.String("good"),
+				Help:   proto.String("Good."),
+				Type:   dto.MetricType_GAUGE.Enum(),
+				Metric: []*dto.Metric{{Gauge: &dto.Gauge{Value: proto.Float64(1)}}},
+			},
+			{
+				Name:   proto.String("bad_total"),
+				Help:   proto.String("Bad."),
+				Type:   dto.MetricType_COUNTER.Enum(),
+				Metric: []*dto.Metric{{Gauge: &dto.Gauge{Value: proto.Float64(1)}}},
+			},
+		}, nil
+	})
+
+	for name, opts := range map[string]HandlerOpts{
+		"identity":      {ContentLengthBufferSize: 1 << 20},
+		"gzip":          {ContentLengthBufferSize: 1 << 20},
+		"identity ETag": {EnableETag: true},
+		"gzip ETag":     {EnableETag: true},
+	} {
+		acceptEnc := strings.Fields(name)[0]
+		t.Run(name, func(t *testing.T) {
+			handler := HandlerFor(gatherer, opts)
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(acceptHeader, acceptTextPlain)
+			req.Header.Set(acceptEncodingHeader, acceptEnc)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusInternalServerError {
+				t.Errorf("got status %d, want %d", rec.Code, http.StatusInternalServerError)
+			}
+			if got := rec.Header().Get(contentEncodingHeader); got != "" {
+				t.Errorf("got Content-Encoding %q, want none", got)
+			}
+			body := rec.Body.String()
+			if !strings.HasPrefix(body, "An error has occurred while serving metrics:") || strings.Contains(body, "good 1") {
+				t.Errorf("unexpected body: %q", body)
+			}
+		})
+	}
+}
+
+func TestHandlerEncodeMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	self := prometheus.NewRegistry()
+	reg.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "some_gauge", Help: "Some gauge."}))
+	handler := HandlerFor(reg, HandlerOpts{Registry: self})
+
+	var textSize int
+	for _, tc := range []struct {
+		accept, acceptEnc string
+	}{
+		{accept: acceptTextPlain, acceptEnc: "identity"},
+		{accept: acceptTextPlain, acceptEnc: "identity"},
+		{accept: acceptTextPlain, acceptEnc: "gzip"},
+		{accept: "application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited", acceptEnc: "identity"},
+	} {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(acceptHeader, tc.accept)
+		req.Header.Set(acceptEncodingHeader, tc.acceptEnc)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if tc.accept == acceptTextPlain && tc.acceptEnc == "identity" {
+			textSize = rec.Body.Len()
+		}
+	}
+
+	mfs, err := self.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]map[string]*dto.Histogram{}
+	for _, mf := range mfs {
+		got[mf.GetName()] = map[string]*dto.Histogram{}
+		for _, m := range mf.GetMetric() {
+			var format, compression string
+			for _, lp := range m.GetLabel() {
+				switch lp.GetName() {
+				case "format":
+					format = lp.GetValue()
+				case "compression":
+					compression = lp.GetValue()
+				}
+			}
+			got[mf.GetName()][format+"/"+compression] = m.GetHistogram()
+		}
+	}
+	for _, name := range []string{"promhttp_metric_handler_encode_duration_seconds", "promhttp_metric_handler_response_size_bytes"} {
+		for series, wantCount := range map[string]uint64{"text/identity": 2, "text/gzip": 1, "protobuf/identity": 1} {
+			if h := got[name][series]; h.GetSampleCount() != wantCount {
+				t.Errorf("%s{%s}: got count %d, want %d", name, series, h.GetSampleCount(), wantCount)
+			}
+		}
+		if len(got[name]) != 3 {
+			t.Errorf("%s: got %d series, want 3", name, len(got[name]))
+		}
+	}
+	if got, want := got["promhttp_metric_handler_response_size_bytes"]["text/identity"].GetSampleSum(), float64(2*textSize); got != want {
+		t.Errorf("got response size sum %v, want %v", got, want)
+	}
+}
+
+// slowResponseWriter is an http.ResponseWriter delaying each write.
+type slowResponseWriter struct {
+	*httptest.ResponseRecorder
+	delay time.Duration
+}
+
+func (w slowResponseWriter) Write(p []byte) (int, error) {
+	time.Sleep(w.delay)
+	return w.ResponseRecorder.Write(p)
+}
+
+func TestHandlerEncodeDurationIncludesFlush(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	self := prometheus.NewRegistry()
+	reg.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "some_gauge", Help: "Some gauge."}))
+	handler := HandlerFor(reg, HandlerOpts{Registry: self, ContentLengthBufferSize: 1 << 20})
+
+	const delay = 50 * time.Millisecond
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(acceptHeader, acceptTextPlain)
+	req.Header.Set(acceptEncodingHeader, "identity")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(slowResponseWriter{ResponseRecorder: rec, delay: delay}, req)
+	if rec.Header().Get(contentLengthHeader) == "" {
+		t.Fatal("expected buffered response with Content-Length")
+	}
+
+	mfs, err := self.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mf := range mfs {
+		if mf.GetName() != "promhttp_metric_handler_encode_duration_seconds" {
+			continue
+		}
+		if got := mf.GetMetric()[0].GetHistogram().GetSampleSum(); got < delay.Seconds() {
+			t.Errorf("got encode duration %vs, want at least %vs", got, delay.Seconds())
+		}
+		return
+	}
+	t.Error("encode duration not found")
+}
+
+func TestHandlerETag(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	g := prometheus.NewGauge(prometheus.GaugeOpts{Name: "some_gauge", Help: "Some gauge."})
+	reg.MustRegister(g)
+	handler := HandlerFor(reg, HandlerOpts{EnableETag: true})
+
+	scrape := func(accept, acceptEnc, ifNoneMatch string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(acceptHeader, accept)
+		req.Header.Set(acceptEncodingHeader, acceptEnc)
+		if ifNoneMatch != "" {
+			req.Header.Set(ifNoneMatchHeader, ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := scrape(acceptTextPlain, "identity", "")
+	etag := first.Header().Get(etagHeader)
+	if first.Code != http.StatusOK || !strings.HasPrefix(etag, `W/"`) || !strings.Contains(first.Body.String(), "some_gauge 0") {
+		t.Fatalf("unexpected first response: %d, ETag %q, body %q", first.Code, etag, first.Body)
+	}
+
+	// Unchanged, also if compressed and with several candidates.
+	for _, ifNoneMatch := range []string{etag, `"other", ` + etag, strings.TrimPrefix(etag, "W/"), "*"} {
+		for _, acceptEnc := range []string{"identity", "gzip"} {
+			rec := scrape(acceptTextPlain, acceptEnc, ifNoneMatch)
+			if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 || rec.Header().Get(etagHeader) != etag {
+				t.Errorf("If-None-Match %q, %s: got %d with ETag %q and %d bytes, want 304", ifNoneMatch, acceptEnc, rec.Code, rec.Header().Get(etagHeader), rec.Body.Len())
+			}
+			if got := rec.Header().Get(contentEncodingHeader); got != "" {
+				t.Errorf("got Content-Encoding %q for 304", got)
+			}
+		}
+	}
+
+	// A different format has a different ETag.
+	proto := scrape("application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited", "identity", etag)
+	if proto.Code != http.StatusOK || proto.Header().Get(etagHeader) == etag {
+		t.Errorf("got %d with ETag %q for protobuf", proto.Code, proto.Header().Get(etagHeader))
+	}
+
+	// Changed content.
+	g.Set(1)
+	rec := scrape(acceptTextPlain, "gzip", etag)
+	if rec.Code != http.StatusOK || rec.Header().Get(etagHeader) == etag {
+		t.Fatalf("got %d with ETag %q after change", rec.Code, rec.Header().Get(etagHeader))
+	}
+	gz, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, err := io.ReadAll(gz); err != nil || !strings.Contains(string(body), "some_gauge 1") {
+		t.Errorf("unexpected body %q (error %v)", body, err)
+	}
+}
+
+func TestHandlerETagDisabled(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "some_gauge", Help: "Some gauge."}))
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(ifNoneMatchHeader, "*")
+	rec := httptest.NewRecorder()
+	HandlerFor(reg, HandlerOpts{}).ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get(etagHeader) != "" {
+		t.Errorf("got %d with ETag %q", rec.Code, rec.Header().Get(etagHeader))
+	}
+}
@@ -15,6 +15,7 @@ package promhttp
 
 import (
 	"context"
+	"unicode/utf8"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -75,6 +76,48 @@ func WithExemplarFromContext(getExemplarFn func(requestCtx context.Context) prom
 	})
 }
 
+// WithExemplarsFromContext works like WithExemplarFromContext but composes the
+// exemplar from the labels returned by multiple functions, e.g. one returning a
+// trace ID, one returning a request ID, and one returning a tenant. The
+// functions are called in order, and earlier functions take precedence: If a
+// label name is returned by more than one function, the value returned by the
+// first one is used. The labels returned by a function are only added (as a
+// whole) if the total number of runes in all label names and values of the
+// exemplar stays within prometheus.ExemplarMaxRunes. Otherwise, they are
+// skipped, while the labels of later functions might still fit. Thereby, the
+// most important labels are kept, and exemplars never get rejected for being
+// too long. If no function returns any labels, no exemplar is added.
+func WithExemplarsFromContext(getExemplarFns ...func(requestCtx context.Context) prometheus.Labels) Option {
+	return WithExemplarFromContext(func(ctx context.Context) prometheus.Labels {
+		var (
+			exemplar prometheus.Labels
+			runes    int
+		)
+		for _, fn := range getExemplarFns {
+			labels := fn(ctx)
+			added := 0
+			for name, value := range labels {
+				if _, ok := exemplar[name]; !ok {
+					added += utf8.RuneCountInString(name) + utf8.RuneCountInString(value)
+				}
+			}
+			if len(labels) == 0 || runes+added > prometheus.ExemplarMaxRunes {
+				continue
+			}
+			if exemplar == nil {
+				exemplar = make(prometheus.Labels, len(labels))
+			}
+			for name, value := range labels {
+				if _, ok := exemplar[name]; !ok {
+					exemplar[name] = value
+				}
+			}
+			runes += added
+		}
+		return exemplar
+	})
+}
+
 // WithLabelFromCtx registers a label for dynamic resolution with access to context.
 // See the example for ExampleInstrumentHandlerWithLabelResolver for example usage
 func WithLabelFromCtx(name string, valueFn LabelValueFromCtx) Option {
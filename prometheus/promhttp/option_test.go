@@ -17,6 +17,9 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"reflect"
+	"strings"
+	"testing"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -126,3 +129,50 @@ func ExampleInstrumentHandlerWithLabelResolver() {
 		log.Fatal(err)
 	}
 }
+
+func TestWithExemplarsFromContext(t *testing.T) {
+	source := func(l prometheus.Labels) func(context.Context) prometheus.Labels {
+		return func(context.Context) prometheus.Labels { return l }
+	}
+	long := strings.Repeat("x", prometheus.ExemplarMaxRunes-len("trace_id")-len("abc")-len("tenant")+1)
+
+	for _, tc := range []struct {
+		name    string
+		sources []func(context.Context) prometheus.Labels
+		want    prometheus.Labels
+	}{
+		{
+			name: "no labels",
+			sources: []func(context.Context) prometheus.Labels{
+				source(nil), source(prometheus.Labels{}),
+			},
+			want: nil,
+		},
+		{
+			name: "precedence",
+			sources: []func(context.Context) prometheus.Labels{
+				source(prometheus.Labels{"trace_id": "abc"}),
+				source(nil),
+				source(prometheus.Labels{"trace_id": "def", "request_id": "123"}),
+			},
+			want: prometheus.Labels{"trace_id": "abc", "request_id": "123"},
+		},
+		{
+			name: "size guard",
+			sources: []func(context.Context) prometheus.Labels{
+				source(prometheus.Labels{"trace_id": "abc"}),
+				source(prometheus.Labels{"tenant": long}),
+				source(prometheus.Labels{"tenant": "a"}),
+			},
+			want: prometheus.Labels{"trace_id": "abc", "tenant": "a"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := defaultOptions()
+			WithExemplarsFromContext(tc.sources...).apply(o)
+			if got := o.getExemplarFn(context.Background()); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got exemplar %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package promhttp
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/prometheus/client_golang/internal/github.com/golang/gddo/httputil"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Modes of serving an Exposition, used as values of the "mode" label of the
+// promhttp_passthrough_responses_total metric.
+const (
+	passthroughModeVerbatim     = "verbatim"
+	passthroughModeRecompressed = "recompressed"
+	passthroughModeDecompressed = "decompressed"
+)
+
+// Exposition is an already encoded exposition of metrics, e.g. the body of a
+// scrape of another endpoint.
+type Exposition struct {
+	// Format is the format of the exposition. It is used as the
+	// Content-Type of the response.
+	Format expfmt.Format
+	// Encoding is the content encoding (compression) of Body. The empty
+	// string is equivalent to Identity.
+	Encoding Compression
+	// Body is the encoded exposition. It is closed once the response has
+	// been served.
+	Body io.ReadCloser
+}
+
+// ExpositionSource provides the Exposition to serve for a scrape request.
+// UpstreamSource returns an ExpositionSource that scrapes another endpoint.
+type ExpositionSource func(req *http.Request) (*Exposition, error)
+
+// UpstreamSource returns an ExpositionSource that scrapes the provided URL with
+// the provided client (or http.DefaultClient if nil). The Accept and
+// Accept-Encoding headers of the scrape request are forwarded, so that the
+// upstream endpoint can directly produce an exposition the scraper accepts. In
+// particular, a compressed response is not decompressed by the client, so
+// that it can be passed through verbatim by PassthroughHandler.
+func UpstreamSource(client *http.Client, url string) ExpositionSource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(req *http.Request) (*Exposition, error) {
+		upstreamReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range []string{"Accept", acceptEncodingHeader} {
+			if v := req.Header.Values(h); len(v) > 0 {
+				upstreamReq.Header[h] = v
+			}
+		}
+		resp, err := client.Do(upstreamReq)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode/100 != 2 {
+			resp.Body.Close()
+			return nil, fmt.Errorf("upstream %s responded with status %s", url, resp.Status)
+		}
+		format := expfmt.Format(resp.Header.Get(contentTypeHeader))
+		if format == "" {
+			format = expfmt.NewFormat(expfmt.TypeTextPlain)
+		}
+		return &Exposition{
+			Format:   format,
+			Encoding: Compression(resp.Header.Get(contentEncodingHeader)),
+			Body:     resp.Body,
+		}, nil
+	}
+}
+
+// PassthroughOpts specifies options for PassthroughHandler. The zero value of
+// PassthroughOpts is a reasonable default.
+type PassthroughOpts struct {
+	// ErrorLog specifies an optional Logger for errors obtaining or serving
+	// an Exposition. If nil, errors are not logged at all.
+	ErrorLog Logger
+	// OfferedCompressions is the set of encodings used if an Exposition
+	// has to be re-encoded because the client doesn't accept its encoding.
+	// It defaults to identity, gzip, and zstd, as for HandlerOpts.
+	OfferedCompressions []Compression
+	// MaxRecompressionsInFlight limits the number of concurrent responses
+	// that are decompressed and compressed again with a different encoding
+	// (the expensive case). Once the limit is reached, further responses
+	// needing re-encoding are served uncompressed instead, trading
+	// bandwidth for CPU. If MaxRecompressionsInFlight is 0 or negative, no
+	// limit is applied.
+	MaxRecompressionsInFlight int
+	// If Registry is not nil, it is used to register a counter
+	// "promhttp_passthrough_responses_total", partitioned by "mode", which
+	// is one of "verbatim" (the Exposition was served as is),
+	// "recompressed", and "decompressed" (served uncompressed, either
+	// because the client requested it or because of
+	// MaxRecompressionsInFlight). If the counter is already registered
+	// (e.g. by another PassthroughHandler), the existing counter is used.
+	Registry prometheus.Registerer
+}
+
+// PassthroughHandler returns an http.Handler serving the Exposition provided
+// by src for each request. It is meant for proxies and sidecars re-exposing
+// the metrics of other endpoints without decoding them.
+//
+// If the client accepts the content encoding of the Exposition, its body is
+// copied to the response verbatim, avoiding a decompress/compress cycle.
+// Otherwise, the body is decompressed (gzip and zstd are supported) and then
+// compressed with the best encoding negotiated with the client from
+// PassthroughOpts.OfferedCompressions, subject to
+// PassthroughOpts.MaxRecompressionsInFlight. The format of the Exposition is
+// never converted. If the client doesn't accept it, the response is sent
+// anyway. Use UpstreamSource to let the upstream endpoint take the Accept
+// header of the client into account.
+//
+// If src returns an error, or the Exposition cannot be decompressed, the
+// request is responded to with an HTTP status code 500. Errors while copying
+// the body abort the response.
+func PassthroughHandler(src ExpositionSource, opts PassthroughOpts) http.Handler {
+	var (
+		recompressionSem chan struct{}
+		responses        = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "promhttp_passthrough_responses_total",
+				Help: "Total number of responses served by the promhttp passthrough handler, by how the exposition was encoded.",
+			},
+			[]string{"mode"},
+		)
+		compressions []string
+	)
+	if opts.MaxRecompressionsInFlight > 0 {
+		recompressionSem = make(chan struct{}, opts.MaxRecompressionsInFlight)
+	}
+	offers := defaultCompressionFormats
+	if len(opts.OfferedCompressions) > 0 {
+		offers = opts.OfferedCompressions
+	}
+	for _, comp := range offers {
+		compressions = append(compressions, string(comp))
+	}
+	if opts.Registry != nil {
+		// Initialize all possibilities that can occur below.
+		responses.WithLabelValues(passthroughModeVerbatim)
+		responses.WithLabelValues(passthroughModeRecompressed)
+		responses.WithLabelValues(passthroughModeDecompressed)
+		responses = registerOrExisting(opts.Registry, responses).(*prometheus.CounterVec)
+	}
+	logError := func(v ...interface{}) {
+		if opts.ErrorLog != nil {
+			opts.ErrorLog.Println(v...)
+		}
+	}
+
+	return http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {
+		exp, err := src(req)
+		if err != nil {
+			logError("error obtaining exposition:", err)
+			httpError(rsp, err)
+			return
+		}
+		defer exp.Body.Close()
+
+		encoding := string(exp.Encoding)
+		if encoding == "" {
+			encoding = string(Identity)
+		}
+		rsp.Header().Set(contentTypeHeader, string(exp.Format))
+
+		if encoding == string(Identity) || httputil.NegotiateContentEncoding(req, []string{encoding}) == encoding {
+			if encoding != string(Identity) {
+				rsp.Header().Set(contentEncodingHeader, encoding)
+			}
+			responses.WithLabelValues(passthroughModeVerbatim).Inc()
+			if _, err := io.Copy(rsp, exp.Body); err != nil {
+				logError("error copying exposition:", err)
+			}
+			return
+		}
+
+		offered := compressions
+		if recompressionSem != nil && httputil.NegotiateContentEncoding(req, compressions) != string(Identity) {
+			select {
+			case recompressionSem <- struct{}{}:
+				defer func() { <-recompressionSem }()
+			default:
+				offered = nil // Over budget, serve uncompressed.
+			}
+		}
+
+		body, closeBody, err := decompress(exp.Body, encoding)
+		if err != nil {
+			logError("error decompressing exposition:", err)
+			httpError(rsp, err)
+			return
+		}
+		defer closeBody()
+
+		w, encodingHeader, closeWriter, err := negotiateEncodingWriter(req, rsp, offered)
+		if err != nil {
+			logError("error getting writer", err)
+			w = io.Writer(rsp)
+			encodingHeader = string(Identity)
+		}
+		defer closeWriter()
+
+		if encodingHeader != string(Identity) {
+			rsp.Header().Set(contentEncodingHeader, encodingHeader)
+			responses.WithLabelValues(passthroughModeRecompressed).Inc()
+		} else {
+			responses.WithLabelValues(passthroughModeDecompressed).Inc()
+		}
+		if _, err := io.Copy(w, body); err != nil {
+			logError("error copying exposition:", err)
+		}
+	})
+}
+
+// decompress returns a reader decompressing r according to the provided
+// content encoding, and a function to release its resources.
+func decompress(r io.Reader, encoding string) (io.Reader, func(), error) {
+	switch encoding {
+	case string(Gzip):
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return gz, func() { _ = gz.Close() }, nil
+	case string(Zstd):
+		z, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return z, z.Close, nil
+	default:
+		return nil, nil, errors.New("unsupported content encoding of exposition: " + encoding)
+	}
+}
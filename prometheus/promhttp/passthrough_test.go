@@ -0,0 +1,234 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package promhttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const passthroughExposition = `# HELP up Whether the target is up.
+# TYPE up gauge
+up 1
+`
+
+func gzipped(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestPassthroughHandler(t *testing.T) {
+	compressed := gzipped(t, passthroughExposition)
+	src := func(*http.Request) (*Exposition, error) {
+		return &Exposition{
+			Format:   expfmt.NewFormat(expfmt.TypeTextPlain),
+			Encoding: Gzip,
+			Body:     io.NopCloser(bytes.NewReader(compressed)),
+		}, nil
+	}
+	reg := prometheus.NewRegistry()
+	h := PassthroughHandler(src, PassthroughOpts{Registry: reg})
+
+	for _, tc := range []struct {
+		acceptEncoding string
+		wantEncoding   string
+	}{
+		{acceptEncoding: "gzip", wantEncoding: "gzip"},
+		{acceptEncoding: "zstd", wantEncoding: "zstd"},
+		{acceptEncoding: "", wantEncoding: ""},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if tc.acceptEncoding != "" {
+			req.Header.Set(acceptEncodingHeader, tc.acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Accept-Encoding %q: got status %d", tc.acceptEncoding, rec.Code)
+		}
+		if got := rec.Header().Get(contentEncodingHeader); got != tc.wantEncoding {
+			t.Errorf("Accept-Encoding %q: got Content-Encoding %q, want %q", tc.acceptEncoding, got, tc.wantEncoding)
+		}
+		if got, want := rec.Header().Get(contentTypeHeader), string(expfmt.NewFormat(expfmt.TypeTextPlain)); got != want {
+			t.Errorf("Accept-Encoding %q: got Content-Type %q, want %q", tc.acceptEncoding, got, want)
+		}
+		var body []byte
+		switch tc.wantEncoding {
+		case "gzip":
+			if !bytes.Equal(rec.Body.Bytes(), compressed) {
+				t.Errorf("gzip body was not passed through verbatim")
+			}
+			continue
+		case "zstd":
+			z, err := zstd.NewReader(rec.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err = io.ReadAll(z)
+			z.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+		default:
+			body = rec.Body.Bytes()
+		}
+		if string(body) != passthroughExposition {
+			t.Errorf("Accept-Encoding %q: got body %q, want %q", tc.acceptEncoding, body, passthroughExposition)
+		}
+	}
+
+	expected := `
+# HELP promhttp_passthrough_responses_total Total number of responses served by the promhttp passthrough handler, by how the exposition was encoded.
+# TYPE promhttp_passthrough_responses_total counter
+promhttp_passthrough_responses_total{mode="decompressed"} 1
+promhttp_passthrough_responses_total{mode="recompressed"} 1
+promhttp_passthrough_responses_total{mode="verbatim"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+// blockingReader signals its first Read and then blocks until released.
+type blockingReader struct {
+	r        io.Reader
+	reading  chan struct{}
+	released chan struct{}
+	started  bool
+}
+
+func (b *blockingReader) Read(p []byte) (int, error) {
+	if !b.started {
+		b.started = true
+		close(b.reading)
+		<-b.released
+	}
+	return b.r.Read(p)
+}
+
+func TestPassthroughHandlerRecompressionBudget(t *testing.T) {
+	compressed := gzipped(t, passthroughExposition)
+	blocking := &blockingReader{
+		r:        bytes.NewReader(compressed),
+		reading:  make(chan struct{}),
+		released: make(chan struct{}),
+	}
+	first := true
+	src := func(*http.Request) (*Exposition, error) {
+		var body io.Reader = bytes.NewReader(compressed)
+		if first {
+			first = false
+			body = blocking
+		}
+		return &Exposition{Encoding: Gzip, Body: io.NopCloser(body)}, nil
+	}
+	h := PassthroughHandler(src, PassthroughOpts{MaxRecompressionsInFlight: 1})
+
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set(acceptEncodingHeader, "zstd")
+		return req
+	}
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, newRequest())
+		done <- rec
+	}()
+	<-blocking.reading
+
+	// The budget is used up by the first request, so the second one is
+	// served uncompressed.
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newRequest())
+	if got := rec.Header().Get(contentEncodingHeader); got != "" {
+		t.Errorf("got Content-Encoding %q while over budget, want none", got)
+	}
+	if got := rec.Body.String(); got != passthroughExposition {
+		t.Errorf("got body %q, want %q", got, passthroughExposition)
+	}
+
+	close(blocking.released)
+	if got := (<-done).Header().Get(contentEncodingHeader); got != "zstd" {
+		t.Errorf("got Content-Encoding %q within budget, want zstd", got)
+	}
+}
+
+func TestPassthroughHandlerSourceError(t *testing.T) {
+	var log logRecorder
+	h := PassthroughHandler(func(*http.Request) (*Exposition, error) {
+		return nil, errors.New("upstream down")
+	}, PassthroughOpts{ErrorLog: &log})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("got status %d, want 500", rec.Code)
+	}
+	if len(log) != 1 || log[0] != "error obtaining exposition: upstream down" {
+		t.Errorf("unexpected log lines: %v", log)
+	}
+}
+
+func TestUpstreamSource(t *testing.T) {
+	compressed := gzipped(t, passthroughExposition)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentTypeHeader, string(expfmt.NewFormat(expfmt.TypeTextPlain)))
+		if strings.Contains(r.Header.Get(acceptEncodingHeader), "gzip") {
+			w.Header().Set(contentEncodingHeader, "gzip")
+			_, _ = w.Write(compressed)
+			return
+		}
+		_, _ = w.Write([]byte(passthroughExposition))
+	}))
+	defer upstream.Close()
+
+	h := PassthroughHandler(UpstreamSource(upstream.Client(), upstream.URL), PassthroughOpts{})
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set(acceptEncodingHeader, "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if got := rec.Header().Get(contentEncodingHeader); got != "gzip" {
+		t.Errorf("got Content-Encoding %q, want gzip", got)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), compressed) {
+		t.Error("upstream body was not passed through verbatim")
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if got := rec.Body.String(); got != passthroughExposition {
+		t.Errorf("got body %q, want %q", got, passthroughExposition)
+	}
+}
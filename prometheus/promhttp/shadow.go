@@ -0,0 +1,342 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package promhttp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Kinds of differences reported by a shadow Gatherer, used as values of the
+// "kind" label of the promhttp_shadow_differences_total metric.
+const (
+	shadowMissingInShadow  = "missing_in_shadow"
+	shadowMissingInPrimary = "missing_in_primary"
+	shadowValueDrift       = "value_drift"
+	shadowTypeMismatch     = "type_mismatch"
+)
+
+// maxShadowExamples is the maximum number of differences listed in the log
+// line of a single shadow scrape.
+const maxShadowExamples = 5
+
+// ShadowOpts specifies options for ShadowGatherer. The zero value of
+// ShadowOpts is a reasonable default.
+type ShadowOpts struct {
+	// SampleEvery configures that only every SampleEvery-th call of Gather
+	// also gathers from the shadow Gatherer and compares the results. If
+	// zero or one, every call of Gather does so.
+	SampleEvery int
+	// Tolerance is the relative difference between a value from the primary
+	// and the shadow Gatherer that is still not considered a value drift.
+	// For example, 0.01 tolerates a difference of 1%. As both Gatherers are
+	// gathered at (slightly) different times, a small tolerance is useful
+	// for frequently changing values. If zero, values have to be equal.
+	Tolerance float64
+	// If Registry is not nil, it is used to register the following
+	// metrics about the comparisons:
+	//   - promhttp_shadow_scrapes_total (counter)
+	//   - promhttp_shadow_gather_errors_total (counter)
+	//   - promhttp_shadow_skipped_comparisons_total (counter)
+	//   - promhttp_shadow_differences_total (counter, by "kind")
+	// The "kind" label is one of missing_in_shadow, missing_in_primary,
+	// value_drift, and type_mismatch. Missing series and value drifts are
+	// counted per series, type mismatches per metric family. If gathering
+	// from the primary or the shadow Gatherer fails, the (possibly
+	// incomplete) results are not compared, and the skipped comparison is
+	// counted instead. Errors of the shadow Gatherer are counted, too.
+	//
+	// If the metrics are already registered with the Registry (e.g. by
+	// another ShadowGatherer), the existing metrics are used.
+	Registry prometheus.Registerer
+	// ErrorLog specifies an optional Logger. If not nil, a line listing
+	// (some of) the differences is logged for each shadow scrape with
+	// differences, and errors gathering from the shadow Gatherer are
+	// logged, too.
+	ErrorLog Logger
+}
+
+// ShadowGatherer returns a Gatherer that serves the metrics of the primary
+// Gatherer while gathering from the shadow Gatherer in parallel for a sample of
+// its Gather calls (see ShadowOpts.SampleEvery). The results of both are
+// compared, and differences (series missing on either side, differing values,
+// differing metric types) are recorded as metrics and optionally logged. The
+// result and the error of the shadow Gatherer never influence the returned
+// metrics or error.
+//
+// This is meant to validate a migration of instrumentation, e.g. to a new
+// registry wiring or another implementation of a Collector, under real scrape
+// traffic: Keep serving the old setup as the primary Gatherer and add the new
+// setup as the shadow Gatherer. Use the returned Gatherer with HandlerFor as
+// usual.
+//
+// The sampled Gather calls take as long as the slower of both Gatherers, plus
+// the time needed to compare the results. For samples, a counter, gauge, or
+// untyped metric is compared by its value, a summary or histogram by its sample
+// count and sample sum.
+func ShadowGatherer(primary, shadow prometheus.Gatherer, opts ShadowOpts) prometheus.Gatherer {
+	g := &shadowGatherer{
+		primary:   primary,
+		shadow:    shadow,
+		every:     uint64(opts.SampleEvery),
+		tolerance: opts.Tolerance,
+		errorLog:  opts.ErrorLog,
+		scrapes: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "promhttp_shadow_scrapes_total",
+			Help: "Total number of scrapes gathered from both the primary and the shadow Gatherer.",
+		}),
+		errs: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "promhttp_shadow_gather_errors_total",
+			Help: "Total number of errors encountered gathering from the shadow Gatherer.",
+		}),
+		skipped: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "promhttp_shadow_skipped_comparisons_total",
+			Help: "Total number of shadow scrapes not compared because gathering from the primary or the shadow Gatherer failed.",
+		}),
+		diffs: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "promhttp_shadow_differences_total",
+				Help: "Total number of differences between the primary and the shadow Gatherer.",
+			},
+			[]string{"kind"},
+		),
+	}
+	if g.every == 0 {
+		g.every = 1
+	}
+	if opts.Registry != nil {
+		// Initialize all possibilities that can occur below.
+		g.diffs.WithLabelValues(shadowMissingInShadow)
+		g.diffs.WithLabelValues(shadowMissingInPrimary)
+		g.diffs.WithLabelValues(shadowValueDrift)
+		g.diffs.WithLabelValues(shadowTypeMismatch)
+		g.scrapes = registerOrExisting(opts.Registry, g.scrapes).(prometheus.Counter)
+		g.errs = registerOrExisting(opts.Registry, g.errs).(prometheus.Counter)
+		g.skipped = registerOrExisting(opts.Registry, g.skipped).(prometheus.Counter)
+		g.diffs = registerOrExisting(opts.Registry, g.diffs).(*prometheus.CounterVec)
+	}
+	return g
+}
+
+// registerOrExisting registers c with reg and returns it. If an equal
+// Collector is already registered, that one is returned instead. Any other
+// registration error causes a panic.
+func registerOrExisting(reg prometheus.Registerer, c prometheus.Collector) prometheus.Collector {
+	if err := reg.Register(c); err != nil {
+		are := &prometheus.AlreadyRegisteredError{}
+		if errors.As(err, are) {
+			return are.ExistingCollector
+		}
+		panic(err)
+	}
+	return c
+}
+
+type shadowGatherer struct {
+	primary, shadow prometheus.Gatherer
+	every           uint64
+	tolerance       float64
+	errorLog        Logger
+
+	calls atomic.Uint64
+
+	scrapes, errs, skipped prometheus.Counter
+	diffs                  *prometheus.CounterVec
+}
+
+func (g *shadowGatherer) Gather() ([]*dto.MetricFamily, error) {
+	if (g.calls.Add(1)-1)%g.every != 0 {
+		return g.primary.Gather()
+	}
+
+	var (
+		wg        sync.WaitGroup
+		shadowMFs []*dto.MetricFamily
+		shadowErr error
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		shadowMFs, shadowErr = g.shadow.Gather()
+	}()
+	mfs, err := g.primary.Gather()
+	wg.Wait()
+
+	g.scrapes.Inc()
+	if shadowErr != nil {
+		g.errs.Inc()
+		if g.errorLog != nil {
+			g.errorLog.Println("error gathering from shadow:", shadowErr)
+		}
+	}
+	if err != nil || shadowErr != nil {
+		// A failed result is possibly incomplete, so comparing it
+		// would report spurious differences.
+		g.skipped.Inc()
+		return mfs, err
+	}
+	g.compare(mfs, shadowMFs)
+	return mfs, err
+}
+
+// shadowSeries is a series as seen by a shadow comparison.
+type shadowSeries struct {
+	family string
+	labels []*dto.LabelPair
+	values []float64
+}
+
+func (s shadowSeries) String() string {
+	lps := make([]string, 0, len(s.labels))
+	for _, lp := range s.labels {
+		lps = append(lps, fmt.Sprintf("%s=%q", lp.GetName(), lp.GetValue()))
+	}
+	return s.family + "{" + strings.Join(lps, ",") + "}"
+}
+
+// compare records the differences between the metric families gathered from
+// the primary and the shadow Gatherer.
+func (g *shadowGatherer) compare(primary, shadow []*dto.MetricFamily) {
+	var (
+		counts   = map[string]int{}
+		examples []string
+	)
+	record := func(kind, what string) {
+		counts[kind]++
+		if len(examples) < maxShadowExamples {
+			examples = append(examples, kind+": "+what)
+		}
+	}
+
+	shadowFamilies := make(map[string]*dto.MetricFamily, len(shadow))
+	for _, mf := range shadow {
+		shadowFamilies[mf.GetName()] = mf
+	}
+	primaryFamilies := make(map[string]struct{}, len(primary))
+	for _, pmf := range primary {
+		primaryFamilies[pmf.GetName()] = struct{}{}
+		smf, ok := shadowFamilies[pmf.GetName()]
+		if !ok {
+			for _, s := range shadowSeriesOf(pmf) {
+				record(shadowMissingInShadow, s.String())
+			}
+			continue
+		}
+		if pmf.GetType() != smf.GetType() {
+			record(shadowTypeMismatch, fmt.Sprintf("%s (%s vs. %s)", pmf.GetName(), pmf.GetType(), smf.GetType()))
+			continue
+		}
+		shadowSeries := map[string]shadowSeries{}
+		for _, s := range shadowSeriesOf(smf) {
+			shadowSeries[s.String()] = s
+		}
+		for _, ps := range shadowSeriesOf(pmf) {
+			key := ps.String()
+			ss, ok := shadowSeries[key]
+			if !ok {
+				record(shadowMissingInShadow, key)
+				continue
+			}
+			delete(shadowSeries, key)
+			if !g.valuesMatch(ps.values, ss.values) {
+				record(shadowValueDrift, fmt.Sprintf("%s (%v vs. %v)", key, ps.values, ss.values))
+			}
+		}
+		for key := range shadowSeries {
+			record(shadowMissingInPrimary, key)
+		}
+	}
+	for _, smf := range shadow {
+		if _, ok := primaryFamilies[smf.GetName()]; ok {
+			continue
+		}
+		for _, s := range shadowSeriesOf(smf) {
+			record(shadowMissingInPrimary, s.String())
+		}
+	}
+
+	total := 0
+	for kind, n := range counts {
+		g.diffs.WithLabelValues(kind).Add(float64(n))
+		total += n
+	}
+	if total > 0 && g.errorLog != nil {
+		g.errorLog.Println(fmt.Sprintf(
+			"shadow scrape found %d differences, e.g.: %s",
+			total, strings.Join(examples, "; "),
+		))
+	}
+}
+
+// valuesMatch returns whether the values of a primary and a shadow series are
+// equal within the configured tolerance.
+func (g *shadowGatherer) valuesMatch(primary, shadow []float64) bool {
+	if len(primary) != len(shadow) {
+		return false
+	}
+	for i, p := range primary {
+		s := shadow[i]
+		if math.IsNaN(p) || math.IsNaN(s) {
+			if math.IsNaN(p) != math.IsNaN(s) {
+				return false
+			}
+			continue
+		}
+		if p == s { // Also covers equal infinities.
+			continue
+		}
+		if math.Abs(p-s) > g.tolerance*math.Max(math.Abs(p), math.Abs(s)) {
+			return false
+		}
+	}
+	return true
+}
+
+// shadowSeriesOf returns the series of mf with their labels sorted and the
+// values to compare.
+func shadowSeriesOf(mf *dto.MetricFamily) []shadowSeries {
+	series := make([]shadowSeries, 0, len(mf.GetMetric()))
+	for _, m := range mf.GetMetric() {
+		labels := append([]*dto.LabelPair(nil), m.GetLabel()...)
+		sort.Slice(labels, func(i, j int) bool { return labels[i].GetName() < labels[j].GetName() })
+		s := shadowSeries{family: mf.GetName(), labels: labels}
+		switch {
+		case m.Counter != nil:
+			s.values = []float64{m.Counter.GetValue()}
+		case m.Gauge != nil:
+			s.values = []float64{m.Gauge.GetValue()}
+		case m.Untyped != nil:
+			s.values = []float64{m.Untyped.GetValue()}
+		case m.Summary != nil:
+			s.values = []float64{float64(m.Summary.GetSampleCount()), m.Summary.GetSampleSum()}
+		case m.Histogram != nil:
+			count := m.Histogram.GetSampleCountFloat()
+			if count == 0 {
+				count = float64(m.Histogram.GetSampleCount())
+			}
+			s.values = []float64{count, m.Histogram.GetSampleSum()}
+		}
+		series = append(series, s)
+	}
+	return series
+}
@@ -0,0 +1,183 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package promhttp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type logRecorder []string
+
+func (l *logRecorder) Println(v ...interface{}) {
+	s := make([]string, 0, len(v))
+	for _, x := range v {
+		if str, ok := x.(string); ok {
+			s = append(s, str)
+		} else if err, ok := x.(error); ok {
+			s = append(s, err.Error())
+		}
+	}
+	*l = append(*l, strings.Join(s, " "))
+}
+
+func TestShadowGatherer(t *testing.T) {
+	primary := prometheus.NewRegistry()
+	shadow := prometheus.NewRegistry()
+	self := prometheus.NewRegistry()
+
+	pReqs := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests_total", Help: "Requests."}, []string{"code"})
+	sReqs := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests_total", Help: "Requests."}, []string{"code"})
+	pReqs.WithLabelValues("200").Add(100)
+	sReqs.WithLabelValues("200").Add(100.5) // Within tolerance.
+	pReqs.WithLabelValues("500").Add(10)
+	sReqs.WithLabelValues("500").Add(20) // Drift.
+	pReqs.WithLabelValues("404").Add(1)  // Missing in shadow.
+	sReqs.WithLabelValues("503").Add(1)  // Missing in primary.
+	primary.MustRegister(pReqs, prometheus.NewGauge(prometheus.GaugeOpts{Name: "temp", Help: "Temp."}))
+	shadow.MustRegister(sReqs, prometheus.NewCounter(prometheus.CounterOpts{Name: "temp", Help: "Temp."}))
+
+	var log logRecorder
+	g := ShadowGatherer(primary, shadow, ShadowOpts{
+		SampleEvery: 2,
+		Tolerance:   0.01,
+		Registry:    self,
+		ErrorLog:    &log,
+	})
+
+	want, err := primary.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		got, err := g.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got %d metric families, want the %d of the primary", len(got), len(want))
+		}
+	}
+
+	// Only the first and third call are sampled.
+	expected := `
+# HELP promhttp_shadow_differences_total Total number of differences between the primary and the shadow Gatherer.
+# TYPE promhttp_shadow_differences_total counter
+promhttp_shadow_differences_total{kind="missing_in_primary"} 2
+promhttp_shadow_differences_total{kind="missing_in_shadow"} 2
+promhttp_shadow_differences_total{kind="type_mismatch"} 2
+promhttp_shadow_differences_total{kind="value_drift"} 2
+# HELP promhttp_shadow_gather_errors_total Total number of errors encountered gathering from the shadow Gatherer.
+# TYPE promhttp_shadow_gather_errors_total counter
+promhttp_shadow_gather_errors_total 0
+# HELP promhttp_shadow_scrapes_total Total number of scrapes gathered from both the primary and the shadow Gatherer.
+# TYPE promhttp_shadow_scrapes_total counter
+promhttp_shadow_scrapes_total 2
+# HELP promhttp_shadow_skipped_comparisons_total Total number of shadow scrapes not compared because gathering from the primary or the shadow Gatherer failed.
+# TYPE promhttp_shadow_skipped_comparisons_total counter
+promhttp_shadow_skipped_comparisons_total 0
+`
+	if err := testutil.GatherAndCompare(self, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+	if len(log) != 2 {
+		t.Fatalf("got %d log lines, want 2: %v", len(log), log)
+	}
+	for _, want := range []string{
+		"shadow scrape found 4 differences",
+		`missing_in_shadow: requests_total{code="404"}`,
+		`missing_in_primary: requests_total{code="503"}`,
+		`value_drift: requests_total{code="500"} ([10] vs. [20])`,
+		"type_mismatch: temp (GAUGE vs. COUNTER)",
+	} {
+		if !strings.Contains(log[0], want) {
+			t.Errorf("log line %q does not contain %q", log[0], want)
+		}
+	}
+}
+
+func TestShadowGathererShadowError(t *testing.T) {
+	primary := prometheus.NewRegistry()
+	primary.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "up", Help: "Up."}))
+	shadow := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return nil, errors.New("shadow broken")
+	})
+	self := prometheus.NewRegistry()
+
+	var log logRecorder
+	g := ShadowGatherer(primary, shadow, ShadowOpts{Registry: self, ErrorLog: &log})
+	mfs, err := g.Gather()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mfs) != 1 {
+		t.Errorf("got %d metric families, want 1", len(mfs))
+	}
+	if got := testutil.ToFloat64(g.(*shadowGatherer).errs); got != 1 {
+		t.Errorf("got %v shadow gather errors, want 1", got)
+	}
+	if got := testutil.ToFloat64(g.(*shadowGatherer).diffs.WithLabelValues(shadowMissingInShadow)); got != 0 {
+		t.Errorf("got %v series missing in shadow, want 0 as the failed shadow scrape must not be compared", got)
+	}
+	if got := testutil.ToFloat64(g.(*shadowGatherer).skipped); got != 1 {
+		t.Errorf("got %v skipped comparisons, want 1", got)
+	}
+	if len(log) != 1 || log[0] != "error gathering from shadow: shadow broken" {
+		t.Errorf("unexpected log lines: %v", log)
+	}
+}
+
+func TestShadowGathererPrimaryError(t *testing.T) {
+	// The primary returns partial results with an error.
+	primary := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return []*dto.MetricFamily{{
+			Name:   proto.String("up"),
+			Type:   dto.MetricType_GAUGE.Enum(),
+			Metric: []*dto.Metric{{Gauge: &dto.Gauge{Value: proto.Float64(1)}}},
+		}}, errors.New("primary partially broken")
+	})
+	shadow := prometheus.NewRegistry()
+	shadow.MustRegister(
+		prometheus.NewGauge(prometheus.GaugeOpts{Name: "up", Help: "Up."}),
+		prometheus.NewGauge(prometheus.GaugeOpts{Name: "other", Help: "Other."}),
+	)
+	self := prometheus.NewRegistry()
+
+	var log logRecorder
+	g := ShadowGatherer(primary, shadow, ShadowOpts{Registry: self, ErrorLog: &log})
+	mfs, err := g.Gather()
+	if err == nil || len(mfs) != 1 {
+		t.Errorf("got %d metric families and error %v, want the partial result and the error of the primary", len(mfs), err)
+	}
+	sg := g.(*shadowGatherer)
+	if got := testutil.ToFloat64(sg.skipped); got != 1 {
+		t.Errorf("got %v skipped comparisons, want 1", got)
+	}
+	if got := testutil.ToFloat64(sg.errs); got != 0 {
+		t.Errorf("got %v shadow gather errors, want 0", got)
+	}
+	if got := testutil.ToFloat64(sg.diffs.WithLabelValues(shadowMissingInPrimary)); got != 0 {
+		t.Errorf("got %v series missing in primary, want 0 as the failed primary scrape must not be compared", got)
+	}
+	if len(log) != 0 {
+		t.Errorf("unexpected log lines: %v", log)
+	}
+}
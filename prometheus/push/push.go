@@ -29,6 +29,11 @@
 //
 // See the examples section for more detailed examples.
 //
+// Pushing works on all targets the prometheus package builds for, including
+// js/wasm and wasip1/wasm. Where the default http.Client cannot reach the
+// Pushgateway (e.g. on wasip1 without socket support), set a custom HTTPDoer
+// with the Client method.
+//
 // See the documentation of the Pushgateway to understand the meaning of
 // the grouping key and the differences between Push and Add:
 // https://github.com/prometheus/pushgateway
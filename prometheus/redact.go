@@ -0,0 +1,218 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/prometheus/client_golang/prometheus/internal"
+)
+
+// LabelRedactor transforms a label value into a redacted form, e.g. by hashing
+// or truncating it. It must return a valid UTF-8 string. See HashRedactor and
+// TruncateRedactor for ready-to-use implementations.
+type LabelRedactor func(value string) string
+
+// LabelRedactionPolicy maps label names to the LabelRedactor to apply to the
+// values of labels with that name.
+type LabelRedactionPolicy map[string]LabelRedactor
+
+// HashRedactor returns a LabelRedactor that replaces a label value by the first
+// 16 hexadecimal digits of the SHA-256 hash of the salt followed by the value.
+// Equal values are still mapped to equal hashes, so that the redacted label
+// keeps its usefulness for aggregations. The empty value is left untouched, as
+// it is equivalent to a missing label. Use a secret salt to make it hard to
+// recover values from their hashes by trying out candidate values.
+func HashRedactor(salt string) LabelRedactor {
+	return func(value string) string {
+		if value == "" {
+			return ""
+		}
+		sum := sha256.Sum256([]byte(salt + value))
+		return hex.EncodeToString(sum[:8])
+	}
+}
+
+// TruncateRedactor returns a LabelRedactor that truncates a label value to at
+// most maxRunes runes.
+func TruncateRedactor(maxRunes int) LabelRedactor {
+	return func(value string) string {
+		if utf8.RuneCountInString(value) <= maxRunes {
+			return value
+		}
+		runes := 0
+		for i := range value {
+			if runes == maxRunes {
+				return value[:i]
+			}
+			runes++
+		}
+		return value
+	}
+}
+
+// NewRedactingGatherer returns a Gatherer that gathers from the provided
+// Gatherer and applies the provided policy to the result: The value of each
+// label (including the labels of exemplars) with a name in the policy is
+// transformed by the corresponding LabelRedactor. This provides a central
+// enforcement point for privacy requirements (e.g. hashing user IDs) rather
+// than relying on every instrumentation call site to sanitize label values.
+// Use the returned Gatherer to expose metrics, e.g. with promhttp.HandlerFor.
+//
+// The metric families returned by the wrapped Gatherer are not modified.
+// Metrics with redacted labels are copied instead. If redaction results in
+// several metrics of a metric family with identical labels (and timestamps),
+// only the first one is kept, and an error is returned together with the
+// redacted metric families (in the same way as a Registry reports inconsistent
+// metrics). Choose redactors that are unlikely to cause such collisions, e.g.
+// HashRedactor rather than a TruncateRedactor with a small limit.
+func NewRedactingGatherer(g Gatherer, policy LabelRedactionPolicy) Gatherer {
+	return GathererFunc(func() ([]*dto.MetricFamily, error) {
+		mfs, err := g.Gather()
+		if len(policy) == 0 {
+			return mfs, err
+		}
+		var errs MultiError
+		if err != nil {
+			if multiErr, ok := err.(MultiError); ok {
+				errs = multiErr
+			} else {
+				errs = MultiError{err}
+			}
+		}
+		redacted := make([]*dto.MetricFamily, 0, len(mfs))
+		for _, mf := range mfs {
+			redactedMF, err := redactMetricFamily(mf, policy)
+			errs.Append(err)
+			redacted = append(redacted, redactedMF)
+		}
+		return redacted, errs.MaybeUnwrap()
+	})
+}
+
+// redactMetricFamily applies policy to mf. It returns mf itself if no label
+// had to be redacted, and a modified copy otherwise.
+func redactMetricFamily(mf *dto.MetricFamily, policy LabelRedactionPolicy) (*dto.MetricFamily, error) {
+	var metrics []*dto.Metric // Only allocated once the first metric is redacted.
+	for i, m := range mf.Metric {
+		if !needsRedaction(m, policy) {
+			if metrics != nil {
+				metrics = append(metrics, m)
+			}
+			continue
+		}
+		if metrics == nil {
+			metrics = make([]*dto.Metric, i, len(mf.Metric))
+			copy(metrics, mf.Metric[:i])
+		}
+		m = proto.Clone(m).(*dto.Metric)
+		redactLabelPairs(m.Label, policy)
+		redactExemplars(m, policy)
+		metrics = append(metrics, m)
+	}
+	if metrics == nil {
+		return mf, nil
+	}
+
+	// Redaction may have changed the order and may have created duplicates.
+	sort.Stable(internal.MetricSorter(metrics))
+	deduped := metrics[:1]
+	duplicates := 0
+	for _, m := range metrics[1:] {
+		last := deduped[len(deduped)-1]
+		if m.GetTimestampMs() == last.GetTimestampMs() && labelPairsEqual(m.Label, last.Label) {
+			duplicates++
+			continue
+		}
+		deduped = append(deduped, m)
+	}
+
+	redacted := &dto.MetricFamily{
+		Name:   mf.Name,
+		Help:   mf.Help,
+		Type:   mf.Type,
+		Unit:   mf.Unit,
+		Metric: deduped,
+	}
+	if duplicates > 0 {
+		return redacted, fmt.Errorf(
+			"label redaction resulted in %d duplicate metric(s) in metric family %q, which have been dropped",
+			duplicates, mf.GetName(),
+		)
+	}
+	return redacted, nil
+}
+
+func needsRedaction(m *dto.Metric, policy LabelRedactionPolicy) bool {
+	for _, lp := range m.Label {
+		if _, ok := policy[lp.GetName()]; ok {
+			return true
+		}
+	}
+	for _, e := range exemplarsOf(m) {
+		for _, lp := range e.Label {
+			if _, ok := policy[lp.GetName()]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func redactLabelPairs(lps []*dto.LabelPair, policy LabelRedactionPolicy) {
+	for _, lp := range lps {
+		if redact, ok := policy[lp.GetName()]; ok {
+			lp.Value = proto.String(redact(lp.GetValue()))
+		}
+	}
+}
+
+func redactExemplars(m *dto.Metric, policy LabelRedactionPolicy) {
+	for _, e := range exemplarsOf(m) {
+		redactLabelPairs(e.Label, policy)
+	}
+}
+
+// exemplarsOf returns all exemplars of m.
+func exemplarsOf(m *dto.Metric) []*dto.Exemplar {
+	var exemplars []*dto.Exemplar
+	if e := m.GetCounter().GetExemplar(); e != nil {
+		exemplars = append(exemplars, e)
+	}
+	for _, b := range m.GetHistogram().GetBucket() {
+		if e := b.GetExemplar(); e != nil {
+			exemplars = append(exemplars, e)
+		}
+	}
+	return append(exemplars, m.GetHistogram().GetExemplars()...)
+}
+
+func labelPairsEqual(a, b []*dto.LabelPair) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].GetName() != b[i].GetName() || a[i].GetValue() != b[i].GetValue() {
+			return false
+		}
+	}
+	return true
+}
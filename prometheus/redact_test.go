@@ -0,0 +1,115 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestHashRedactor(t *testing.T) {
+	redact := HashRedactor("salt")
+	a := redact("alice@example.org")
+	if len(a) != 16 {
+		t.Errorf("got hash %q, want 16 hex digits", a)
+	}
+	if a != redact("alice@example.org") {
+		t.Error("hash is not stable")
+	}
+	if a == redact("bob@example.org") || a == HashRedactor("pepper")("alice@example.org") {
+		t.Error("hash does not depend on value and salt")
+	}
+	if got := redact(""); got != "" {
+		t.Errorf("got %q for empty value, want empty value", got)
+	}
+}
+
+func TestTruncateRedactor(t *testing.T) {
+	redact := TruncateRedactor(3)
+	for value, want := range map[string]string{
+		"":       "",
+		"ab":     "ab",
+		"abc":    "abc",
+		"abcdef": "abc",
+		"äöüß":   "äöü",
+	} {
+		if got := redact(value); got != want {
+			t.Errorf("%q: got %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestRedactingGatherer(t *testing.T) {
+	reg := NewRegistry()
+	logins := NewCounterVec(CounterOpts{Name: "logins_total", Help: "Logins."}, []string{"user", "result"})
+	reg.MustRegister(logins)
+	logins.WithLabelValues("alice@example.org", "ok").Add(2)
+	logins.WithLabelValues("alice@example.com", "ok").Add(3)
+	logins.WithLabelValues("bob@example.org", "failed").(ExemplarAdder).AddWithExemplar(1, Labels{"user": "bob@example.org"})
+
+	g := NewRedactingGatherer(reg, LabelRedactionPolicy{
+		"user": TruncateRedactor(5), // Truncates both alice addresses to "alice".
+	})
+	mfs, err := g.Gather()
+	if err == nil || !strings.Contains(err.Error(), `1 duplicate metric(s) in metric family "logins_total"`) {
+		t.Errorf("expected duplicate error, got %v", err)
+	}
+	if len(mfs) != 1 {
+		t.Fatalf("got %d metric families, want 1", len(mfs))
+	}
+	want := []*dto.Metric{
+		{
+			Label: []*dto.LabelPair{{Name: proto.String("result"), Value: proto.String("failed")}, {Name: proto.String("user"), Value: proto.String("bob@e")}},
+			Counter: &dto.Counter{
+				Value:    proto.Float64(1),
+				Exemplar: &dto.Exemplar{Label: []*dto.LabelPair{{Name: proto.String("user"), Value: proto.String("bob@e")}}},
+			},
+		},
+		{
+			Label:   []*dto.LabelPair{{Name: proto.String("result"), Value: proto.String("ok")}, {Name: proto.String("user"), Value: proto.String("alice")}},
+			Counter: &dto.Counter{Value: proto.Float64(3)},
+		},
+	}
+	got := mfs[0].GetMetric()
+	if len(got) != len(want) {
+		t.Fatalf("got %d metrics, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		got[i].Counter.CreatedTimestamp = nil
+		if got[i].Counter.Exemplar != nil {
+			got[i].Counter.Exemplar.Value = nil
+			got[i].Counter.Exemplar.Timestamp = nil
+		}
+		if !proto.Equal(got[i], want[i]) {
+			t.Errorf("metric %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	// Without redacted labels, the metric families are passed through.
+	g = NewRedactingGatherer(reg, LabelRedactionPolicy{"email": HashRedactor("")})
+	mfs, err = g.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range mfs[0].GetMetric() {
+		for _, lp := range m.GetLabel() {
+			if lp.GetName() == "user" && !strings.Contains(lp.GetValue(), "@example.") {
+				t.Errorf("unexpected redaction of %v", lp)
+			}
+		}
+	}
+}
@@ -20,10 +20,12 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"runtime/debug"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 	"unicode/utf8"
 
 	"github.com/prometheus/client_golang/prometheus/internal"
@@ -66,6 +68,7 @@ func init() {
 // pre-registered.
 func NewRegistry() *Registry {
 	return &Registry{
+		mtx:             profiledRWMutex{kind: lockKindRegistry},
 		collectorsByID:  map[uint64]Collector{},
 		descIDs:         map[uint64]struct{}{},
 		dimHashesByName: map[string]uint64{},
@@ -258,12 +261,201 @@ func (errs MultiError) MaybeUnwrap() error {
 // Registry implements Collector to allow it to be used for creating groups of
 // metrics. See the Grouping example for how this can be done.
 type Registry struct {
-	mtx                   sync.RWMutex
+	mtx                   profiledRWMutex
 	collectorsByID        map[uint64]Collector // ID is a hash of the descIDs.
 	descIDs               map[uint64]struct{}
 	dimHashesByName       map[string]uint64
 	uncheckedCollectors   []Collector
+	collectorInfos        map[uint64]collectorInfo // By collector ID.
+	uncheckedInfos        []collectorInfo          // By index in uncheckedCollectors.
 	pedanticChecksEnabled bool
+	provenance            CollectorProvenanceOpts
+	histogramDefaults     HistogramDefaults
+	paused                map[uint64]pausedCollector // By collector ID.
+	lastGather            map[collectorKey]*CollectorGatherStats
+	pendingUnregistration map[uint64]pendingUnregistration // By collector ID.
+}
+
+// collectorInfo is what a Registry records about a Collector at registration
+// time, so that it doesn't need to call its Describe method again.
+type collectorInfo struct {
+	identity string // See collectorIdentity.
+	descs    int    // Number of distinct Descs.
+}
+
+// collectorKey identifies a registered Collector. Checked Collectors are
+// identified by their ID, unchecked Collectors by their index in
+// uncheckedCollectors (as they cannot be unregistered).
+type collectorKey struct {
+	id        uint64
+	unchecked int // -1 for checked Collectors.
+}
+
+// CollectorProvenanceOpts configures how a Registry attributes errors during
+// Gather to the Collector that caused them. See
+// Registry.SetCollectorProvenance.
+type CollectorProvenanceOpts struct {
+	// Enabled causes each error caused by a collected Metric to be wrapped
+	// in a *CollectorError identifying the Collector that has collected
+	// the Metric.
+	Enabled bool
+	// RecoverPanics causes panics in the Collect method of a Collector to
+	// be recovered and reported as a *CollectorError (rather than crashing
+	// the program). It requires Enabled to be true. Note that a Collector
+	// that panics might not have collected all its metrics and might leave
+	// its internal state inconsistent.
+	RecoverPanics bool
+	// MaxStackBytes is the maximum size of the stack trace of a recovered
+	// panic to be included in the CollectorError. If zero, no stack trace
+	// is included.
+	MaxStackBytes int
+}
+
+// SetCollectorProvenance configures the Registry to attribute errors during
+// Gather to the Collector that caused them, see CollectorProvenanceOpts for
+// details. By default, provenance is disabled, which avoids its overhead (an
+// additional goroutine per Collector and Gather call and the wrapping of each
+// collected Metric).
+//
+// Provenance is helpful to debug errors found by a pedantic Registry (see
+// NewPedanticRegistry) or inconsistent metrics in large programs with many
+// Collectors. It can be changed at any time, but it only affects Gather calls
+// started afterwards.
+//
+// With provenance enabled, the Registry also records the duration, the
+// collected metric families, and the errors of the last collection of each
+// Collector, see RegisteredCollector.LastGather.
+func (r *Registry) SetCollectorProvenance(opts CollectorProvenanceOpts) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.provenance = opts
+}
+
+// SetHistogramDefaults sets the HistogramDefaults of the Registry. The Registry
+// itself does not apply them, as Histograms are created before they are
+// registered. Instead, the Factory of the promauto package applies them to the
+// HistogramOpts of all Histograms and HistogramVecs it creates for the
+// Registry (also if the Registry is wrapped, see WrapRegistererWith). When
+// creating Histograms without promauto, apply the defaults explicitly with
+// HistogramDefaults.Apply.
+//
+// A typical use case is setting the defaults for DefaultRegisterer in a module
+// shared across many services, so that the bucket layout of all Histograms
+// created with promauto can be changed in one place. The defaults only affect
+// Histograms created after the call.
+func (r *Registry) SetHistogramDefaults(d HistogramDefaults) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.histogramDefaults = d
+}
+
+// HistogramDefaults returns the HistogramDefaults set with
+// SetHistogramDefaults.
+func (r *Registry) HistogramDefaults() HistogramDefaults {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	return r.histogramDefaults
+}
+
+// CollectorError is an error caused by a Collector during Gather. Registries
+// only return it if provenance is enabled, see Registry.SetCollectorProvenance.
+// In the error returned by Gather, it is usually contained in a MultiError.
+type CollectorError struct {
+	// Collector is the Collector that has collected the offending Metric
+	// or has panicked.
+	Collector Collector
+	// Err is the underlying error.
+	Err error
+	// Stack is the (possibly truncated) stack trace of the goroutine at
+	// the time of a panic. It is empty if Err is not caused by a panic or
+	// if no stack trace has been requested. It is not part of the string
+	// returned by Error.
+	Stack string
+
+	// identity is the identity of Collector recorded by the Registry at
+	// registration time.
+	identity string
+}
+
+// Error implements error.
+func (e *CollectorError) Error() string {
+	id := e.identity
+	if id == "" {
+		// Not created by a Registry. Don't call Describe here.
+		id = identityOf(e.Collector, nil)
+	}
+	return fmt.Sprintf("collector %s: %s", id, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *CollectorError) Unwrap() error {
+	return e.Err
+}
+
+// collectorIdentity returns a human-readable identity of c, i.e. its type, its
+// string representation if it implements fmt.Stringer, and the names of the
+// Descs it describes, so that Collectors of the same type can be told apart.
+// A Collector registered through a wrapping Registerer is identified by the
+// type of the originally registered Collector and the names of the wrapped
+// Descs.
+func collectorIdentity(c Collector) string {
+	return identityOf(c, describedNames(c))
+}
+
+// identityOf returns the identity of c as described for collectorIdentity,
+// using the provided names as the names of the Descs of c.
+func identityOf(c Collector, names []string) string {
+	original := c
+	if wc, ok := c.(*wrappingCollector); ok {
+		original = wc.unwrapRecursively()
+	}
+	id := fmt.Sprintf("%T", original)
+	if s, ok := original.(fmt.Stringer); ok {
+		id = fmt.Sprintf("%s (%s)", id, s)
+	}
+	if len(names) > 0 {
+		id = fmt.Sprintf("%s [%s]", id, strings.Join(names, ", "))
+	}
+	return id
+}
+
+// maxIdentityNames is the maximum number of Desc names included in the
+// identity of a Collector.
+const maxIdentityNames = 3
+
+// describedNames returns the sorted and deduplicated fully-qualified names of
+// the Descs described by c, limited to maxIdentityNames. If names have been
+// left out, the last element states their number.
+func describedNames(c Collector) []string {
+	descChan := make(chan *Desc, capDescChan)
+	go func() {
+		c.Describe(descChan)
+		close(descChan)
+	}()
+	seen := map[string]struct{}{}
+	for desc := range descChan {
+		if desc.err == nil && desc.fqName != "" {
+			seen[desc.fqName] = struct{}{}
+		}
+	}
+	return identityNames(seen)
+}
+
+// identityNames returns the provided set of names sorted and limited as
+// described for describedNames.
+func identityNames(seen map[string]struct{}) []string {
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) > maxIdentityNames {
+		names = append(names[:maxIdentityNames], fmt.Sprintf("+%d more", len(names)-maxIdentityNames))
+	}
+	return names
 }
 
 // Register implements Registerer.
@@ -274,6 +466,8 @@ func (r *Registry) Register(c Collector) error {
 		newDimHashesByName = map[string]uint64{}
 		collectorID        uint64 // All desc IDs XOR'd together.
 		duplicateDescErr   error
+		replacedIDs        = map[uint64]struct{}{} // Collectors pending unregistration.
+		names              = map[string]struct{}{} // For the identity of the Collector.
 	)
 	go func() {
 		c.Describe(descChan)
@@ -296,7 +490,11 @@ func (r *Registry) Register(c Collector) error {
 
 		// Is the descID unique?
 		// (In other words: Is the fqName + constLabel combination unique?)
-		if _, exists := r.descIDs[desc.id]; exists {
+		// Collectors pending unregistration are replaced rather than
+		// causing a conflict.
+		if id, pending := r.pendingOwnerOf(desc.id); pending {
+			replacedIDs[id] = struct{}{}
+		} else if _, exists := r.descIDs[desc.id]; exists {
 			duplicateDescErr = fmt.Errorf("descriptor %s already exists with the same fully-qualified name and const label values", desc)
 		}
 		// If it is not a duplicate desc in this collector, XOR it to
@@ -306,6 +504,9 @@ func (r *Registry) Register(c Collector) error {
 			newDescIDs[desc.id] = struct{}{}
 			collectorID ^= desc.id
 		}
+		if desc.fqName != "" {
+			names[desc.fqName] = struct{}{}
+		}
 
 		// Are all the label names and the help string consistent with
 		// previous descriptors of the same name?
@@ -329,9 +530,12 @@ func (r *Registry) Register(c Collector) error {
 	// A Collector yielding no Desc at all is considered unchecked.
 	if len(newDescIDs) == 0 {
 		r.uncheckedCollectors = append(r.uncheckedCollectors, c)
+		r.uncheckedInfos = append(r.uncheckedInfos, collectorInfo{identity: identityOf(c, nil)})
 		return nil
 	}
-	if existing, exists := r.collectorsByID[collectorID]; exists {
+	if _, pending := r.pendingUnregistration[collectorID]; pending {
+		replacedIDs[collectorID] = struct{}{}
+	} else if existing, exists := r.collectorsByID[collectorID]; exists {
 		switch e := existing.(type) {
 		case *wrappingCollector:
 			return AlreadyRegisteredError{
@@ -352,7 +556,17 @@ func (r *Registry) Register(c Collector) error {
 	}
 
 	// Only after all tests have passed, actually register.
+	for id := range replacedIDs {
+		r.unregister(id, r.pendingUnregistration[id].descIDs)
+	}
 	r.collectorsByID[collectorID] = c
+	if r.collectorInfos == nil {
+		r.collectorInfos = map[uint64]collectorInfo{}
+	}
+	r.collectorInfos[collectorID] = collectorInfo{
+		identity: identityOf(c, identityNames(names)),
+		descs:    len(newDescIDs),
+	}
 	for hash := range newDescIDs {
 		r.descIDs[hash] = struct{}{}
 	}
@@ -364,10 +578,113 @@ func (r *Registry) Register(c Collector) error {
 
 // Unregister implements Registerer.
 func (r *Registry) Unregister(c Collector) bool {
+	collectorID, descIDs := collectorIDOf(c)
+
+	r.mtx.RLock()
+	if _, exists := r.collectorsByID[collectorID]; !exists {
+		r.mtx.RUnlock()
+		return false
+	}
+	r.mtx.RUnlock()
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.unregister(collectorID, descIDs)
+	return true
+}
+
+// unregister removes the checked Collector with the provided ID and Desc IDs.
+// The caller must hold the write lock of r.mtx.
+func (r *Registry) unregister(collectorID uint64, descIDs map[uint64]struct{}) {
+	delete(r.collectorsByID, collectorID)
+	delete(r.collectorInfos, collectorID)
+	delete(r.paused, collectorID)
+	delete(r.lastGather, collectorKey{id: collectorID, unchecked: -1})
+	delete(r.pendingUnregistration, collectorID)
+	for id := range descIDs {
+		delete(r.descIDs, id)
+	}
+	// dimHashesByName is left untouched as those must be consistent
+	// throughout the lifetime of a program.
+}
+
+// UnregisterAfter unregisters the provided Collector after a grace period,
+// which avoids gaps in the exposed series while a Collector is being replaced,
+// e.g. during a rolling reload of a configuration. Until then, the Collector
+// stays registered and keeps being collected. It is unregistered after the
+// first Gather that has started once d has elapsed has collected it. Thus, if
+// d is not positive, it is unregistered after the next Gather.
+//
+// Registering a Collector with the same Descs as the Collector, or with any
+// Desc in common with it, unregisters the Collector immediately, so that its
+// replacement can be registered during the grace period. Unregister also
+// unregisters the Collector immediately, and calling UnregisterAfter again
+// replaces the grace period.
+//
+// The Collector is identified in the same way as by Unregister. UnregisterAfter
+// returns whether the Collector has been registered. Unchecked Collectors
+// cannot be unregistered.
+func (r *Registry) UnregisterAfter(c Collector, d time.Duration) bool {
+	collectorID, descIDs := collectorIDOf(c)
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if _, exists := r.collectorsByID[collectorID]; !exists {
+		return false
+	}
+	if r.pendingUnregistration == nil {
+		r.pendingUnregistration = map[uint64]pendingUnregistration{}
+	}
+	r.pendingUnregistration[collectorID] = pendingUnregistration{
+		deadline: time.Now().Add(d),
+		descIDs:  descIDs,
+	}
+	return true
+}
+
+// pendingUnregistration describes a Collector to be unregistered after a grace
+// period, see Registry.UnregisterAfter.
+type pendingUnregistration struct {
+	deadline time.Time
+	descIDs  map[uint64]struct{}
+}
+
+// pendingOwnerOf returns the ID of the Collector pending unregistration that
+// has described the Desc with the provided ID, if any. The caller must hold at
+// least a read lock of r.mtx.
+func (r *Registry) pendingOwnerOf(descID uint64) (uint64, bool) {
+	for id, p := range r.pendingUnregistration {
+		if _, ok := p.descIDs[descID]; ok {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// applyPendingUnregistrations unregisters the Collectors pending unregistration
+// whose grace period has ended before start, the time a Gather collecting them
+// has started.
+func (r *Registry) applyPendingUnregistrations(start time.Time) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	for id, p := range r.pendingUnregistration {
+		if !p.deadline.After(start) {
+			r.unregister(id, p.descIDs)
+		}
+	}
+}
+
+// collectorIDOf returns the ID of c (all IDs of its Descs XOR'd together) as
+// used by the Registry to identify a checked Collector, together with the set
+// of the IDs of its Descs.
+func collectorIDOf(c Collector) (uint64, map[uint64]struct{}) {
 	var (
 		descChan    = make(chan *Desc, capDescChan)
 		descIDs     = map[uint64]struct{}{}
-		collectorID uint64 // All desc IDs XOR'd together.
+		collectorID uint64
 	)
 	go func() {
 		c.Describe(descChan)
@@ -379,26 +696,241 @@ func (r *Registry) Unregister(c Collector) bool {
 			descIDs[desc.id] = struct{}{}
 		}
 	}
+	return collectorID, descIDs
+}
+
+// PauseMode determines what a Registry exposes for a paused Collector, see
+// Registry.Pause.
+type PauseMode int
+
+const (
+	// PauseOmit omits the metrics of a paused Collector, so that its series
+	// are marked stale by the Prometheus server.
+	PauseOmit PauseMode = iota
+	// PauseFreeze exposes a snapshot of the metrics of a paused Collector,
+	// collected at the time of pausing. The series thus keep existing with
+	// frozen values.
+	PauseFreeze
+)
+
+// Pause pauses the collection of the provided Collector, which has to be
+// registered with the Registry, e.g. to silence an expensive or misbehaving
+// Collector during a maintenance window. While paused, Gather and Collect
+// don't call the Collect method of the Collector but expose its metrics
+// according to the provided PauseMode. The Collector stays registered, and
+// Resume restores normal collection. Unregistering a paused Collector also
+// ends the pause.
+//
+// In mode PauseFreeze, the Collector is collected once during the call of
+// Pause to take the snapshot. Pausing an already paused Collector replaces
+// the previous pause (and takes a new snapshot if needed).
+//
+// The Collector is identified by its Descs, in the same way as by Unregister.
+// If it has been registered through a wrapping Registerer (see
+// WrapRegistererWith), the wrapped form has to be provided, which can be
+// obtained from RegisteredCollectors. Unchecked Collectors (which don't
+// describe any Desc) cannot be paused. An error is returned if the Collector
+// is not registered (as a checked Collector).
+//
+// Pause and Resume are meant to be invoked rarely, e.g. from an admin
+// endpoint.
+func (r *Registry) Pause(c Collector, mode PauseMode) error {
+	collectorID, descIDs := collectorIDOf(c)
+	if len(descIDs) == 0 {
+		return errors.New("unchecked collectors cannot be paused")
+	}
+	var frozen Collector
+	if mode == PauseFreeze {
+		frozen = freezeCollector(c)
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
 
-	r.mtx.RLock()
 	if _, exists := r.collectorsByID[collectorID]; !exists {
-		r.mtx.RUnlock()
-		return false
+		return fmt.Errorf("collector %s is not registered", collectorIdentity(c))
 	}
-	r.mtx.RUnlock()
+	if r.paused == nil {
+		r.paused = map[uint64]pausedCollector{}
+	}
+	r.paused[collectorID] = pausedCollector{frozen: frozen}
+	return nil
+}
+
+// Resume resumes the collection of a Collector paused with Pause. It returns
+// whether the Collector has been paused.
+func (r *Registry) Resume(c Collector) bool {
+	collectorID, _ := collectorIDOf(c)
 
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
-	delete(r.collectorsByID, collectorID)
-	for id := range descIDs {
-		delete(r.descIDs, id)
+	if _, exists := r.paused[collectorID]; !exists {
+		return false
 	}
-	// dimHashesByName is left untouched as those must be consistent
-	// throughout the lifetime of a program.
+	delete(r.paused, collectorID)
 	return true
 }
 
+// pausedCollector describes how a paused Collector is exposed.
+type pausedCollector struct {
+	// frozen collects the snapshot of the paused Collector, or it is nil
+	// if the paused Collector is omitted.
+	frozen Collector
+}
+
+// activeCollectors returns the Collectors to collect, i.e. the checked
+// Collectors, with paused ones replaced by their snapshot or omitted. The
+// caller must hold at least a read lock of r.mtx.
+func (r *Registry) activeCollectors() map[uint64]Collector {
+	collectors := make(map[uint64]Collector, len(r.collectorsByID))
+	for id, c := range r.collectorsByID {
+		if p, ok := r.paused[id]; ok {
+			if p.frozen == nil {
+				continue
+			}
+			c = p.frozen
+		}
+		collectors[id] = c
+	}
+	return collectors
+}
+
+// freezeCollector collects c and returns a Collector collecting a snapshot of
+// the collected metrics.
+func freezeCollector(c Collector) Collector {
+	var (
+		ch      = make(chan Metric, capMetricChan)
+		metrics []Metric
+	)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+	for m := range ch {
+		pb := &dto.Metric{}
+		if err := m.Write(pb); err != nil {
+			// Keep the metric to report the error.
+			metrics = append(metrics, m)
+			continue
+		}
+		metrics = append(metrics, &frozenMetric{desc: m.Desc(), pb: pb})
+	}
+	return frozenCollector(metrics)
+}
+
+// frozenCollector collects a fixed set of metrics.
+type frozenCollector []Metric
+
+func (c frozenCollector) Describe(ch chan<- *Desc) {
+	for _, m := range c {
+		ch <- m.Desc()
+	}
+}
+
+func (c frozenCollector) Collect(ch chan<- Metric) {
+	for _, m := range c {
+		ch <- m
+	}
+}
+
+// frozenMetric is a snapshot of a metric.
+type frozenMetric struct {
+	desc *Desc
+	pb   *dto.Metric
+}
+
+func (m *frozenMetric) Desc() *Desc {
+	return m.desc
+}
+
+func (m *frozenMetric) Write(out *dto.Metric) error {
+	proto.Merge(out, m.pb)
+	return nil
+}
+
+// RegisteredCollector describes a Collector registered with a Registry, see
+// Registry.RegisteredCollectors.
+type RegisteredCollector struct {
+	// Collector is the Collector as it is registered with the Registry. If
+	// it has been registered through a wrapping Registerer (see
+	// WrapRegistererWith), it is the wrapped form, which collects the
+	// metrics as exposed by the Registry.
+	Collector Collector
+	// Name identifies the Collector by its type (and its string
+	// representation if it implements fmt.Stringer) and the names of the
+	// Descs it has described. If the Collector has been registered through
+	// a wrapping Registerer, the type is the one of the Collector
+	// originally provided for registration. The Name is determined once at
+	// registration time.
+	Name string
+	// Unchecked is true if the Collector is an unchecked Collector, i.e. it
+	// has not described any Desc upon registration.
+	Unchecked bool
+	// Descriptors is the number of distinct Descs the Collector has
+	// described upon registration.
+	Descriptors int
+	// LastGather describes the last collection of the Collector by Gather.
+	// It is only recorded if provenance is enabled (see
+	// Registry.SetCollectorProvenance) and nil if the Collector has not
+	// been collected since.
+	LastGather *CollectorGatherStats
+}
+
+// CollectorGatherStats describes the collection of a Collector during a Gather
+// call.
+type CollectorGatherStats struct {
+	// Start is the time the collection has started.
+	Start time.Time
+	// Duration is the time the Collect method took, which includes
+	// waiting for the Registry to process the collected metrics.
+	Duration time.Duration
+	// MetricFamilies lists the metric families the Collector has
+	// contributed to and how many metrics it has collected for each of
+	// them, sorted by name. Metrics that could not be processed are not
+	// counted.
+	MetricFamilies []CollectedMetricFamily
+	// Err contains the errors caused by the Collector, if any.
+	Err error
+}
+
+// CollectedMetricFamily is the number of metrics collected by a Collector for a
+// metric family.
+type CollectedMetricFamily struct {
+	Name    string
+	Type    dto.MetricType
+	Metrics int
+}
+
+// RegisteredCollectors returns all Collectors currently registered with the
+// Registry, sorted by their Name. It is meant for introspection, e.g. for
+// debugging purposes (see promhttp.DebugHandlerFor).
+func (r *Registry) RegisteredCollectors() []RegisteredCollector {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	registered := make([]RegisteredCollector, 0, len(r.collectorsByID)+len(r.uncheckedCollectors))
+	add := func(c Collector, key collectorKey, info collectorInfo) {
+		registered = append(registered, RegisteredCollector{
+			Collector:   c,
+			Name:        info.identity,
+			Unchecked:   key.unchecked >= 0,
+			Descriptors: info.descs,
+			LastGather:  r.lastGather[key],
+		})
+	}
+	for id, c := range r.collectorsByID {
+		add(c, collectorKey{id: id, unchecked: -1}, r.collectorInfos[id])
+	}
+	for i, c := range r.uncheckedCollectors {
+		add(c, collectorKey{unchecked: i}, r.uncheckedInfos[i])
+	}
+	sort.SliceStable(registered, func(i, j int) bool {
+		return registered[i].Name < registered[j].Name
+	})
+	return registered
+}
+
 // MustRegister implements Registerer.
 func (r *Registry) MustRegister(cs ...Collector) {
 	for _, c := range cs {
@@ -425,17 +957,33 @@ func (r *Registry) Gather() ([]*dto.MetricFamily, error) {
 		wg                  sync.WaitGroup
 		errs                MultiError          // The collected errors to return in the end.
 		registeredDescIDs   map[uint64]struct{} // Only used for pedantic checks
+		provenance          = r.provenance
+		start               = time.Now()
+		pending             = len(r.pendingUnregistration) > 0
 	)
 
-	goroutineBudget := len(r.collectorsByID) + len(r.uncheckedCollectors)
+	active := r.activeCollectors()
+	goroutineBudget := len(active) + len(r.uncheckedCollectors)
 	metricFamiliesByName := make(map[string]*dto.MetricFamily, len(r.dimHashesByName))
-	checkedCollectors := make(chan Collector, len(r.collectorsByID))
-	uncheckedCollectors := make(chan Collector, len(r.uncheckedCollectors))
-	for _, collector := range r.collectorsByID {
-		checkedCollectors <- collector
+	checkedCollectors := make(chan gatherJob, len(active))
+	uncheckedCollectors := make(chan gatherJob, len(r.uncheckedCollectors))
+	var stats map[collectorKey]*gatherStats
+	if provenance.Enabled {
+		stats = make(map[collectorKey]*gatherStats, goroutineBudget)
+	}
+	newJob := func(c Collector, key collectorKey, info collectorInfo) gatherJob {
+		if stats == nil {
+			return gatherJob{collector: c}
+		}
+		s := &gatherStats{metrics: map[string]int{}}
+		stats[key] = s
+		return gatherJob{collector: c, identity: info.identity, stats: s}
 	}
-	for _, collector := range r.uncheckedCollectors {
-		uncheckedCollectors <- collector
+	for id, collector := range active {
+		checkedCollectors <- newJob(collector, collectorKey{id: id, unchecked: -1}, r.collectorInfos[id])
+	}
+	for i, collector := range r.uncheckedCollectors {
+		uncheckedCollectors <- newJob(collector, collectorKey{unchecked: i}, r.uncheckedInfos[i])
 	}
 	// In case pedantic checks are enabled, we have to copy the map before
 	// giving up the RLock.
@@ -449,13 +997,22 @@ func (r *Registry) Gather() ([]*dto.MetricFamily, error) {
 
 	wg.Add(goroutineBudget)
 
+	collect := func(job gatherJob, ch chan<- Metric) {
+		if job.stats != nil {
+			job.stats.start = time.Now()
+			collectWithProvenance(job, ch, provenance)
+			job.stats.duration = time.Since(job.stats.start)
+			return
+		}
+		job.collector.Collect(ch)
+	}
 	collectWorker := func() {
 		for {
 			select {
-			case collector := <-checkedCollectors:
-				collector.Collect(checkedMetricChan)
-			case collector := <-uncheckedCollectors:
-				collector.Collect(uncheckedMetricChan)
+			case job := <-checkedCollectors:
+				collect(job, checkedMetricChan)
+			case job := <-uncheckedCollectors:
+				collect(job, uncheckedMetricChan)
 			default:
 				return
 			}
@@ -487,6 +1044,26 @@ func (r *Registry) Gather() ([]*dto.MetricFamily, error) {
 		}
 	}()
 
+	// process processes a collected metric and records any error, wrapped
+	// in a CollectorError if the metric has been annotated with provenance.
+	process := func(metric Metric, descIDs map[uint64]struct{}) {
+		pm, ok := metric.(provenanceMetric)
+		if !ok {
+			errs.Append(processMetric(metric, metricFamiliesByName, metricHashes, descIDs))
+			return
+		}
+		err := processMetric(pm.Metric, metricFamiliesByName, metricHashes, descIDs)
+		if err == nil {
+			pm.stats.metrics[pm.Desc().fqName]++
+			return
+		}
+		if ce := (*CollectorError)(nil); !errors.As(err, &ce) {
+			err = &CollectorError{Collector: pm.collector, Err: err, identity: pm.identity}
+		}
+		errs.Append(err)
+		pm.stats.errs.Append(err)
+	}
+
 	// Copy the channel references so we can nil them out later to remove
 	// them from the select statements below.
 	cmc := checkedMetricChan
@@ -499,21 +1076,13 @@ func (r *Registry) Gather() ([]*dto.MetricFamily, error) {
 				cmc = nil
 				break
 			}
-			errs.Append(processMetric(
-				metric, metricFamiliesByName,
-				metricHashes,
-				registeredDescIDs,
-			))
+			process(metric, registeredDescIDs)
 		case metric, ok := <-umc:
 			if !ok {
 				umc = nil
 				break
 			}
-			errs.Append(processMetric(
-				metric, metricFamiliesByName,
-				metricHashes,
-				nil,
-			))
+			process(metric, nil)
 		default:
 			if goroutineBudget <= 0 || len(checkedCollectors)+len(uncheckedCollectors) == 0 {
 				// All collectors are already being worked on or
@@ -526,21 +1095,13 @@ func (r *Registry) Gather() ([]*dto.MetricFamily, error) {
 						cmc = nil
 						break
 					}
-					errs.Append(processMetric(
-						metric, metricFamiliesByName,
-						metricHashes,
-						registeredDescIDs,
-					))
+					process(metric, registeredDescIDs)
 				case metric, ok := <-umc:
 					if !ok {
 						umc = nil
 						break
 					}
-					errs.Append(processMetric(
-						metric, metricFamiliesByName,
-						metricHashes,
-						nil,
-					))
+					process(metric, nil)
 				}
 				break
 			}
@@ -556,9 +1117,117 @@ func (r *Registry) Gather() ([]*dto.MetricFamily, error) {
 			break
 		}
 	}
+	if stats != nil {
+		r.recordGatherStats(stats, metricFamiliesByName)
+	}
+	if pending {
+		r.applyPendingUnregistrations(start)
+	}
 	return internal.NormalizeMetricFamilies(metricFamiliesByName), errs.MaybeUnwrap()
 }
 
+// gatherJob is a Collector to be collected by Gather. If provenance is
+// enabled, stats is where its collection is recorded.
+type gatherJob struct {
+	collector Collector
+	identity  string // Only set if provenance is enabled.
+	stats     *gatherStats
+}
+
+// gatherStats is where Gather records the collection of a Collector. The start
+// and duration are set by the collecting goroutine, the metrics and errors by
+// the goroutine processing the collected metrics.
+type gatherStats struct {
+	start    time.Time
+	duration time.Duration
+	metrics  map[string]int // Number of processed metrics by family name.
+	errs     MultiError
+}
+
+// recordGatherStats stores the provided stats as the last gather stats of the
+// respective Collectors. The types of the metric families are looked up in
+// metricFamiliesByName.
+func (r *Registry) recordGatherStats(stats map[collectorKey]*gatherStats, metricFamiliesByName map[string]*dto.MetricFamily) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.lastGather == nil {
+		r.lastGather = make(map[collectorKey]*CollectorGatherStats, len(stats))
+	}
+	for key, s := range stats {
+		if _, ok := r.collectorsByID[key.id]; key.unchecked < 0 && !ok {
+			continue // Unregistered in the meantime.
+		}
+		cs := &CollectorGatherStats{
+			Start:          s.start,
+			Duration:       s.duration,
+			MetricFamilies: make([]CollectedMetricFamily, 0, len(s.metrics)),
+			Err:            s.errs.MaybeUnwrap(),
+		}
+		for name, n := range s.metrics {
+			cs.MetricFamilies = append(cs.MetricFamilies, CollectedMetricFamily{
+				Name:    name,
+				Type:    metricFamiliesByName[name].GetType(),
+				Metrics: n,
+			})
+		}
+		sort.Slice(cs.MetricFamilies, func(i, j int) bool {
+			return cs.MetricFamilies[i].Name < cs.MetricFamilies[j].Name
+		})
+		r.lastGather[key] = cs
+	}
+}
+
+// provenanceMetric is a Metric annotated with the Collector that has collected
+// it and the gatherStats to record its processing in.
+type provenanceMetric struct {
+	Metric
+	collector Collector
+	identity  string
+	stats     *gatherStats
+}
+
+// collectWithProvenance collects the Collector of job and sends the collected
+// metrics to ch, each annotated with the Collector and the stats of job.
+// Depending on opts, it recovers panics and reports them as invalid metrics.
+func collectWithProvenance(job gatherJob, ch chan<- Metric, opts CollectorProvenanceOpts) {
+	c := job.collector
+	collected := make(chan Metric, capMetricChan)
+	done := make(chan struct{})
+	go func() {
+		for m := range collected {
+			ch <- provenanceMetric{Metric: m, collector: c, identity: job.identity, stats: job.stats}
+		}
+		close(done)
+	}()
+	defer func() {
+		close(collected)
+		<-done
+	}()
+	if opts.RecoverPanics {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			err := &CollectorError{
+				Collector: c,
+				Err:       fmt.Errorf("panic during collection: %v", p),
+				identity:  job.identity,
+			}
+			if opts.MaxStackBytes > 0 {
+				stack := debug.Stack()
+				if len(stack) > opts.MaxStackBytes {
+					stack = stack[:opts.MaxStackBytes]
+				}
+				err.Stack = string(stack)
+			}
+			collected <- NewInvalidMetric(NewInvalidDesc(err), err)
+		}()
+	}
+	c.Collect(collected)
+}
+
 // Describe implements Collector.
 func (r *Registry) Describe(ch chan<- *Desc) {
 	r.mtx.RLock()
@@ -571,12 +1240,43 @@ func (r *Registry) Describe(ch chan<- *Desc) {
 	}
 }
 
+// Descriptors returns the Descs of all checked Collectors registered with the
+// Registry (including paused ones), sorted by their fully-qualified name and
+// then by their constant label values. Unchecked Collectors don't describe any
+// Desc and are thus not represented. The result is meant for tooling like
+// metric catalogs (see also Desc.Labels and Desc.Metadata).
+func (r *Registry) Descriptors() []*Desc {
+	ch := make(chan *Desc, capDescChan)
+	go func() {
+		r.Describe(ch)
+		close(ch)
+	}()
+	var (
+		descs []*Desc
+		seen  = map[uint64]struct{}{}
+	)
+	for d := range ch {
+		if _, ok := seen[d.id]; ok {
+			continue
+		}
+		seen[d.id] = struct{}{}
+		descs = append(descs, d)
+	}
+	sort.Slice(descs, func(i, j int) bool {
+		if descs[i].fqName != descs[j].fqName {
+			return descs[i].fqName < descs[j].fqName
+		}
+		return descs[i].String() < descs[j].String()
+	})
+	return descs
+}
+
 // Collect implements Collector.
 func (r *Registry) Collect(ch chan<- Metric) {
 	r.mtx.RLock()
 	defer r.mtx.RUnlock()
 
-	for _, c := range r.collectorsByID {
+	for _, c := range r.activeCollectors() {
 		c.Collect(ch)
 	}
 	for _, c := range r.uncheckedCollectors {
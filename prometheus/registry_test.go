@@ -28,6 +28,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -1339,3 +1340,317 @@ func TestCheckMetricConsistency(t *testing.T) {
 	}
 	reg.Unregister(invalidCollector)
 }
+
+func TestCollectorProvenance(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	reg.SetCollectorProvenance(prometheus.CollectorProvenanceOpts{
+		Enabled:       true,
+		RecoverPanics: true,
+		MaxStackBytes: 100,
+	})
+
+	// Collects a metric with a Desc that the collector doesn't describe.
+	undescribed := prometheus.NewCounter(prometheus.CounterOpts{Name: "undescribed", Help: "Not described."})
+	inconsistentCollector := &describedCollector{
+		desc: prometheus.NewDesc("described", "Described.", nil, nil),
+		collectFunc: func(ch chan<- prometheus.Metric) {
+			ch <- undescribed
+		},
+	}
+	panickingCollector := &customCollector{
+		collectFunc: func(ch chan<- prometheus.Metric) {
+			panic("boom")
+		},
+	}
+	healthyCollector := prometheus.NewGauge(prometheus.GaugeOpts{Name: "healthy", Help: "Healthy."})
+	reg.MustRegister(inconsistentCollector, panickingCollector, healthyCollector)
+
+	mfs, err := reg.Gather()
+	var multiErr prometheus.MultiError
+	if !errors.As(err, &multiErr) || len(multiErr) != 2 {
+		t.Fatalf("expected MultiError with two errors, got %v", err)
+	}
+	found := map[prometheus.Collector]*prometheus.CollectorError{}
+	for _, err := range multiErr {
+		var ce *prometheus.CollectorError
+		if !errors.As(err, &ce) {
+			t.Fatalf("expected CollectorError, got %T: %v", err, err)
+		}
+		found[ce.Collector] = ce
+	}
+	if ce, ok := found[inconsistentCollector]; !ok {
+		t.Error("no error for inconsistent collector")
+	} else if ce.Stack != "" {
+		t.Errorf("unexpected stack for inconsistent collector: %s", ce.Stack)
+	}
+	if ce, ok := found[panickingCollector]; !ok {
+		t.Error("no error for panicking collector")
+	} else {
+		if got, want := ce.Error(), "collector *prometheus_test.customCollector: panic during collection: boom"; got != want {
+			t.Errorf("got error %q, want %q", got, want)
+		}
+		if len(ce.Stack) == 0 || len(ce.Stack) > 100 {
+			t.Errorf("expected stack of up to 100 bytes, got %d bytes", len(ce.Stack))
+		}
+	}
+	if len(mfs) != 1 || mfs[0].GetName() != "healthy" {
+		t.Errorf("expected only the healthy metric family, got %v", mfs)
+	}
+
+	// The collections are recorded per Collector.
+	for _, rc := range reg.RegisteredCollectors() {
+		stats := rc.LastGather
+		if stats == nil || stats.Start.IsZero() {
+			t.Fatalf("no last gather recorded for %s", rc.Name)
+		}
+		switch rc.Collector {
+		case healthyCollector:
+			if stats.Err != nil || len(stats.MetricFamilies) != 1 ||
+				stats.MetricFamilies[0] != (prometheus.CollectedMetricFamily{Name: "healthy", Type: dto.MetricType_GAUGE, Metrics: 1}) {
+				t.Errorf("unexpected last gather of healthy collector: %+v", stats)
+			}
+		case panickingCollector, inconsistentCollector:
+			if stats.Err == nil || len(stats.MetricFamilies) != 0 {
+				t.Errorf("unexpected last gather of %s: %+v", rc.Name, stats)
+			}
+		}
+	}
+}
+
+type describedCollector struct {
+	desc        *prometheus.Desc
+	collectFunc func(ch chan<- prometheus.Metric)
+}
+
+func (c *describedCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *describedCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collectFunc(ch)
+}
+
+func TestRegisteredCollectors(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "a_total", Help: "help"})
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "b", Help: "help"}, []string{"l"})
+	unchecked := uncheckedCollector{c: prometheus.NewGauge(prometheus.GaugeOpts{Name: "c", Help: "help"})}
+	reg.MustRegister(counter, unchecked)
+	wrapped := prometheus.WrapRegistererWith(prometheus.Labels{"w": "x"}, reg)
+	wrapped.MustRegister(vec)
+
+	got := reg.RegisteredCollectors()
+	if len(got) != 3 {
+		t.Fatalf("got %d registered collectors, want 3", len(got))
+	}
+	want := []struct {
+		name      string
+		unchecked bool
+	}{
+		{name: "*prometheus.GaugeVec [b]"},
+		{name: "*prometheus.counter [a_total]"},
+		{name: "prometheus_test.uncheckedCollector", unchecked: true},
+	}
+	for i, w := range want {
+		if got[i].Name != w.name || got[i].Unchecked != w.unchecked {
+			t.Errorf("registered collector %d: got %q (unchecked %t), want %q (unchecked %t)", i, got[i].Name, got[i].Unchecked, w.name, w.unchecked)
+		}
+	}
+	// The wrapped form is returned, so that it can be unregistered.
+	if got[0].Collector == prometheus.Collector(vec) {
+		t.Error("expected wrapped form of the wrapped collector")
+	}
+	if !reg.Unregister(got[0].Collector) {
+		t.Error("failed to unregister returned collector")
+	}
+}
+
+// describeCounter is a Collector counting the calls of its Describe method. It
+// collects an invalid metric, so that collecting it causes an error.
+type describeCounter struct {
+	desc      *prometheus.Desc
+	describes int
+}
+
+func (c *describeCounter) Describe(ch chan<- *prometheus.Desc) {
+	c.describes++
+	ch <- c.desc
+}
+
+func (c *describeCounter) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.NewInvalidMetric(c.desc, errors.New("broken"))
+}
+
+func TestCollectorIdentityRecordedAtRegistration(t *testing.T) {
+	c := &describeCounter{desc: prometheus.NewDesc("d", "help", []string{"l"}, nil)}
+	reg := prometheus.NewRegistry()
+	reg.SetCollectorProvenance(prometheus.CollectorProvenanceOpts{Enabled: true})
+	reg.MustRegister(c)
+	describes := c.describes
+
+	_, err := reg.Gather()
+	var ce *prometheus.CollectorError
+	if !errors.As(err, &ce) {
+		t.Fatalf("got error %v, want a CollectorError", err)
+	}
+	for i := 0; i < 3; i++ {
+		if got, want := ce.Error(), "collector *prometheus_test.describeCounter [d]: "; !strings.HasPrefix(got, want) {
+			t.Errorf("got error %q, want prefix %q", got, want)
+		}
+	}
+	if got, want := reg.RegisteredCollectors()[0].Name, "*prometheus_test.describeCounter [d]"; got != want {
+		t.Errorf("got name %q, want %q", got, want)
+	}
+	if c.describes != describes {
+		t.Errorf("Describe called %d times after registration", c.describes-describes)
+	}
+}
+
+func TestRegistryPause(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c := prometheus.NewCounter(prometheus.CounterOpts{Name: "c_total", Help: "c"})
+	g := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "g", Help: "g"}, []string{"l"})
+	reg.MustRegister(c, g)
+	c.Add(1)
+	g.WithLabelValues("a").Set(1)
+
+	values := func() map[string]float64 {
+		t.Helper()
+		mfs, err := reg.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := map[string]float64{}
+		for _, mf := range mfs {
+			for _, m := range mf.GetMetric() {
+				res[mf.GetName()] += m.GetCounter().GetValue() + m.GetGauge().GetValue()
+			}
+		}
+		return res
+	}
+
+	if err := reg.Pause(c, prometheus.PauseOmit); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.Pause(g, prometheus.PauseFreeze); err != nil {
+		t.Fatal(err)
+	}
+	c.Add(1)
+	g.WithLabelValues("a").Set(5)
+	g.WithLabelValues("b").Set(5)
+	if got, want := values(), map[string]float64{"g": 1}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("while paused: got %v, want %v", got, want)
+	}
+
+	// The registry as a Collector respects the pause, too.
+	outer := prometheus.NewRegistry()
+	outer.MustRegister(reg)
+	mfs, err := outer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mfs) != 1 || mfs[0].GetName() != "g" {
+		t.Errorf("unexpected metric families collected from paused registry: %v", mfs)
+	}
+
+	if !reg.Resume(c) || !reg.Resume(g) {
+		t.Error("expected collectors to be paused")
+	}
+	if reg.Resume(c) {
+		t.Error("expected collector not to be paused anymore")
+	}
+	if got, want := values(), map[string]float64{"c_total": 2, "g": 10}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("after resume: got %v, want %v", got, want)
+	}
+
+	// Unregistering ends the pause.
+	if err := reg.Pause(c, prometheus.PauseOmit); err != nil {
+		t.Fatal(err)
+	}
+	reg.Unregister(c)
+	reg.MustRegister(c)
+	if got := values()["c_total"]; got != 2 {
+		t.Errorf("after re-registration: got %v, want 2", got)
+	}
+
+	unregistered := prometheus.NewCounter(prometheus.CounterOpts{Name: "other_total", Help: "other"})
+	if err := reg.Pause(unregistered, prometheus.PauseOmit); err == nil {
+		t.Error("expected error pausing an unregistered collector")
+	}
+}
+
+func TestRegistryUnregisterAfter(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	names := func() []string {
+		t.Helper()
+		mfs, err := reg.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var res []string
+		for _, mf := range mfs {
+			res = append(res, mf.GetName())
+		}
+		return res
+	}
+
+	a := prometheus.NewCounter(prometheus.CounterOpts{Name: "a_total", Help: "a"})
+	b := prometheus.NewCounter(prometheus.CounterOpts{Name: "b_total", Help: "b"})
+	reg.MustRegister(a, b)
+
+	if reg.UnregisterAfter(prometheus.NewCounter(prometheus.CounterOpts{Name: "x_total", Help: "x"}), 0) {
+		t.Error("unregistering a collector that is not registered succeeded")
+	}
+
+	// Without a grace period, a is still collected by the next Gather.
+	if !reg.UnregisterAfter(a, 0) {
+		t.Fatal("collector is not registered")
+	}
+	if got, want := names(), []string{"a_total", "b_total"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("first gather: got %v, want %v", got, want)
+	}
+	if got, want := names(), []string{"b_total"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("second gather: got %v, want %v", got, want)
+	}
+
+	// Within the grace period, b keeps being collected.
+	if !reg.UnregisterAfter(b, time.Hour) {
+		t.Fatal("collector is not registered")
+	}
+	for i := 0; i < 2; i++ {
+		if got, want := names(), []string{"b_total"}; fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("gather %d: got %v, want %v", i, got, want)
+		}
+	}
+
+	// A replacement can be registered during the grace period.
+	b2 := prometheus.NewCounter(prometheus.CounterOpts{Name: "b_total", Help: "b"})
+	b2.Add(2)
+	if err := reg.Register(b2); err != nil {
+		t.Fatalf("registering replacement: %v", err)
+	}
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mfs) != 1 || mfs[0].GetMetric()[0].GetCounter().GetValue() != 2 {
+		t.Errorf("replacement not collected: %v", mfs)
+	}
+	if !reg.Unregister(b2) {
+		t.Error("replacement is not registered")
+	}
+
+	// A collector sharing a Desc with a pending one replaces it, too.
+	c := prometheus.NewCounter(prometheus.CounterOpts{Name: "c_total", Help: "c"})
+	reg.MustRegister(c)
+	reg.UnregisterAfter(c, time.Hour)
+	if err := reg.Register(prometheus.NewCounterFunc(prometheus.CounterOpts{Name: "c_total", Help: "c"}, func() float64 { return 1 })); err != nil {
+		t.Errorf("registering collector with a Desc of a pending one: %v", err)
+	}
+	if err := reg.Register(a); err != nil {
+		t.Errorf("registering unregistered collector: %v", err)
+	}
+	if got, want := names(), []string{"a_total", "c_total"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("after replacement: got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// CounterState is the state of a Counter as needed to restore it, e.g. after
+// a restart of a process that checkpoints its metrics. See
+// NewCounterWithState.
+type CounterState struct {
+	// Value is the value of the counter.
+	Value float64
+	// CreatedTimestamp is the time the counter was originally created. If
+	// zero, the current time is used, as for a new counter.
+	CreatedTimestamp time.Time
+}
+
+// NewCounterWithState works like NewCounter but initializes the returned Counter
+// with the provided state rather than with zero. This allows processes that
+// checkpoint their metrics (e.g. batch runners or appliances restarting
+// frequently) to continue counting across restarts. Carrying over the created
+// timestamp of the original counter makes sure that consumers of the created
+// timestamp don't detect a counter reset that never happened.
+//
+// An error is returned if the value is negative or NaN.
+func NewCounterWithState(opts CounterOpts, state CounterState) (Counter, error) {
+	if state.Value < 0 || math.IsNaN(state.Value) {
+		return nil, fmt.Errorf("invalid counter value to restore: %v", state.Value)
+	}
+	c := NewCounter(opts).(*counter)
+	c.valBits = math.Float64bits(state.Value)
+	if !state.CreatedTimestamp.IsZero() {
+		c.createdTs = timestamppb.New(state.CreatedTimestamp)
+	}
+	return c, nil
+}
+
+// HistogramState is the state of a Histogram as needed to restore it, e.g.
+// after a restart of a process that checkpoints its metrics. See
+// NewHistogramWithState.
+type HistogramState struct {
+	// Count and Sum are the number and the sum of all observations.
+	Count uint64
+	Sum   float64
+	// Buckets maps the upper bounds of the buckets to their cumulative
+	// counts, as in NewConstHistogram. The upper bounds have to match the
+	// buckets of the histogram (as configured by HistogramOpts.Buckets or
+	// DefBuckets if none are configured) exactly. The +Inf bucket may be
+	// omitted, as its cumulative count is equal to Count.
+	Buckets map[float64]uint64
+	// CreatedTimestamp is the time the histogram was originally created.
+	// If zero, the current time is used, as for a new histogram.
+	CreatedTimestamp time.Time
+}
+
+// NewHistogramWithState works like NewHistogram but initializes the returned
+// Histogram with the provided state rather than with zero observations. See
+// NewCounterWithState for the use case.
+//
+// Only classic histograms can be restored. An error is returned if the
+// HistogramOpts configure a native histogram, if the upper bounds in the
+// state don't match the buckets of the histogram, or if the cumulative counts
+// are inconsistent (decreasing or greater than the count). Like NewHistogram,
+// NewHistogramWithState panics if the buckets in HistogramOpts are not in
+// strictly increasing order.
+func NewHistogramWithState(opts HistogramOpts, state HistogramState) (Histogram, error) {
+	if opts.NativeHistogramBucketFactor > 1 {
+		return nil, errors.New("restoring the state of native histograms is not supported")
+	}
+	if state.Count >= 1<<63 {
+		return nil, fmt.Errorf("histogram count to restore is too large: %d", state.Count)
+	}
+	h := NewHistogram(opts).(*histogram)
+
+	buckets := len(state.Buckets)
+	if inf, ok := state.Buckets[math.Inf(+1)]; ok {
+		if inf != state.Count {
+			return nil, fmt.Errorf("count of +Inf bucket (%d) differs from the histogram count (%d)", inf, state.Count)
+		}
+		buckets--
+	}
+	if buckets != len(h.upperBounds) {
+		return nil, fmt.Errorf("got %d buckets to restore, the histogram has %d", buckets, len(h.upperBounds))
+	}
+	hc := h.counts[0]
+	var prev uint64
+	for i, upperBound := range h.upperBounds {
+		cumCount, ok := state.Buckets[upperBound]
+		if !ok {
+			return nil, fmt.Errorf("no bucket with upper bound %v to restore", upperBound)
+		}
+		if cumCount < prev || cumCount > state.Count {
+			return nil, fmt.Errorf("inconsistent cumulative count %d in bucket with upper bound %v", cumCount, upperBound)
+		}
+		hc.buckets[i] = cumCount - prev
+		prev = cumCount
+	}
+	hc.sumBits = math.Float64bits(state.Sum)
+	hc.count = state.Count
+	h.countAndHotIdx = state.Count // Hot index is 0.
+	if !state.CreatedTimestamp.IsZero() {
+		h.lastResetTime = state.CreatedTimestamp
+	}
+	return h, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestNewCounterWithState(t *testing.T) {
+	created := time.Unix(1700000000, 0)
+	c, err := NewCounterWithState(CounterOpts{Name: "test", Help: "test help"}, CounterState{Value: 42.5, CreatedTimestamp: created})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Inc()
+	c.Add(0.5)
+
+	var m dto.Metric
+	if err := c.Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.GetCounter().GetValue(); got != 44 {
+		t.Errorf("got value %v, want 44", got)
+	}
+	if got := m.GetCounter().GetCreatedTimestamp().AsTime(); !got.Equal(created) {
+		t.Errorf("got created timestamp %v, want %v", got, created)
+	}
+
+	for _, v := range []float64{-1, math.NaN()} {
+		if _, err := NewCounterWithState(CounterOpts{Name: "test", Help: "test help"}, CounterState{Value: v}); err == nil {
+			t.Errorf("expected error for value %v", v)
+		}
+	}
+}
+
+func TestNewHistogramWithState(t *testing.T) {
+	opts := HistogramOpts{Name: "test", Help: "test help", Buckets: []float64{1, 2, 5}}
+	created := time.Unix(1700000000, 0)
+	h, err := NewHistogramWithState(opts, HistogramState{
+		Count:            10,
+		Sum:              20,
+		Buckets:          map[float64]uint64{1: 3, 2: 5, 5: 9, math.Inf(+1): 10},
+		CreatedTimestamp: created,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Observe(1.5)
+
+	var m dto.Metric
+	if err := h.Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.GetHistogram().GetSampleCount(); got != 11 {
+		t.Errorf("got count %d, want 11", got)
+	}
+	if got := m.GetHistogram().GetSampleSum(); got != 21.5 {
+		t.Errorf("got sum %v, want 21.5", got)
+	}
+	for i, want := range []uint64{3, 6, 10} {
+		if got := m.GetHistogram().GetBucket()[i].GetCumulativeCount(); got != want {
+			t.Errorf("bucket %d: got cumulative count %d, want %d", i, got, want)
+		}
+	}
+	if got := m.GetHistogram().GetCreatedTimestamp().AsTime(); !got.Equal(created) {
+		t.Errorf("got created timestamp %v, want %v", got, created)
+	}
+
+	// Survives the hot/cold swap of another Write.
+	h.Observe(10)
+	if err := h.Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.GetHistogram().GetSampleCount(); got != 12 {
+		t.Errorf("got count %d, want 12", got)
+	}
+
+	for name, tc := range map[string]struct {
+		opts  HistogramOpts
+		state HistogramState
+	}{
+		"native histogram": {
+			opts:  HistogramOpts{Name: "test", Help: "test help", NativeHistogramBucketFactor: 1.1},
+			state: HistogramState{},
+		},
+		"missing bucket": {
+			opts:  opts,
+			state: HistogramState{Count: 1, Buckets: map[float64]uint64{1: 1, 2: 1}},
+		},
+		"wrong bucket": {
+			opts:  opts,
+			state: HistogramState{Count: 1, Buckets: map[float64]uint64{1: 1, 2: 1, 4: 1}},
+		},
+		"decreasing counts": {
+			opts:  opts,
+			state: HistogramState{Count: 2, Buckets: map[float64]uint64{1: 2, 2: 1, 5: 2}},
+		},
+		"count exceeded": {
+			opts:  opts,
+			state: HistogramState{Count: 2, Buckets: map[float64]uint64{1: 1, 2: 2, 5: 3}},
+		},
+		"inconsistent +Inf bucket": {
+			opts:  opts,
+			state: HistogramState{Count: 2, Buckets: map[float64]uint64{1: 1, 2: 2, 5: 2, math.Inf(+1): 3}},
+		},
+	} {
+		if _, err := NewHistogramWithState(tc.opts, tc.state); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
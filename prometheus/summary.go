@@ -118,6 +118,14 @@ type SummaryOpts struct {
 	// https://prometheus.io/docs/instrumenting/writing_exporters/#target-labels-not-static-scraped-labels
 	ConstLabels Labels
 
+	// Metadata is an opaque set of key/value pairs attached to the Desc of
+	// this metric, e.g. the owning team, a runbook URL, or a linked SLO. It
+	// is meant for tooling within an organization (e.g. a metric catalog
+	// built with Registry.Descriptors and Desc.Metadata) and is neither
+	// exposed to Prometheus nor taken into account for the consistency
+	// checks of a Registry. The map is copied on creation of the metric.
+	Metadata map[string]string
+
 	// Objectives defines the quantile rank estimates with their respective
 	// absolute error. If Objectives[q] = e, then the value reported for q
 	// will be the φ-quantile value for some φ between q-e and q+e.  The
@@ -186,7 +194,7 @@ func NewSummary(opts SummaryOpts) Summary {
 			opts.Help,
 			nil,
 			opts.ConstLabels,
-		),
+		).withMetadata(opts.Metadata),
 		opts,
 	)
 }
@@ -242,8 +250,10 @@ func newSummary(desc *Desc, opts SummaryOpts, labelValues ...string) Summary {
 	}
 
 	s := &summary{
-		desc: desc,
-		now:  opts.now,
+		desc:   desc,
+		now:    opts.now,
+		bufMtx: profiledMutex{kind: lockKindSummary},
+		mtx:    profiledMutex{kind: lockKindSummary},
 
 		objectives:       opts.Objectives,
 		sortedObjectives: make([]float64, 0, len(opts.Objectives)),
@@ -275,8 +285,8 @@ func newSummary(desc *Desc, opts SummaryOpts, labelValues ...string) Summary {
 type summary struct {
 	selfCollector
 
-	bufMtx sync.Mutex // Protects hotBuf and hotBufExpTime.
-	mtx    sync.Mutex // Protects every other moving part.
+	bufMtx profiledMutex // Protects hotBuf and hotBufExpTime.
+	mtx    profiledMutex // Protects every other moving part.
 	// Lock bufMtx before mtx if both are needed.
 
 	desc *Desc
@@ -370,6 +380,13 @@ func (s *summary) asyncFlush(now time.Time) {
 	s.mtx.Lock()
 	s.swapBufs(now)
 
+	// In no-goroutines mode, flush synchronously, see SetNoGoroutinesMode.
+	if NoGoroutinesMode() {
+		s.flushColdBuf()
+		s.mtx.Unlock()
+		return
+	}
+
 	// Unblock the original goroutine that was responsible for the mutation
 	// that triggered the compaction.  But hold onto the global non-buffer
 	// state mutex until the operation finishes.
@@ -573,7 +590,7 @@ func (v2) NewSummaryVec(opts SummaryVecOpts) *SummaryVec {
 		opts.Help,
 		opts.VariableLabels,
 		opts.ConstLabels,
-	)
+	).withMetadata(opts.Metadata)
 	return &SummaryVec{
 		MetricVec: NewMetricVec(desc, func(lvs ...string) Metric {
 			return newSummary(desc, opts.SummaryOpts, lvs...)
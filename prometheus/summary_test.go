@@ -407,6 +407,30 @@ func TestSummaryDecay(t *testing.T) {
 	}
 }
 
+func TestSummaryNoGoroutinesMode(t *testing.T) {
+	SetNoGoroutinesMode(true)
+	defer SetNoGoroutinesMode(false)
+
+	sum := NewSummary(SummaryOpts{
+		Name:       "test_summary",
+		Help:       "helpless",
+		Objectives: map[float64]float64{0.5: 0.05},
+		BufCap:     2,
+	}).(*summary)
+
+	// Filling up the hot buffer triggers a flush, which must have completed
+	// once Observe returns.
+	sum.Observe(1)
+	sum.Observe(2)
+	if !sum.mtx.TryLock() {
+		t.Fatal("summary mutex still locked after Observe returned")
+	}
+	defer sum.mtx.Unlock()
+	if got, want := sum.headStream.Count(), 2; got != want {
+		t.Errorf("got %d observations in head stream, want %d", got, want)
+	}
+}
+
 func getBounds(vars []float64, q, ε float64) (minBound, maxBound float64) {
 	// TODO(beorn7): This currently tolerates an error of up to 2*ε. The
 	// error must be at most ε, but for some reason, it's sometimes slightly
@@ -0,0 +1,155 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/kylelemons/godebug/diff"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// corpusFormats lists the exposition formats written by WriteExpositionCorpus,
+// together with the file name extension used for each of them.
+var corpusFormats = []struct {
+	format expfmt.FormatType
+	ext    string
+}{
+	{expfmt.TypeTextPlain, ".txt"},
+	{expfmt.TypeOpenMetrics, ".om.txt"},
+	{expfmt.TypeProtoText, ".prototext"},
+	{expfmt.TypeProtoCompact, ".protocompact"},
+	{expfmt.TypeProtoDelim, ".pb"},
+}
+
+// WriteExpositionCorpus gathers all metrics from the provided Gatherer once and
+// writes them in every exposition format supported by CollectAndFormat to the
+// directory dir, which is created if it does not exist yet. The files are
+// named after the provided name, with an extension depending on the format:
+//
+//   - name.txt for the text format
+//   - name.om.txt for OpenMetrics
+//   - name.prototext for the protobuf text format
+//   - name.protocompact for the compact protobuf text format
+//   - name.pb for the length-delimited protobuf format
+//
+// Existing files are overwritten. The paths of the written files are returned.
+//
+// As all files are encoded from the same gathered metrics, they can serve as
+// golden files or as a seed corpus for fuzz tests of exposition parsers, e.g.
+// by registering a representative set of metrics (including histograms with
+// native buckets and exemplars) and writing the corpus to testdata.
+func WriteExpositionCorpus(g prometheus.Gatherer, dir, name string) ([]string, error) {
+	mfs, err := g.Gather()
+	if err != nil {
+		return nil, fmt.Errorf("gathering metrics failed: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(corpusFormats))
+	for _, cf := range corpusFormats {
+		b, err := formatMetricFamilies(mfs, cf.format)
+		if err != nil {
+			return paths, err
+		}
+		path := filepath.Join(dir, name+cf.ext)
+		if err := os.WriteFile(path, b, 0o644); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// CheckExpositionRoundTrip gathers all metrics from the provided Gatherer and
+// checks, for every exposition format that can be parsed by expfmt (the text
+// format and the length-delimited protobuf format), that encoding the metrics,
+// parsing the result, and encoding the parsed metrics again yields the very
+// same encoding. It returns an error describing all differences, or nil if the
+// encodings are invariant.
+//
+// Note that an encoding might lose information (e.g. the text format cannot
+// represent native histograms), so that the parsed metrics might differ from
+// the gathered ones. The check is about the invariance of the encoding itself,
+// which is a requirement for stable golden files and a useful property to
+// test encoders and parsers for.
+func CheckExpositionRoundTrip(g prometheus.Gatherer) error {
+	mfs, err := g.Gather()
+	if err != nil {
+		return fmt.Errorf("gathering metrics failed: %w", err)
+	}
+	var errs []error
+	for _, format := range []expfmt.FormatType{expfmt.TypeTextPlain, expfmt.TypeProtoDelim} {
+		if err := checkRoundTrip(mfs, format); err != nil {
+			errs = append(errs, fmt.Errorf("format %s: %w", expfmt.NewFormat(format), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func checkRoundTrip(mfs []*dto.MetricFamily, format expfmt.FormatType) error {
+	first, err := formatMetricFamilies(mfs, format)
+	if err != nil {
+		return err
+	}
+
+	var parsed []*dto.MetricFamily
+	if format == expfmt.TypeTextPlain {
+		// Use the same normalization as for expected metrics in
+		// GatherAndCompare.
+		if parsed, err = convertReaderToMetricFamily(bytes.NewReader(first)); err != nil {
+			return err
+		}
+	} else {
+		dec := expfmt.NewDecoder(bytes.NewReader(first), expfmt.NewFormat(format))
+		for {
+			mf := &dto.MetricFamily{}
+			if err := dec.Decode(mf); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return fmt.Errorf("parsing encoded metrics failed: %w", err)
+			}
+			parsed = append(parsed, mf)
+		}
+	}
+
+	second, err := formatMetricFamilies(parsed, format)
+	if err != nil {
+		return err
+	}
+	switch {
+	case bytes.Equal(first, second):
+		return nil
+	case format == expfmt.TypeTextPlain:
+		return fmt.Errorf(
+			"re-encoding parsed metrics changed the encoding:\n%s",
+			diff.Diff(string(first), string(second)),
+		)
+	default:
+		return fmt.Errorf(
+			"re-encoding parsed metrics changed the encoding (%d bytes before, %d bytes after)",
+			len(first), len(second),
+		)
+	}
+}
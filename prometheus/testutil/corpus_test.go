@@ -0,0 +1,74 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func corpusTestRegistry() *prometheus.Registry {
+	reg := prometheus.NewPedanticRegistry()
+	c := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "some_total",
+		Help: "A counter.",
+	}, []string{"label"})
+	c.WithLabelValues("a").Add(3)
+	c.WithLabelValues(`with "quotes" and \ backslash`).Inc()
+	h := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:                        "some_duration_seconds",
+		Help:                        "A histogram with classic and native buckets.",
+		Buckets:                     []float64{0.1, 1},
+		NativeHistogramBucketFactor: 1.1,
+	})
+	h.Observe(0.05)
+	h.(prometheus.ExemplarObserver).ObserveWithExemplar(0.5, prometheus.Labels{"trace_id": "abc"})
+	g := prometheus.NewGauge(prometheus.GaugeOpts{Name: "some_gauge", Help: ""})
+	g.Set(-1.5)
+	reg.MustRegister(c, h, g)
+	return reg
+}
+
+func TestWriteExpositionCorpus(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "corpus")
+	paths, err := WriteExpositionCorpus(corpusTestRegistry(), dir, "seed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != len(corpusFormats) {
+		t.Fatalf("got %d files, want %d", len(paths), len(corpusFormats))
+	}
+	for _, p := range paths {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(b) == 0 {
+			t.Errorf("file %s is empty", p)
+		}
+		if strings.HasSuffix(p, ".om.txt") && !strings.HasSuffix(string(b), "# EOF\n") {
+			t.Errorf("OpenMetrics file %s is not finalized", p)
+		}
+	}
+}
+
+func TestCheckExpositionRoundTrip(t *testing.T) {
+	if err := CheckExpositionRoundTrip(corpusTestRegistry()); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/prometheus/client_golang/prometheus/internal"
+)
+
+// PushRequest is a single request received by a PushgatewayServer.
+type PushRequest struct {
+	// Method is the HTTP method of the request, i.e. “PUT” for
+	// Pusher.Push, “POST” for Pusher.Add, and “DELETE” for Pusher.Delete.
+	Method string
+	// Header is the header of the request.
+	Header http.Header
+	// Grouping is the decoded grouping key, including the job label.
+	Grouping map[string]string
+	// Families are the decoded metric families contained in the request
+	// body. It is nil for DELETE requests.
+	Families []*dto.MetricFamily
+}
+
+// PushgatewayServer is a fake Pushgateway, backed by an httptest.Server. It
+// understands the push, add, and delete calls issued by a push.Pusher and
+// records every request it has received. The pushed metrics are stored per
+// grouping key with the same replacement semantics as the real Pushgateway.
+//
+// PushgatewayServer implements prometheus.Gatherer. Gather returns all
+// currently stored metrics with the grouping labels added, so that the result
+// can be compared with GatherAndCompare.
+//
+// PushgatewayServer is meant for end-to-end tests of code using the push
+// package. It does not add the push_time_seconds and push_failure_time_seconds
+// metrics of the real Pushgateway, nor does it provide any of its APIs besides
+// the push endpoints.
+type PushgatewayServer struct {
+	*httptest.Server
+
+	mtx      sync.Mutex
+	groups   map[string]*pushGroup
+	requests []PushRequest
+}
+
+type pushGroup struct {
+	grouping map[string]string
+	families map[string]*dto.MetricFamily
+}
+
+// NewPushgatewayServer starts and returns a new PushgatewayServer. The caller
+// should call Close when finished, to shut it down. The URL field of the
+// embedded httptest.Server can be passed to push.New.
+func NewPushgatewayServer() *PushgatewayServer {
+	s := &PushgatewayServer{groups: map[string]*pushGroup{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Requests returns all requests received so far, in the order in which they
+// have been received.
+func (s *PushgatewayServer) Requests() []PushRequest {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return append([]PushRequest(nil), s.requests...)
+}
+
+// Gather implements prometheus.Gatherer. It returns the metrics of all
+// grouping keys currently stored, with the grouping labels added to each
+// metric.
+func (s *PushgatewayServer) Gather() ([]*dto.MetricFamily, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	merged := map[string]*dto.MetricFamily{}
+	for _, g := range s.groups {
+		for name, mf := range g.families {
+			existing, ok := merged[name]
+			if !ok {
+				existing = &dto.MetricFamily{
+					Name: mf.Name,
+					Help: mf.Help,
+					Type: mf.Type,
+					Unit: mf.Unit,
+				}
+				merged[name] = existing
+			}
+			for _, m := range mf.GetMetric() {
+				m = proto.Clone(m).(*dto.Metric)
+				m.Label = addGroupingLabels(m.Label, g.grouping)
+				existing.Metric = append(existing.Metric, m)
+			}
+		}
+	}
+	return internal.NormalizeMetricFamilies(merged), nil
+}
+
+func (s *PushgatewayServer) handle(w http.ResponseWriter, r *http.Request) {
+	grouping, err := parsePushPath(r.URL.EscapedPath())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req := PushRequest{
+		Method:   r.Method,
+		Header:   r.Header.Clone(),
+		Grouping: grouping,
+	}
+	switch r.Method {
+	case http.MethodPut, http.MethodPost:
+		req.Families, err = decodePushBody(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	case http.MethodDelete:
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.requests = append(s.requests, req)
+	key := groupingKey(grouping)
+	switch r.Method {
+	case http.MethodPut:
+		s.groups[key] = &pushGroup{grouping: grouping, families: familiesByName(req.Families)}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodPost:
+		g, ok := s.groups[key]
+		if !ok {
+			g = &pushGroup{grouping: grouping, families: map[string]*dto.MetricFamily{}}
+			s.groups[key] = g
+		}
+		for name, mf := range familiesByName(req.Families) {
+			g.families[name] = mf
+		}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		delete(s.groups, key)
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// parsePushPath extracts the grouping key from a path of the form
+// /metrics/job/<job>{/<label>/<value>}, honoring the “@base64” suffix.
+func parsePushPath(path string) (map[string]string, error) {
+	rest, ok := strings.CutPrefix(path, "/metrics/")
+	if !ok {
+		return nil, fmt.Errorf("unexpected path %q", path)
+	}
+	components := strings.Split(rest, "/")
+	if len(components)%2 != 0 {
+		return nil, fmt.Errorf("odd number of components in path %q", path)
+	}
+	grouping := make(map[string]string, len(components)/2)
+	for i := 0; i < len(components); i += 2 {
+		name, value := components[i], components[i+1]
+		var err error
+		if n, ok := strings.CutSuffix(name, "@base64"); ok {
+			name = n
+			var b []byte
+			if value == "=" {
+				value = ""
+			} else if b, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "=")); err == nil {
+				value = string(b)
+			}
+		} else {
+			value, err = url.QueryUnescape(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for label %q in path %q: %w", name, path, err)
+		}
+		grouping[name] = value
+	}
+	if grouping["job"] == "" {
+		return nil, errors.New("job name is empty")
+	}
+	return grouping, nil
+}
+
+func decodePushBody(r *http.Request) ([]*dto.MetricFamily, error) {
+	dec := expfmt.NewDecoder(r.Body, expfmt.ResponseFormat(r.Header))
+	var mfs []*dto.MetricFamily
+	for {
+		mf := &dto.MetricFamily{}
+		if err := dec.Decode(mf); err != nil {
+			if errors.Is(err, io.EOF) {
+				return mfs, nil
+			}
+			return nil, fmt.Errorf("decoding pushed metrics failed: %w", err)
+		}
+		mfs = append(mfs, mf)
+	}
+}
+
+func familiesByName(mfs []*dto.MetricFamily) map[string]*dto.MetricFamily {
+	byName := make(map[string]*dto.MetricFamily, len(mfs))
+	for _, mf := range mfs {
+		byName[mf.GetName()] = mf
+	}
+	return byName
+}
+
+func groupingKey(grouping map[string]string) string {
+	names := make([]string, 0, len(grouping))
+	for name := range grouping {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte(0xff)
+		b.WriteString(grouping[name])
+		b.WriteByte(0xff)
+	}
+	return b.String()
+}
+
+// addGroupingLabels adds the grouping labels to lps. Like in the Pushgateway,
+// a grouping label replaces a pushed label of the same name.
+func addGroupingLabels(lps []*dto.LabelPair, grouping map[string]string) []*dto.LabelPair {
+	for name, value := range grouping {
+		replaced := false
+		for _, lp := range lps {
+			if lp.GetName() == name {
+				lp.Value = proto.String(value)
+				replaced = true
+			}
+		}
+		if !replaced {
+			lps = append(lps, &dto.LabelPair{Name: proto.String(name), Value: proto.String(value)})
+		}
+	}
+	sort.Sort(internal.LabelPairSorter(lps))
+	return lps
+}
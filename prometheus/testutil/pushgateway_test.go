@@ -0,0 +1,152 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+func TestPushgatewayServer(t *testing.T) {
+	pgw := NewPushgatewayServer()
+	defer pgw.Close()
+
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "some_counter", Help: "A counter."})
+	counter.Add(3)
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "some_gauge", Help: "A gauge."})
+	gauge.Set(42)
+
+	if err := push.New(pgw.URL, "batch/job").
+		Grouping("instance", "a b").
+		Collector(counter).
+		Collector(gauge).
+		Push(); err != nil {
+		t.Fatal("Push failed:", err)
+	}
+	counter.Add(2)
+	if err := push.New(pgw.URL, "other").
+		Collector(counter).
+		Format(expfmt.NewFormat(expfmt.TypeTextPlain)).
+		Add(); err != nil {
+		t.Fatal("Add failed:", err)
+	}
+
+	expected := `
+# HELP some_counter A counter.
+# TYPE some_counter counter
+some_counter{instance="a b",job="batch/job"} 3
+some_counter{job="other"} 5
+# HELP some_gauge A gauge.
+# TYPE some_gauge gauge
+some_gauge{instance="a b",job="batch/job"} 42
+`
+	if err := GatherAndCompare(pgw, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+
+	// Add only replaces metrics with the same name.
+	counter.Add(5)
+	if err := push.New(pgw.URL, "batch/job").
+		Grouping("instance", "a b").
+		Collector(counter).
+		Add(); err != nil {
+		t.Fatal("Add failed:", err)
+	}
+	if err := push.New(pgw.URL, "other").Delete(); err != nil {
+		t.Fatal("Delete failed:", err)
+	}
+
+	expected = `
+# HELP some_counter A counter.
+# TYPE some_counter counter
+some_counter{instance="a b",job="batch/job"} 10
+# HELP some_gauge A gauge.
+# TYPE some_gauge gauge
+some_gauge{instance="a b",job="batch/job"} 42
+`
+	if err := GatherAndCompare(pgw, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+
+	reqs := pgw.Requests()
+	if got, want := len(reqs), 4; got != want {
+		t.Fatalf("got %d requests, want %d", got, want)
+	}
+	for i, want := range []string{http.MethodPut, http.MethodPost, http.MethodPost, http.MethodDelete} {
+		if got := reqs[i].Method; got != want {
+			t.Errorf("request %d: got method %q, want %q", i, got, want)
+		}
+	}
+	if got, want := reqs[0].Grouping["job"], "batch/job"; got != want {
+		t.Errorf("got job %q, want %q", got, want)
+	}
+	if got, want := len(reqs[0].Families), 2; got != want {
+		t.Errorf("got %d families in first request, want %d", got, want)
+	}
+	if reqs[3].Families != nil {
+		t.Errorf("got families in DELETE request: %v", reqs[3].Families)
+	}
+}
+
+func TestPushgatewayServerRejectsInvalidPath(t *testing.T) {
+	pgw := NewPushgatewayServer()
+	defer pgw.Close()
+
+	for _, path := range []string{"/foo", "/metrics/job", "/metrics/job/"} {
+		resp, err := http.Post(pgw.URL+path, string(expfmt.NewFormat(expfmt.TypeTextPlain)), strings.NewReader(""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: got status %d, want %d", path, resp.StatusCode, http.StatusBadRequest)
+		}
+	}
+	if got := len(pgw.Requests()); got != 0 {
+		t.Errorf("got %d recorded requests, want 0", got)
+	}
+}
+
+func TestPushgatewayServerGroupingLabelsReplacePushedLabels(t *testing.T) {
+	pgw := NewPushgatewayServer()
+	defer pgw.Close()
+
+	body := `# HELP some_gauge A gauge.
+# TYPE some_gauge gauge
+some_gauge{instance="pushed",job="pushed",other="x"} 1
+`
+	resp, err := http.Post(pgw.URL+"/metrics/job/batch/instance/a", string(expfmt.NewFormat(expfmt.TypeTextPlain)), strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	expected := `
+# HELP some_gauge A gauge.
+# TYPE some_gauge gauge
+some_gauge{instance="a",job="batch",other="x"} 1
+`
+	if err := GatherAndCompare(pgw, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
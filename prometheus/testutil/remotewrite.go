@@ -0,0 +1,341 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+
+	"github.com/klauspost/compress/s2"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	// RemoteWriteV2ContentType is the content type of a Prometheus Remote
+	// Write 2.0 request.
+	RemoteWriteV2ContentType = "application/x-protobuf;proto=io.prometheus.write.v2.Request"
+
+	remoteWriteV2Proto = "io.prometheus.write.v2.Request"
+
+	remoteWriteSamplesWrittenHeader    = "X-Prometheus-Remote-Write-Samples-Written"
+	remoteWriteHistogramsWrittenHeader = "X-Prometheus-Remote-Write-Histograms-Written"
+	remoteWriteExemplarsWrittenHeader  = "X-Prometheus-Remote-Write-Exemplars-Written"
+)
+
+// RemoteWriteRequest is a single, successfully decoded request received by a
+// RemoteWriteServer.
+type RemoteWriteRequest struct {
+	// Header is the header of the request.
+	Header http.Header
+	// Series are the time series contained in the request, in the order in
+	// which they have been sent.
+	Series []RemoteWriteSeries
+}
+
+// RemoteWriteSeries is a time series decoded from a Remote Write 2.0 request.
+// Label references are resolved against the symbols table of the request.
+type RemoteWriteSeries struct {
+	Labels           map[string]string
+	Samples          []RemoteWriteSample
+	Histograms       int // Number of native histogram samples.
+	Exemplars        int // Number of exemplars.
+	Help, Unit       string
+	CreatedTimestamp int64 // In milliseconds, 0 if not set.
+}
+
+// RemoteWriteSample is a float sample of a RemoteWriteSeries.
+type RemoteWriteSample struct {
+	Value     float64
+	Timestamp int64 // In milliseconds.
+}
+
+// RemoteWriteStats are the written sample counts reported by a
+// RemoteWriteServer, summed up over all requests received so far.
+type RemoteWriteStats struct {
+	Samples, Histograms, Exemplars int
+}
+
+// RemoteWriteServer is a fake Prometheus Remote Write 2.0 receiver, backed by
+// an httptest.Server. It validates the content type and encoding of each
+// request, decodes the snappy-compressed protobuf payload, records the
+// contained series, and responds with the written-stats headers mandated by
+// the specification.
+//
+// RemoteWriteServer is meant for end-to-end tests of remote write senders. It
+// neither persists nor deduplicates anything, and native histograms are only
+// counted, not decoded.
+type RemoteWriteServer struct {
+	*httptest.Server
+
+	mtx      sync.Mutex
+	requests []RemoteWriteRequest
+	stats    RemoteWriteStats
+	status   int
+}
+
+// NewRemoteWriteServer starts and returns a new RemoteWriteServer. The caller
+// should call Close when finished, to shut it down. Requests have to be sent
+// to the URL field of the embedded httptest.Server.
+func NewRemoteWriteServer() *RemoteWriteServer {
+	s := &RemoteWriteServer{status: http.StatusNoContent}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// SetStatus configures the HTTP status code the RemoteWriteServer responds
+// with to well-formed requests. The default is 204 (No Content). Setting a
+// non-2xx code allows testing the retry behavior of a sender. Requests
+// answered with a non-2xx code are recorded but are not included in the
+// written stats.
+func (s *RemoteWriteServer) SetStatus(code int) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.status = code
+}
+
+// Requests returns all well-formed requests received so far, in the order in
+// which they have been received.
+func (s *RemoteWriteServer) Requests() []RemoteWriteRequest {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return append([]RemoteWriteRequest(nil), s.requests...)
+}
+
+// Stats returns the number of samples, histograms, and exemplars reported as
+// written so far.
+func (s *RemoteWriteServer) Stats() RemoteWriteStats {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.stats
+}
+
+func (s *RemoteWriteServer) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/x-protobuf" || params["proto"] != remoteWriteV2Proto {
+		http.Error(w, fmt.Sprintf("unsupported content type %q", r.Header.Get("Content-Type")), http.StatusUnsupportedMediaType)
+		return
+	}
+	if enc := r.Header.Get("Content-Encoding"); enc != "snappy" {
+		http.Error(w, fmt.Sprintf("unsupported content encoding %q", enc), http.StatusUnsupportedMediaType)
+		return
+	}
+	compressed, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	payload, err := s2.Decode(nil, compressed)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("decompressing request failed: %v", err), http.StatusBadRequest)
+		return
+	}
+	series, err := decodeRemoteWriteV2(payload)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("decoding request failed: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var written RemoteWriteStats
+	for _, ts := range series {
+		written.Samples += len(ts.Samples)
+		written.Histograms += ts.Histograms
+		written.Exemplars += ts.Exemplars
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.requests = append(s.requests, RemoteWriteRequest{Header: r.Header.Clone(), Series: series})
+	if s.status/100 != 2 {
+		written = RemoteWriteStats{}
+	}
+	s.stats.Samples += written.Samples
+	s.stats.Histograms += written.Histograms
+	s.stats.Exemplars += written.Exemplars
+
+	w.Header().Set(remoteWriteSamplesWrittenHeader, strconv.Itoa(written.Samples))
+	w.Header().Set(remoteWriteHistogramsWrittenHeader, strconv.Itoa(written.Histograms))
+	w.Header().Set(remoteWriteExemplarsWrittenHeader, strconv.Itoa(written.Exemplars))
+	w.WriteHeader(s.status)
+}
+
+// rawSeries is a time series with unresolved symbol references.
+type rawSeries struct {
+	RemoteWriteSeries
+	labelRefs        []uint32
+	helpRef, unitRef uint64
+	hasHelp, hasUnit bool
+}
+
+// decodeRemoteWriteV2 decodes an uncompressed io.prometheus.write.v2.Request.
+// Only the fields needed by RemoteWriteServer are decoded, all other fields are
+// skipped.
+func decodeRemoteWriteV2(b []byte) ([]RemoteWriteSeries, error) {
+	var (
+		symbols []string
+		raw     []rawSeries
+	)
+	err := forEachField(b, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		switch {
+		case num == 4 && typ == protowire.BytesType: // symbols
+			symbols = append(symbols, string(v))
+		case num == 5 && typ == protowire.BytesType: // timeseries
+			ts, err := decodeRemoteWriteV2Series(v)
+			if err != nil {
+				return err
+			}
+			raw = append(raw, ts)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	symbol := func(ref uint64) (string, error) {
+		if ref >= uint64(len(symbols)) {
+			return "", fmt.Errorf("symbol reference %d out of range", ref)
+		}
+		return symbols[ref], nil
+	}
+	result := make([]RemoteWriteSeries, 0, len(raw))
+	for _, ts := range raw {
+		if len(ts.labelRefs)%2 != 0 {
+			return nil, errors.New("odd number of label references")
+		}
+		ts.Labels = make(map[string]string, len(ts.labelRefs)/2)
+		for i := 0; i < len(ts.labelRefs); i += 2 {
+			name, err := symbol(uint64(ts.labelRefs[i]))
+			if err != nil {
+				return nil, err
+			}
+			value, err := symbol(uint64(ts.labelRefs[i+1]))
+			if err != nil {
+				return nil, err
+			}
+			ts.Labels[name] = value
+		}
+		if ts.hasHelp {
+			if ts.Help, err = symbol(ts.helpRef); err != nil {
+				return nil, err
+			}
+		}
+		if ts.hasUnit {
+			if ts.Unit, err = symbol(ts.unitRef); err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, ts.RemoteWriteSeries)
+	}
+	return result, nil
+}
+
+func decodeRemoteWriteV2Series(b []byte) (rawSeries, error) {
+	var ts rawSeries
+	err := forEachField(b, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType: // labels_refs, packed
+			for len(v) > 0 {
+				ref, n := protowire.ConsumeVarint(v)
+				if n < 0 {
+					return protowire.ParseError(n)
+				}
+				ts.labelRefs = append(ts.labelRefs, uint32(ref))
+				v = v[n:]
+			}
+		case num == 1 && typ == protowire.VarintType: // labels_refs, unpacked
+			ref, _ := protowire.ConsumeVarint(v)
+			ts.labelRefs = append(ts.labelRefs, uint32(ref))
+		case num == 2 && typ == protowire.BytesType: // samples
+			var s RemoteWriteSample
+			if err := forEachField(v, func(num protowire.Number, typ protowire.Type, v []byte) error {
+				switch {
+				case num == 1 && typ == protowire.Fixed64Type:
+					bits, _ := protowire.ConsumeFixed64(v)
+					s.Value = math.Float64frombits(bits)
+				case num == 2 && typ == protowire.VarintType:
+					t, _ := protowire.ConsumeVarint(v)
+					s.Timestamp = int64(t)
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			ts.Samples = append(ts.Samples, s)
+		case num == 3 && typ == protowire.BytesType: // histograms
+			ts.Histograms++
+		case num == 4 && typ == protowire.BytesType: // exemplars
+			ts.Exemplars++
+		case num == 5 && typ == protowire.BytesType: // metadata
+			return forEachField(v, func(num protowire.Number, typ protowire.Type, v []byte) error {
+				if typ != protowire.VarintType {
+					return nil
+				}
+				ref, _ := protowire.ConsumeVarint(v)
+				switch num {
+				case 3:
+					ts.helpRef, ts.hasHelp = ref, true
+				case 4:
+					ts.unitRef, ts.hasUnit = ref, true
+				}
+				return nil
+			})
+		case num == 6 && typ == protowire.VarintType: // created_timestamp
+			t, _ := protowire.ConsumeVarint(v)
+			ts.CreatedTimestamp = int64(t)
+		}
+		return nil
+	})
+	return ts, err
+}
+
+// forEachField calls f for each top-level field of the protobuf message
+// encoded in b. For length-delimited fields, v is the content of the field.
+// For all other fields, v is the raw encoded value.
+func forEachField(b []byte, f func(num protowire.Number, typ protowire.Type, v []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		m := protowire.ConsumeFieldValue(num, typ, b)
+		if m < 0 {
+			return protowire.ParseError(m)
+		}
+		v := b[:m]
+		if typ == protowire.BytesType {
+			v, _ = protowire.ConsumeBytes(v)
+		}
+		if err := f(num, typ, v); err != nil {
+			return err
+		}
+		b = b[m:]
+	}
+	return nil
+}
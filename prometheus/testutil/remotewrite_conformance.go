@@ -0,0 +1,219 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/klauspost/compress/s2"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// remoteWriteConformanceCheck is a single check of CheckRemoteWriteReceiver.
+type remoteWriteConformanceCheck struct {
+	name        string
+	contentType string
+	encoding    string
+	body        []byte
+	check       func(*httptest.ResponseRecorder) error
+}
+
+// CheckRemoteWriteReceiver runs a set of conformance checks based on the
+// Prometheus Remote Write 2.0 specification against the provided handler of a
+// remote write receiver. It returns an error describing all failed checks, or
+// nil if all checks passed. The checks cover:
+//
+//   - Accepting a well-formed request (containing float samples, a native
+//     histogram sample, and an exemplar) with a 2xx status code.
+//   - Reporting the written samples, histograms, and exemplars of that request
+//     in the X-Prometheus-Remote-Write-*-Written response headers.
+//   - Rejecting unsupported content types and content encodings with status
+//     code 415 (Unsupported Media Type).
+//   - Rejecting malformed requests (undecodable snappy payload, invalid
+//     protobuf, unresolvable symbol references) with status code 400 (Bad
+//     Request). The specification demands that a sender never retries a 4xx
+//     response (other than 429), so responding to those requests with a 5xx
+//     code would make the sender retry a request that can never succeed.
+//
+// The handler is called directly, without a network round trip. It has to
+// accept the requests regardless of their URL path, and it has to write the
+// samples of the well-formed request (which have current timestamps) to
+// pass. The checks don't cover the behavior of a receiver under load or
+// failure (e.g. responding with 429 or 5xx to signal a retryable error), as
+// that cannot be provoked in a generic way.
+//
+// CheckRemoteWriteReceiver is meant to be used in tests of receiver
+// implementations, e.g.:
+//
+//	if err := testutil.CheckRemoteWriteReceiver(myHandler); err != nil {
+//		t.Error(err)
+//	}
+func CheckRemoteWriteReceiver(h http.Handler) error {
+	now := time.Now().UnixMilli()
+	valid := s2.EncodeSnappy(nil, encodeConformanceRequest(now, 0))
+
+	checks := []remoteWriteConformanceCheck{
+		{
+			name:        "well-formed request",
+			contentType: RemoteWriteV2ContentType,
+			encoding:    "snappy",
+			body:        valid,
+			check: func(rec *httptest.ResponseRecorder) error {
+				if rec.Code/100 != 2 {
+					return fmt.Errorf("expected 2xx status code, got %d: %s", rec.Code, rec.Body)
+				}
+				var errs []error
+				for _, h := range []struct{ header, want string }{
+					{remoteWriteSamplesWrittenHeader, "2"},
+					{remoteWriteHistogramsWrittenHeader, "1"},
+					{remoteWriteExemplarsWrittenHeader, "1"},
+				} {
+					if got := rec.Header().Get(h.header); got != h.want {
+						errs = append(errs, fmt.Errorf("expected header %s to be %q, got %q", h.header, h.want, got))
+					}
+				}
+				return errors.Join(errs...)
+			},
+		},
+		{
+			name:        "unsupported content type",
+			contentType: "application/x-protobuf;proto=io.prometheus.write.v99.Request",
+			encoding:    "snappy",
+			body:        valid,
+			check:       expectStatus(http.StatusUnsupportedMediaType),
+		},
+		{
+			name:        "unsupported content encoding",
+			contentType: RemoteWriteV2ContentType,
+			encoding:    "x-unsupported",
+			body:        valid,
+			check:       expectStatus(http.StatusUnsupportedMediaType),
+		},
+		{
+			name:        "undecodable snappy payload",
+			contentType: RemoteWriteV2ContentType,
+			encoding:    "snappy",
+			body:        []byte("this is not snappy"),
+			check:       expectStatus(http.StatusBadRequest),
+		},
+		{
+			name:        "invalid protobuf",
+			contentType: RemoteWriteV2ContentType,
+			encoding:    "snappy",
+			body:        s2.EncodeSnappy(nil, []byte{0x2a, 0xff, 0xff, 0xff}),
+			check:       expectStatus(http.StatusBadRequest),
+		},
+		{
+			name:        "unresolvable symbol reference",
+			contentType: RemoteWriteV2ContentType,
+			encoding:    "snappy",
+			body:        s2.EncodeSnappy(nil, encodeConformanceRequest(now, 100)),
+			check:       expectStatus(http.StatusBadRequest),
+		},
+	}
+
+	var errs []error
+	for _, c := range checks {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/write", bytes.NewReader(c.body))
+		req.Header.Set("Content-Type", c.contentType)
+		req.Header.Set("Content-Encoding", c.encoding)
+		req.Header.Set("X-Prometheus-Remote-Write-Version", "2.0.0")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if err := c.check(rec); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("remote write receiver is not conformant: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func expectStatus(code int) func(*httptest.ResponseRecorder) error {
+	return func(rec *httptest.ResponseRecorder) error {
+		if rec.Code != code {
+			return fmt.Errorf("expected status code %d, got %d", code, rec.Code)
+		}
+		return nil
+	}
+}
+
+// encodeConformanceRequest encodes an uncompressed Remote Write 2.0 request
+// with a float series (two samples and an exemplar) and a native histogram
+// series (one sample), all with the timestamp ts. All label references are
+// shifted by refOffset, so that a non-zero refOffset results in unresolvable
+// references.
+func encodeConformanceRequest(ts int64, refOffset uint64) []byte {
+	symbols := []string{"", "__name__", "conformance_test_metric", "job", "conformance", "conformance_test_histogram"}
+	refs := func(refs ...uint64) []byte {
+		var b []byte
+		for _, r := range refs {
+			b = protowire.AppendVarint(b, r+refOffset)
+		}
+		return b
+	}
+
+	var floatSeries []byte
+	floatSeries = protowire.AppendTag(floatSeries, 1, protowire.BytesType) // labels_refs
+	floatSeries = protowire.AppendBytes(floatSeries, refs(1, 2, 3, 4))
+	for i, v := range []float64{1, 2} {
+		var sample []byte
+		sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type) // value
+		sample = protowire.AppendFixed64(sample, math.Float64bits(v))
+		sample = protowire.AppendTag(sample, 2, protowire.VarintType) // timestamp
+		sample = protowire.AppendVarint(sample, uint64(ts-int64(1-i)))
+		floatSeries = protowire.AppendTag(floatSeries, 2, protowire.BytesType) // samples
+		floatSeries = protowire.AppendBytes(floatSeries, sample)
+	}
+	var exemplar []byte
+	exemplar = protowire.AppendTag(exemplar, 2, protowire.Fixed64Type) // value
+	exemplar = protowire.AppendFixed64(exemplar, math.Float64bits(2))
+	exemplar = protowire.AppendTag(exemplar, 3, protowire.VarintType) // timestamp
+	exemplar = protowire.AppendVarint(exemplar, uint64(ts))
+	floatSeries = protowire.AppendTag(floatSeries, 4, protowire.BytesType) // exemplars
+	floatSeries = protowire.AppendBytes(floatSeries, exemplar)
+
+	var histogramSeries []byte
+	histogramSeries = protowire.AppendTag(histogramSeries, 1, protowire.BytesType) // labels_refs
+	histogramSeries = protowire.AppendBytes(histogramSeries, refs(1, 5, 3, 4))
+	var histogram []byte
+	histogram = protowire.AppendTag(histogram, 1, protowire.VarintType) // count_int
+	histogram = protowire.AppendVarint(histogram, 0)
+	histogram = protowire.AppendTag(histogram, 3, protowire.Fixed64Type) // sum
+	histogram = protowire.AppendFixed64(histogram, math.Float64bits(0))
+	histogram = protowire.AppendTag(histogram, 4, protowire.VarintType) // schema
+	histogram = protowire.AppendVarint(histogram, protowire.EncodeZigZag(0))
+	histogram = protowire.AppendTag(histogram, 15, protowire.VarintType) // timestamp
+	histogram = protowire.AppendVarint(histogram, uint64(ts))
+	histogramSeries = protowire.AppendTag(histogramSeries, 3, protowire.BytesType) // histograms
+	histogramSeries = protowire.AppendBytes(histogramSeries, histogram)
+
+	var req []byte
+	for _, sym := range symbols {
+		req = protowire.AppendTag(req, 4, protowire.BytesType) // symbols
+		req = protowire.AppendString(req, sym)
+	}
+	for _, series := range [][]byte{floatSeries, histogramSeries} {
+		req = protowire.AppendTag(req, 5, protowire.BytesType) // timeseries
+		req = protowire.AppendBytes(req, series)
+	}
+	return req
+}
@@ -0,0 +1,50 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCheckRemoteWriteReceiver(t *testing.T) {
+	s := NewRemoteWriteServer()
+	defer s.Close()
+
+	if err := CheckRemoteWriteReceiver(s.Config.Handler); err != nil {
+		t.Errorf("fake receiver not conformant: %v", err)
+	}
+
+	// A receiver that accepts everything without reporting stats.
+	sloppy := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	err := CheckRemoteWriteReceiver(sloppy)
+	if err == nil {
+		t.Fatal("expected sloppy receiver to fail the checks")
+	}
+	for _, want := range []string{
+		`well-formed request: expected header X-Prometheus-Remote-Write-Samples-Written to be "2", got ""`,
+		"unsupported content type: expected status code 415, got 204",
+		"unsupported content encoding: expected status code 415, got 204",
+		"undecodable snappy payload: expected status code 400, got 204",
+		"invalid protobuf: expected status code 400, got 204",
+		"unresolvable symbol reference: expected status code 400, got 204",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got: %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/klauspost/compress/s2"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// encodeTestRemoteWriteV2 encodes a request with a single series with the
+// labels __name__="foo" and job="bar", the provided samples, and one exemplar.
+func encodeTestRemoteWriteV2(samples ...RemoteWriteSample) []byte {
+	var ts []byte
+	ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+	ts = protowire.AppendBytes(ts, []byte{1, 2, 3, 4})
+	for _, s := range samples {
+		var sb []byte
+		sb = protowire.AppendTag(sb, 1, protowire.Fixed64Type)
+		sb = protowire.AppendFixed64(sb, math.Float64bits(s.Value))
+		sb = protowire.AppendTag(sb, 2, protowire.VarintType)
+		sb = protowire.AppendVarint(sb, uint64(s.Timestamp))
+		ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, sb)
+	}
+	ts = protowire.AppendTag(ts, 4, protowire.BytesType)
+	ts = protowire.AppendBytes(ts, nil)
+	var md []byte
+	md = protowire.AppendTag(md, 3, protowire.VarintType)
+	md = protowire.AppendVarint(md, 5)
+	ts = protowire.AppendTag(ts, 5, protowire.BytesType)
+	ts = protowire.AppendBytes(ts, md)
+
+	var req []byte
+	for _, sym := range []string{"", "__name__", "foo", "job", "bar", "Some help."} {
+		req = protowire.AppendTag(req, 4, protowire.BytesType)
+		req = protowire.AppendString(req, sym)
+	}
+	req = protowire.AppendTag(req, 5, protowire.BytesType)
+	req = protowire.AppendBytes(req, ts)
+	return s2.EncodeSnappy(nil, req)
+}
+
+func postRemoteWrite(t *testing.T, url, contentType string, body []byte) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "2.0.0")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestRemoteWriteServer(t *testing.T) {
+	srv := NewRemoteWriteServer()
+	defer srv.Close()
+
+	samples := []RemoteWriteSample{{Value: 1.5, Timestamp: 1000}, {Value: 2.5, Timestamp: 2000}}
+	resp := postRemoteWrite(t, srv.URL, RemoteWriteV2ContentType, encodeTestRemoteWriteV2(samples...))
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+	for header, want := range map[string]string{
+		remoteWriteSamplesWrittenHeader:    "2",
+		remoteWriteHistogramsWrittenHeader: "0",
+		remoteWriteExemplarsWrittenHeader:  "1",
+	} {
+		if got := resp.Header.Get(header); got != want {
+			t.Errorf("got %s=%q, want %q", header, got, want)
+		}
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("got %d requests, want 1", len(reqs))
+	}
+	want := []RemoteWriteSeries{{
+		Labels:    map[string]string{"__name__": "foo", "job": "bar"},
+		Samples:   samples,
+		Exemplars: 1,
+		Help:      "Some help.",
+	}}
+	if got := reqs[0].Series; !reflect.DeepEqual(got, want) {
+		t.Errorf("got series %+v, want %+v", got, want)
+	}
+	if got := reqs[0].Header.Get("X-Prometheus-Remote-Write-Version"); got != "2.0.0" {
+		t.Errorf("got version header %q", got)
+	}
+
+	srv.SetStatus(http.StatusServiceUnavailable)
+	resp = postRemoteWrite(t, srv.URL, RemoteWriteV2ContentType, encodeTestRemoteWriteV2(samples[0]))
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("got status %d, want %d", resp.StatusCode, http.StatusServiceUnavailable)
+	}
+	if got, want := srv.Stats(), (RemoteWriteStats{Samples: 2, Exemplars: 1}); got != want {
+		t.Errorf("got stats %+v, want %+v", got, want)
+	}
+	if got := len(srv.Requests()); got != 2 {
+		t.Errorf("got %d requests, want 2", got)
+	}
+}
+
+func TestRemoteWriteServerRejectsInvalidRequests(t *testing.T) {
+	srv := NewRemoteWriteServer()
+	defer srv.Close()
+
+	scenarios := map[string]struct {
+		contentType string
+		body        []byte
+		want        int
+	}{
+		"remote write 1.0": {
+			contentType: "application/x-protobuf",
+			body:        encodeTestRemoteWriteV2(),
+			want:        http.StatusUnsupportedMediaType,
+		},
+		"not compressed": {
+			contentType: RemoteWriteV2ContentType,
+			body:        []byte("garbage"),
+			want:        http.StatusBadRequest,
+		},
+		"invalid protobuf": {
+			contentType: RemoteWriteV2ContentType,
+			body:        s2.EncodeSnappy(nil, []byte{0xff}),
+			want:        http.StatusBadRequest,
+		},
+	}
+	for name, s := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			resp := postRemoteWrite(t, srv.URL, s.contentType, s.body)
+			if resp.StatusCode != s.want {
+				t.Errorf("got status %d, want %d", resp.StatusCode, s.want)
+			}
+		})
+	}
+	if got := len(srv.Requests()); got != 0 {
+		t.Errorf("got %d recorded requests, want 0", got)
+	}
+}
@@ -35,6 +35,12 @@
 // In a similar pattern, CollectAndLint and GatherAndLint can be used to detect
 // metrics that have issues with their name, type, or metadata without being
 // necessarily invalid, e.g. a counter with a name missing the “_total” suffix.
+//
+// Code pushing metrics rather than exposing them can be tested end-to-end
+// against the in-process fakes provided by NewPushgatewayServer and
+// NewRemoteWriteServer, which record everything they receive. Conversely,
+// implementations of remote write receivers can be checked for conformance with
+// the Remote Write 2.0 specification with CheckRemoteWriteReceiver.
 package testutil
 
 import (
@@ -235,26 +241,50 @@ func TransactionalGatherAndCompare(g prometheus.TransactionalGatherer, expected
 }
 
 // CollectAndFormat collects the metrics identified by `metricNames` and returns them in the given format.
+// All exposition formats known to expfmt are supported, i.e. expfmt.TypeTextPlain,
+// expfmt.TypeOpenMetrics (including the final "# EOF" line), expfmt.TypeProtoText,
+// expfmt.TypeProtoCompact, and expfmt.TypeProtoDelim.
 func CollectAndFormat(c prometheus.Collector, format expfmt.FormatType, metricNames ...string) ([]byte, error) {
 	reg := prometheus.NewPedanticRegistry()
 	if err := reg.Register(c); err != nil {
 		return nil, fmt.Errorf("registering collector failed: %w", err)
 	}
+	return GatherAndFormat(reg, format, metricNames...)
+}
 
-	gotFiltered, err := reg.Gather()
+// GatherAndFormat gathers all metrics from the provided Gatherer and returns
+// the ones identified by `metricNames` (or all of them if no metricNames are
+// provided) in the given format. It supports the same formats as
+// CollectAndFormat.
+func GatherAndFormat(g prometheus.Gatherer, format expfmt.FormatType, metricNames ...string) ([]byte, error) {
+	gotFiltered, err := g.Gather()
 	if err != nil {
 		return nil, fmt.Errorf("gathering metrics failed: %w", err)
 	}
 
 	gotFiltered = filterMetrics(gotFiltered, metricNames)
+	return formatMetricFamilies(gotFiltered, format)
+}
+
+// formatMetricFamilies encodes mfs in the given format.
+func formatMetricFamilies(mfs []*dto.MetricFamily, format expfmt.FormatType) ([]byte, error) {
+	f := expfmt.NewFormat(format)
+	if f == expfmt.FmtUnknown {
+		return nil, fmt.Errorf("unknown exposition format type %d", format)
+	}
 
 	var gotFormatted bytes.Buffer
-	enc := expfmt.NewEncoder(&gotFormatted, expfmt.NewFormat(format))
-	for _, mf := range gotFiltered {
+	enc := expfmt.NewEncoder(&gotFormatted, f)
+	for _, mf := range mfs {
 		if err := enc.Encode(mf); err != nil {
 			return nil, fmt.Errorf("encoding gathered metrics failed: %w", err)
 		}
 	}
+	if closer, ok := enc.(expfmt.Closer); ok {
+		if err := closer.Close(); err != nil {
+			return nil, fmt.Errorf("finalizing encoding failed: %w", err)
+		}
+	}
 
 	return gotFormatted.Bytes(), nil
 }
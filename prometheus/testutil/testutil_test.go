@@ -462,3 +462,31 @@ foo_bar{fizz="bang"} 1
 		t.Errorf("unexpected metric output, got %q, expected %q", gotS, expected)
 	}
 }
+
+func TestCollectAndFormatOpenMetrics(t *testing.T) {
+	const expected = `# HELP foo_bar A value that represents the number of bars in foo.
+# TYPE foo_bar counter
+foo_bar_total{fizz="bang"} 1.0
+# EOF
+`
+	c := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "foo_bar_total",
+			Help: "A value that represents the number of bars in foo.",
+		},
+		[]string{"fizz"},
+	)
+	c.WithLabelValues("bang").Inc()
+
+	got, err := CollectAndFormat(c, expfmt.TypeOpenMetrics, "foo_bar_total")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if string(got) != expected {
+		t.Errorf("unexpected metric output, got %q, expected %q", got, expected)
+	}
+
+	if _, err := CollectAndFormat(c, expfmt.FormatType(-1)); err == nil {
+		t.Error("expected error for unknown format type")
+	}
+}
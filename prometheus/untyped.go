@@ -38,5 +38,5 @@ func NewUntypedFunc(opts UntypedOpts, function func() float64) UntypedFunc {
 		opts.Help,
 		nil,
 		opts.ConstLabels,
-	), UntypedValue, function)
+	).withMetadata(opts.Metadata), UntypedValue, function)
 }
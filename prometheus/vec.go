@@ -14,6 +14,7 @@
 package prometheus
 
 import (
+	"container/list"
 	"fmt"
 	"sync"
 
@@ -33,6 +34,26 @@ import (
 // (*FooVec, error) rather than (*MetricVec, error). It is recommended to also
 // add the convenience methods WithLabelValues, With, and MustCurryWith, which
 // panic instead of returning errors. See also the MetricVec example.
+//
+// # Consistency under concurrent modification
+//
+// All methods of MetricVec are safe for concurrent use. Collecting a MetricVec
+// (usually as part of a Gather call of a Registry) observes the set of its
+// children atomically with respect to Reset, Rebuild, and the Delete methods:
+// The children collected in one Collect call are either all from before or all
+// from after such a modification, even if the modification happens while the
+// collected metrics are still being written. Thus, an exposition never mixes
+// children from before and after a Reset of the same vector.
+//
+// However, a sequence of modifications is not atomic. If a Reset is followed
+// by creating new children (e.g. in an exporter that periodically re-creates
+// the current state from scratch), a Collect call might happen in between and
+// observe an empty or partially populated vector. Use Rebuild to make such a
+// sequence appear atomic to Collect.
+//
+// A child removed by Reset, Rebuild, or a Delete method is not modified by the
+// removal. Holders of a reference to such a child may still update it, but
+// those updates are not collected anymore.
 type MetricVec struct {
 	*metricMap
 
@@ -41,6 +62,9 @@ type MetricVec struct {
 	// hashAdd and hashAddByte can be replaced for testing collision handling.
 	hashAdd     func(h uint64, s string) uint64
 	hashAddByte func(h uint64, b byte) uint64
+
+	// curryCache caches the vectors returned by CurryWith.
+	curryCache curryCache
 }
 
 // NewMetricVec returns an initialized metricVec.
@@ -129,6 +153,27 @@ func (m *MetricVec) Collect(ch chan<- Metric) { m.metricMap.Collect(ch) }
 // Reset deletes all metrics in this vector.
 func (m *MetricVec) Reset() { m.metricMap.Reset() }
 
+// Rebuild deletes all metrics in this vector and calls populate to create new
+// ones, e.g. with the With or WithLabelValues methods of the vector. Collecting
+// the vector while populate is running still observes the metrics from before
+// the Rebuild call. Once populate returns, all metrics created in the meantime
+// become visible at once, replacing the previous ones. If populate panics, the
+// vector keeps the metrics from before the Rebuild call, and the panic is
+// propagated.
+//
+// Metrics created or retrieved during populate are already the new ones,
+// including those retrieved concurrently by other goroutines. Updates of the
+// previous metrics are lost, just as after a Reset. Deleting metrics during
+// populate deletes them from both the previous and the new metrics. In that
+// case, the number returned by DeletePartialMatch is the sum of the deleted
+// previous and new metrics.
+//
+// As with Reset, Rebuild affects all metrics, even if called on a curried
+// vector. Concurrent calls of Rebuild are serialized. populate must not call
+// Rebuild of the same vector (or a vector curried from it), as that results in
+// a deadlock.
+func (m *MetricVec) Rebuild(populate func()) { m.metricMap.rebuild(populate) }
+
 // CurryWith returns a vector curried with the provided labels, i.e. the
 // returned vector has those labels pre-set for all labeled operations performed
 // on it. The cardinality of the curried vector is reduced accordingly. The
@@ -146,7 +191,38 @@ func (m *MetricVec) Reset() { m.metricMap.Reset() }
 // Note that CurryWith is usually not called directly but through a wrapper
 // around MetricVec, implementing a vector for a specific Metric
 // implementation, for example GaugeVec.
+//
+// The most recently curried vectors are cached (up to a small fixed number per
+// vector). Calling CurryWith repeatedly with the same labels, as it is common
+// in per-request code (e.g. currying with the tenant of the request), returns
+// the cached vector without allocating or constraining the label values again.
 func (m *MetricVec) CurryWith(labels Labels) (*MetricVec, error) {
+	key := m.curryCacheKey(labels)
+	if cached := m.curryCache.get(key, labels); cached != nil {
+		return cached, nil
+	}
+	curried, err := m.curryWith(labels)
+	if err != nil {
+		return nil, err
+	}
+	m.curryCache.add(key, labels, curried)
+	return curried, nil
+}
+
+// curryCacheKey returns a hash of the provided labels that does not depend on
+// the iteration order of the map.
+func (m *MetricVec) curryCacheKey(labels Labels) uint64 {
+	var key uint64
+	for name, value := range labels {
+		h := m.hashAdd(hashNew(), name)
+		h = m.hashAddByte(h, model.SeparatorByte)
+		h = m.hashAdd(h, value)
+		key ^= h
+	}
+	return key
+}
+
+func (m *MetricVec) curryWith(labels Labels) (*MetricVec, error) {
 	var (
 		newCurry []curriedLabelValue
 		oldCurry = m.curry
@@ -315,10 +391,24 @@ type curriedLabelValue struct {
 // metricMap is a helper for metricVec and shared between differently curried
 // metricVecs.
 type metricMap struct {
-	mtx       sync.RWMutex // Protects metrics.
+	mtx       profiledRWMutex // Protects metrics and staging.
 	metrics   map[uint64][]metricWithLabelValues
 	desc      *Desc
 	newMetric func(labelValues ...string) Metric
+
+	// staging is only non-nil while a rebuild is in progress. It then
+	// receives all newly created metrics, while metrics is still the one
+	// that gets collected.
+	staging    map[uint64][]metricWithLabelValues
+	rebuildMtx sync.Mutex // Serializes rebuilds.
+
+	// expectedAbsent contains the label values marked with
+	// MarkExpectedAbsent (with nil metrics). Protected by mtx.
+	expectedAbsent map[uint64][]metricWithLabelValues
+
+	// onDelete, if not nil, is called with every metric removed from the
+	// map while holding mtx.
+	onDelete func(Metric)
 }
 
 // Describe implements Collector. It will send exactly one Desc to the provided
@@ -344,38 +434,112 @@ func (m *metricMap) Reset() {
 	m.mtx.Lock()
 	defer m.mtx.Unlock()
 
-	for h := range m.metrics {
+	for h, metrics := range m.metrics {
+		m.deleted(metrics...)
 		delete(m.metrics, h)
 	}
+	for h, metrics := range m.staging {
+		m.deleted(metrics...)
+		delete(m.staging, h)
+	}
 }
 
-// deleteByHashWithLabelValues removes the metric from the hash bucket h. If
-// there are multiple matches in the bucket, use lvs to select a metric and
-// remove only that metric.
-func (m *metricMap) deleteByHashWithLabelValues(
-	h uint64, lvs []string, curry []curriedLabelValue,
-) bool {
+// deleted calls onDelete for the provided metrics, if set. Must be called
+// while holding the write mutex.
+func (m *metricMap) deleted(metrics ...metricWithLabelValues) {
+	if m.onDelete == nil {
+		return
+	}
+	for _, metric := range metrics {
+		m.onDelete(metric.metric)
+	}
+}
+
+// rebuild implements MetricVec.Rebuild.
+func (m *metricMap) rebuild(populate func()) {
+	m.rebuildMtx.Lock()
+	defer m.rebuildMtx.Unlock()
+
 	m.mtx.Lock()
-	defer m.mtx.Unlock()
+	m.staging = map[uint64][]metricWithLabelValues{}
+	m.mtx.Unlock()
+
+	completed := false
+	defer func() {
+		m.mtx.Lock()
+		defer m.mtx.Unlock()
+		if completed {
+			for _, metrics := range m.metrics {
+				m.deleted(metrics...)
+			}
+			m.metrics = m.staging
+		} else {
+			for _, metrics := range m.staging {
+				m.deleted(metrics...)
+			}
+		}
+		m.staging = nil
+	}()
+	populate()
+	completed = true
+}
 
-	metrics, ok := m.metrics[h]
-	if !ok {
-		return false
+// current returns the map that metrics are retrieved from and created in,
+// i.e. the staging map during a rebuild and the collected map otherwise. Must
+// be called while holding the read mutex.
+func (m *metricMap) current() map[uint64][]metricWithLabelValues {
+	if m.staging != nil {
+		return m.staging
 	}
+	return m.metrics
+}
 
-	i := findMetricWithLabelValues(metrics, lvs, curry)
-	if i >= len(metrics) {
-		return false
+// modifiable returns all maps a deletion has to be applied to. Must be called
+// while holding the write mutex.
+func (m *metricMap) modifiable() []map[uint64][]metricWithLabelValues {
+	if m.staging != nil {
+		return []map[uint64][]metricWithLabelValues{m.metrics, m.staging}
 	}
+	return []map[uint64][]metricWithLabelValues{m.metrics}
+}
 
+// deleteFromBucket removes the metric with index i from the hash bucket h of
+// the provided map.
+func deleteFromBucket(metricsByHash map[uint64][]metricWithLabelValues, h uint64, i int) {
+	metrics := metricsByHash[h]
 	if len(metrics) > 1 {
 		old := metrics
-		m.metrics[h] = append(metrics[:i], metrics[i+1:]...)
+		metricsByHash[h] = append(metrics[:i], metrics[i+1:]...)
 		old[len(old)-1] = metricWithLabelValues{}
 	} else {
-		delete(m.metrics, h)
+		delete(metricsByHash, h)
 	}
-	return true
+}
+
+// deleteByHashWithLabelValues removes the metric from the hash bucket h. If
+// there are multiple matches in the bucket, use lvs to select a metric and
+// remove only that metric.
+func (m *metricMap) deleteByHashWithLabelValues(
+	h uint64, lvs []string, curry []curriedLabelValue,
+) bool {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	deleted := false
+	for _, metricsByHash := range m.modifiable() {
+		metrics, ok := metricsByHash[h]
+		if !ok {
+			continue
+		}
+		i := findMetricWithLabelValues(metrics, lvs, curry)
+		if i >= len(metrics) {
+			continue
+		}
+		m.deleted(metrics[i])
+		deleteFromBucket(metricsByHash, h, i)
+		deleted = true
+	}
+	return deleted
 }
 
 // deleteByHashWithLabels removes the metric from the hash bucket h. If there
@@ -387,23 +551,21 @@ func (m *metricMap) deleteByHashWithLabels(
 	m.mtx.Lock()
 	defer m.mtx.Unlock()
 
-	metrics, ok := m.metrics[h]
-	if !ok {
-		return false
-	}
-	i := findMetricWithLabels(m.desc, metrics, labels, curry)
-	if i >= len(metrics) {
-		return false
-	}
-
-	if len(metrics) > 1 {
-		old := metrics
-		m.metrics[h] = append(metrics[:i], metrics[i+1:]...)
-		old[len(old)-1] = metricWithLabelValues{}
-	} else {
-		delete(m.metrics, h)
+	deleted := false
+	for _, metricsByHash := range m.modifiable() {
+		metrics, ok := metricsByHash[h]
+		if !ok {
+			continue
+		}
+		i := findMetricWithLabels(m.desc, metrics, labels, curry)
+		if i >= len(metrics) {
+			continue
+		}
+		m.deleted(metrics[i])
+		deleteFromBucket(metricsByHash, h, i)
+		deleted = true
 	}
-	return true
+	return deleted
 }
 
 // deleteByLabels deletes a metric if the given labels are present in the metric.
@@ -413,14 +575,17 @@ func (m *metricMap) deleteByLabels(labels Labels, curry []curriedLabelValue) int
 
 	var numDeleted int
 
-	for h, metrics := range m.metrics {
-		i := findMetricWithPartialLabels(m.desc, metrics, labels, curry)
-		if i >= len(metrics) {
-			// Didn't find matching labels in this metric slice.
-			continue
+	for _, metricsByHash := range m.modifiable() {
+		for h, metrics := range metricsByHash {
+			i := findMetricWithPartialLabels(m.desc, metrics, labels, curry)
+			if i >= len(metrics) {
+				// Didn't find matching labels in this metric slice.
+				continue
+			}
+			m.deleted(metrics...)
+			delete(metricsByHash, h)
+			numDeleted++
 		}
-		delete(m.metrics, h)
-		numDeleted++
 	}
 
 	return numDeleted
@@ -502,7 +667,9 @@ func (m *metricMap) getOrCreateMetricWithLabelValues(
 	if !ok {
 		inlinedLVs := inlineLabelValues(lvs, curry)
 		metric = m.newMetric(inlinedLVs...)
-		m.metrics[hash] = append(m.metrics[hash], metricWithLabelValues{values: inlinedLVs, metric: metric})
+		metrics := m.current()
+		metrics[hash] = append(metrics[hash], metricWithLabelValues{values: inlinedLVs, metric: metric})
+		m.unmarkExpectedAbsent(hash, inlinedLVs)
 	}
 	return metric
 }
@@ -527,7 +694,9 @@ func (m *metricMap) getOrCreateMetricWithLabels(
 	if !ok {
 		lvs := extractLabelValues(m.desc, labels, curry)
 		metric = m.newMetric(lvs...)
-		m.metrics[hash] = append(m.metrics[hash], metricWithLabelValues{values: lvs, metric: metric})
+		metrics := m.current()
+		metrics[hash] = append(metrics[hash], metricWithLabelValues{values: lvs, metric: metric})
+		m.unmarkExpectedAbsent(hash, lvs)
 	}
 	return metric
 }
@@ -537,7 +706,7 @@ func (m *metricMap) getOrCreateMetricWithLabels(
 func (m *metricMap) getMetricWithHashAndLabelValues(
 	h uint64, lvs []string, curry []curriedLabelValue,
 ) (Metric, bool) {
-	metrics, ok := m.metrics[h]
+	metrics, ok := m.current()[h]
 	if ok {
 		if i := findMetricWithLabelValues(metrics, lvs, curry); i < len(metrics) {
 			return metrics[i].metric, true
@@ -551,7 +720,7 @@ func (m *metricMap) getMetricWithHashAndLabelValues(
 func (m *metricMap) getMetricWithHashAndLabels(
 	h uint64, labels Labels, curry []curriedLabelValue,
 ) (Metric, bool) {
-	metrics, ok := m.metrics[h]
+	metrics, ok := m.current()[h]
 	if ok {
 		if i := findMetricWithLabels(m.desc, metrics, labels, curry); i < len(metrics) {
 			return metrics[i].metric, true
@@ -707,3 +876,92 @@ func constrainLabelValues(desc *Desc, lvs []string, curry []curriedLabelValue) [
 	}
 	return constrainedValues
 }
+
+// curryCacheSize is the maximum number of curried vectors cached per vector.
+const curryCacheSize = 64
+
+// curryCache is a bounded LRU cache of curried vectors, keyed by the labels
+// used for currying. Its zero value is ready to use.
+type curryCache struct {
+	mtx     sync.Mutex
+	entries map[uint64][]*list.Element // Several entries in case of hash collisions.
+	lru     list.List                  // Elements are *curryCacheEntry, most recently used first.
+}
+
+type curryCacheEntry struct {
+	key    uint64
+	labels Labels
+	vec    *MetricVec
+}
+
+// get returns the cached vector for the provided labels (which hash to key), or
+// nil if there is none.
+func (c *curryCache) get(key uint64, labels Labels) *MetricVec {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for _, e := range c.entries[key] {
+		entry := e.Value.(*curryCacheEntry)
+		if labelsEqual(entry.labels, labels) {
+			c.lru.MoveToFront(e)
+			return entry.vec
+		}
+	}
+	return nil
+}
+
+// add caches vec for the provided labels (which hash to key), evicting the
+// least recently used entry if the cache is full.
+func (c *curryCache) add(key uint64, labels Labels, vec *MetricVec) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.entries == nil {
+		c.entries = map[uint64][]*list.Element{}
+	}
+	for _, e := range c.entries[key] {
+		if labelsEqual(e.Value.(*curryCacheEntry).labels, labels) {
+			return // Added concurrently.
+		}
+	}
+	if c.lru.Len() >= curryCacheSize {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		c.remove(oldest)
+	}
+	copied := make(Labels, len(labels))
+	for name, value := range labels {
+		copied[name] = value
+	}
+	e := c.lru.PushFront(&curryCacheEntry{key: key, labels: copied, vec: vec})
+	c.entries[key] = append(c.entries[key], e)
+}
+
+// remove removes e from c.entries. The caller must have locked c.mtx.
+func (c *curryCache) remove(e *list.Element) {
+	key := e.Value.(*curryCacheEntry).key
+	elements := c.entries[key]
+	for i, other := range elements {
+		if other == e {
+			elements = append(elements[:i], elements[i+1:]...)
+			break
+		}
+	}
+	if len(elements) == 0 {
+		delete(c.entries, key)
+		return
+	}
+	c.entries[key] = elements
+}
+
+func labelsEqual(a, b Labels) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, value := range a {
+		if other, ok := b[name]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
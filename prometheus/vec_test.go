@@ -16,7 +16,9 @@ package prometheus
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
+	"sync"
 	"testing"
 
 	dto "github.com/prometheus/client_model/go"
@@ -470,6 +472,204 @@ func TestCurryVecWithCollisions(t *testing.T) {
 	testCurryVec(t, vec)
 }
 
+func TestCurryVecCache(t *testing.T) {
+	vec := NewCounterVec(
+		CounterOpts{
+			Name: "test",
+			Help: "helpless",
+		},
+		[]string{"one", "two", "three"},
+	)
+	a1 := vec.MustCurryWith(Labels{"one": "a", "two": "b"})
+	a2 := vec.MustCurryWith(Labels{"two": "b", "one": "a"})
+	if a1.MetricVec != a2.MetricVec {
+		t.Error("currying with the same labels did not return the cached vector")
+	}
+	if b := vec.MustCurryWith(Labels{"one": "a", "two": "c"}); b.MetricVec == a1.MetricVec {
+		t.Error("currying with different labels returned the same vector")
+	}
+	if _, err := vec.CurryWith(Labels{"four": "x"}); err == nil {
+		t.Error("expected error currying with unknown label")
+	}
+	if _, err := vec.CurryWith(Labels{"four": "x"}); err == nil {
+		t.Error("expected error currying with unknown label again")
+	}
+
+	// The curried vectors still share their metrics.
+	a1.WithLabelValues("x").Inc()
+	a2.WithLabelValues("x").Inc()
+	if got := counterValue(t, vec.WithLabelValues("a", "b", "x")); got != 2 {
+		t.Errorf("got %v, want 2", got)
+	}
+
+	// Fill the cache to evict the first entry.
+	for i := 0; i < curryCacheSize; i++ {
+		vec.MustCurryWith(Labels{"one": strconv.Itoa(i)})
+	}
+	if vec.curryCache.lru.Len() != curryCacheSize {
+		t.Errorf("got %d cached vectors, want %d", vec.curryCache.lru.Len(), curryCacheSize)
+	}
+	if a3 := vec.MustCurryWith(Labels{"one": "a", "two": "b"}); a3.MetricVec == a1.MetricVec {
+		t.Error("expected evicted vector to be curried anew")
+	}
+}
+
+func counterValue(t *testing.T, c Counter) float64 {
+	t.Helper()
+	m := &dto.Metric{}
+	if err := c.Write(m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+func TestMetricVecResetConsistency(t *testing.T) {
+	vec := NewGaugeVec(GaugeOpts{Name: "test", Help: "helpless"}, []string{"l"})
+	reg := NewRegistry()
+	reg.MustRegister(vec)
+
+	const children = 20
+	populate := func(generation float64) {
+		for i := 0; i < children; i++ {
+			vec.WithLabelValues(strconv.Itoa(i)).Set(generation)
+		}
+	}
+	populate(0)
+
+	var (
+		wg   sync.WaitGroup
+		done = make(chan struct{})
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for gen := 1; ; gen++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if gen%2 == 0 {
+				vec.Rebuild(func() { populate(float64(gen)) })
+				continue
+			}
+			// A Reset followed by re-creation is not atomic, but a
+			// single Collect must never see a mix of both.
+			vec.Reset()
+			populate(float64(gen))
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		mfs, err := reg.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(mfs) == 0 {
+			continue // Gathered right after a Reset.
+		}
+		generations := map[float64]struct{}{}
+		for _, m := range mfs[0].GetMetric() {
+			// Children created after a Reset are zero until set, so
+			// ignore zero values.
+			if v := m.GetGauge().GetValue(); v != 0 {
+				generations[v] = struct{}{}
+			}
+		}
+		if len(generations) > 1 {
+			t.Fatalf("gathered children of several generations in one exposition: %v", generations)
+		}
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestMetricVecRebuild(t *testing.T) {
+	vec := NewCounterVec(CounterOpts{Name: "test", Help: "helpless"}, []string{"l"})
+	vec.WithLabelValues("old").Inc()
+	vec.WithLabelValues("gone").Inc()
+
+	collected := func() []string {
+		ch := make(chan Metric, 10)
+		vec.Collect(ch)
+		close(ch)
+		var lvs []string
+		for m := range ch {
+			pb := &dto.Metric{}
+			if err := m.Write(pb); err != nil {
+				t.Fatal(err)
+			}
+			lvs = append(lvs, pb.GetLabel()[0].GetValue())
+		}
+		sort.Strings(lvs)
+		return lvs
+	}
+
+	vec.Rebuild(func() {
+		vec.WithLabelValues("old").Add(5)
+		vec.WithLabelValues("new").Inc()
+		vec.WithLabelValues("gone").Inc()
+		if got, want := collected(), []string{"gone", "old"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("collected %v during rebuild, want %v", got, want)
+		}
+		if !vec.DeleteLabelValues("gone") {
+			t.Error("expected deletion during rebuild to succeed")
+		}
+		if got, want := collected(), []string{"old"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("collected %v after deletion during rebuild, want %v", got, want)
+		}
+	})
+	if got, want := collected(), []string{"new", "old"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("collected %v after rebuild, want %v", got, want)
+	}
+	if got := counterValue(t, vec.WithLabelValues("old")); got != 5 {
+		t.Errorf("got %v for rebuilt child, want 5", got)
+	}
+
+	// A panicking rebuild keeps the previous children.
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic to be propagated")
+			}
+		}()
+		vec.Rebuild(func() {
+			vec.WithLabelValues("partial").Inc()
+			panic("populate failed")
+		})
+	}()
+	if got, want := collected(), []string{"new", "old"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("collected %v after failed rebuild, want %v", got, want)
+	}
+	vec.WithLabelValues("after").Inc()
+	if got, want := collected(), []string{"after", "new", "old"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("collected %v after creating a child, want %v", got, want)
+	}
+}
+
+func TestMetricVecRebuildDeletePartialMatch(t *testing.T) {
+	vec := NewCounterVec(CounterOpts{Name: "test", Help: "helpless"}, []string{"l1", "l2"})
+	vec.WithLabelValues("a", "old").Inc()
+	vec.WithLabelValues("a", "both").Inc()
+	vec.WithLabelValues("b", "old").Inc()
+
+	vec.Rebuild(func() {
+		vec.WithLabelValues("a", "both").Inc()
+		vec.WithLabelValues("a", "new").Inc()
+		vec.WithLabelValues("b", "new").Inc()
+		// Two previous and two new metrics match.
+		if got, want := vec.DeletePartialMatch(Labels{"l1": "a"}), 4; got != want {
+			t.Errorf("got %d deleted metrics during rebuild, want %d", got, want)
+		}
+	})
+	ch := make(chan Metric, 10)
+	vec.Collect(ch)
+	close(ch)
+	if got, want := len(ch), 1; got != want {
+		t.Errorf("collected %d metrics after rebuild, want %d", got, want)
+	}
+}
+
 func TestCurryVecWithConstraints(t *testing.T) {
 	constraint := func(s string) string { return "x" + s }
 	t.Run("constrainedLabels overlap variableLabels", func(t *testing.T) {
@@ -1004,3 +1204,19 @@ func benchmarkMetricVecWithLabelValues(b *testing.B, labels map[string][]string)
 		vec.WithLabelValues(values...)
 	}
 }
+
+func BenchmarkMetricVecCurryWith(b *testing.B) {
+	vec := NewCounterVec(
+		CounterOpts{
+			Name: "benchmark_vec",
+			Help: "A vector for benchmarking.",
+		},
+		[]string{"tenant", "code"},
+	)
+	tenants := []string{"a", "b", "c", "d"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vec.MustCurryWith(Labels{"tenant": tenants[i%len(tenants)]})
+	}
+}
@@ -42,6 +42,11 @@ import (
 // either Registerer will contain the ExistingCollector in the form it was
 // provided to the respective registry.
 //
+// Apart from the added labels, the collected Metrics are written unchanged. In
+// particular, exemplars (including those of native histograms), created
+// timestamps, and explicit timestamps (see NewMetricWithTimestamp) are
+// preserved.
+//
 // The Collector example demonstrates a use of WrapRegistererWith.
 func WrapRegistererWith(labels Labels, reg Registerer) Registerer {
 	return &wrappingRegisterer{
@@ -71,6 +76,9 @@ func WrapRegistererWith(labels Labels, reg Registerer) Registerer {
 // detected. Any AlreadyRegisteredError returned by the Register method of
 // either Registerer will contain the ExistingCollector in the form it was
 // provided to the respective registry.
+//
+// As with WrapRegistererWith, exemplars, created timestamps, and explicit
+// timestamps of the collected Metrics are preserved.
 func WrapRegistererWithPrefix(prefix string, reg Registerer) Registerer {
 	return &wrappingRegisterer{
 		wrappedRegisterer: reg,
@@ -106,6 +114,16 @@ func (r *wrappingRegisterer) MustRegister(cs ...Collector) {
 	}
 }
 
+// HistogramDefaults returns the HistogramDefaults of the wrapped Registerer if
+// it has any (see Registry.SetHistogramDefaults), or zero-valued
+// HistogramDefaults otherwise.
+func (r *wrappingRegisterer) HistogramDefaults() HistogramDefaults {
+	if hd, ok := r.wrappedRegisterer.(interface{ HistogramDefaults() HistogramDefaults }); ok {
+		return hd.HistogramDefaults()
+	}
+	return HistogramDefaults{}
+}
+
 func (r *wrappingRegisterer) Unregister(c Collector) bool {
 	if r.wrappedRegisterer == nil {
 		return false
@@ -176,13 +194,18 @@ func (m *wrappingMetric) Write(out *dto.Metric) error {
 		// No wrapping labels.
 		return nil
 	}
+	// The wrapped Metric might have set out.Label to a slice it keeps
+	// using (as most Metrics in this package do), so append to a copy.
+	labels := make([]*dto.LabelPair, len(out.Label), len(out.Label)+len(m.labels))
+	copy(labels, out.Label)
 	for ln, lv := range m.labels {
-		out.Label = append(out.Label, &dto.LabelPair{
+		labels = append(labels, &dto.LabelPair{
 			Name:  proto.String(ln),
 			Value: proto.String(lv),
 		})
 	}
-	sort.Sort(internal.LabelPairSorter(out.Label))
+	sort.Sort(internal.LabelPairSorter(labels))
+	out.Label = labels
 	return nil
 }
 
@@ -204,7 +227,7 @@ func wrapDesc(desc *Desc, prefix string, labels Labels) *Desc {
 		constLabels[ln] = lv
 	}
 	// NewDesc will do remaining validations.
-	newDesc := V2.NewDesc(prefix+desc.fqName, desc.help, desc.variableLabels, constLabels)
+	newDesc := V2.NewDesc(prefix+desc.fqName, desc.help, desc.variableLabels, constLabels).withMetadata(desc.metadata)
 	// Propagate errors if there was any. This will override any errer
 	// created by NewDesc above, i.e. earlier errors get precedence.
 	if desc.err != nil {
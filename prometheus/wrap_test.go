@@ -339,3 +339,124 @@ func TestNil(t *testing.T) {
 		t.Fatal("registering failed:", err)
 	}
 }
+
+// funcCollector is a Collector with a single Desc and a custom collect function.
+type funcCollector struct {
+	desc    *Desc
+	collect func(chan<- Metric)
+}
+
+func (c funcCollector) Describe(ch chan<- *Desc) { ch <- c.desc }
+func (c funcCollector) Collect(ch chan<- Metric) { c.collect(ch) }
+
+func TestWrapPreservesExemplarsAndTimestamps(t *testing.T) {
+	counter := NewCounter(CounterOpts{Name: "counter_total", Help: "helpless"})
+	counter.(ExemplarAdder).AddWithExemplar(1, Labels{"trace_id": "a"})
+
+	classic := NewHistogram(HistogramOpts{Name: "classic", Help: "helpless", Buckets: []float64{1, 2}})
+	classic.(ExemplarObserver).ObserveWithExemplar(1.5, Labels{"trace_id": "b"})
+	classic.(ExemplarObserver).ObserveWithExemplar(3, Labels{"trace_id": "c"}) // +Inf bucket.
+
+	native := NewHistogram(HistogramOpts{
+		Name:                        "native",
+		Help:                        "helpless",
+		NativeHistogramBucketFactor: 1.1,
+		NativeHistogramMaxExemplars: 10,
+	})
+	native.(ExemplarObserver).ObserveWithExemplar(42, Labels{"trace_id": "d"})
+
+	constDesc := NewDesc("const_total", "helpless", nil, nil)
+	constCollector := funcCollector{desc: constDesc, collect: func(ch chan<- Metric) {
+		m := MustNewConstMetricWithCreatedTimestamp(constDesc, CounterValue, 5, time.Unix(1000, 0))
+		m = MustNewMetricWithExemplars(m, Exemplar{Value: 5, Labels: Labels{"trace_id": "e"}, Timestamp: time.Unix(1500, 0)})
+		ch <- NewMetricWithTimestamp(time.Unix(2000, 0), m)
+	}}
+
+	collectors := []Collector{counter, classic, native, constCollector}
+	plain := NewRegistry()
+	wrapped := NewRegistry()
+	plain.MustRegister(collectors...)
+	WrapRegistererWithPrefix("prefix_", WrapRegistererWith(Labels{"wrapped": "yes"}, wrapped)).MustRegister(collectors...)
+
+	want, err := plain.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := wrapped.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d metric families, want %d", len(got), len(want))
+	}
+	for i, wantMF := range want {
+		gotMF := got[i]
+		if gotMF.GetName() != "prefix_"+wantMF.GetName() {
+			t.Errorf("got metric family %q, want prefixed %q", gotMF.GetName(), wantMF.GetName())
+			continue
+		}
+		gotM := proto.Clone(gotMF.Metric[0]).(*dto.Metric)
+		if len(gotM.Label) != 1 || gotM.Label[0].GetName() != "wrapped" {
+			t.Errorf("%s: unexpected labels %v", gotMF.GetName(), gotM.Label)
+		}
+		gotM.Label = nil
+		if !proto.Equal(gotM, wantMF.Metric[0]) {
+			t.Errorf("%s: wrapping changed the metric\ngot:  %v\nwant: %v", gotMF.GetName(), gotM, wantMF.Metric[0])
+		}
+	}
+
+	// Make sure the test actually covers exemplars and timestamps.
+	for _, mf := range want {
+		m := mf.Metric[0]
+		switch mf.GetName() {
+		case "counter_total":
+			if m.GetCounter().GetExemplar() == nil || m.GetCounter().GetCreatedTimestamp() == nil {
+				t.Errorf("%s: missing exemplar or created timestamp", mf.GetName())
+			}
+		case "classic":
+			if len(m.GetHistogram().GetBucket()) != 3 || m.GetHistogram().GetBucket()[2].GetExemplar() == nil {
+				t.Errorf("%s: missing +Inf bucket exemplar", mf.GetName())
+			}
+		case "native":
+			if len(m.GetHistogram().GetExemplars()) != 1 {
+				t.Errorf("%s: missing native histogram exemplar", mf.GetName())
+			}
+		case "const_total":
+			if m.GetTimestampMs() != 2000000 || m.GetCounter().GetExemplar() == nil || m.GetCounter().GetCreatedTimestamp() == nil {
+				t.Errorf("%s: missing timestamp, exemplar, or created timestamp", mf.GetName())
+			}
+		}
+	}
+}
+
+// sharedLabelsMetric writes a label slice it keeps using, with spare capacity.
+type sharedLabelsMetric struct {
+	desc   *Desc
+	labels []*dto.LabelPair
+}
+
+func (m sharedLabelsMetric) Desc() *Desc { return m.desc }
+
+func (m sharedLabelsMetric) Write(out *dto.Metric) error {
+	out.Label = m.labels
+	out.Gauge = &dto.Gauge{Value: proto.Float64(1)}
+	return nil
+}
+
+func TestWrapDoesNotModifyWrappedLabels(t *testing.T) {
+	desc := NewDesc("shared", "helpless", nil, Labels{"a": "1"})
+	labels := make([]*dto.LabelPair, 1, 4)
+	labels[0] = &dto.LabelPair{Name: proto.String("a"), Value: proto.String("1")}
+	m := sharedLabelsMetric{desc: desc, labels: labels}
+
+	reg := NewRegistry()
+	WrapRegistererWith(Labels{"b": "2"}, reg).MustRegister(funcCollector{desc: desc, collect: func(ch chan<- Metric) { ch <- m }})
+	for i := 0; i < 2; i++ {
+		if _, err := reg.Gather(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if spare := labels[:2][1]; spare != nil {
+		t.Errorf("wrapping wrote label %v into the spare capacity of the wrapped metric's labels", spare)
+	}
+}